@@ -0,0 +1,205 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/command"
+	"github.com/atc0005/check-restart/internal/restart/definitions"
+	"github.com/atc0005/check-restart/internal/restart/files"
+	"github.com/atc0005/check-restart/internal/restart/kernel"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+	"github.com/atc0005/check-restart/internal/restart/uptime"
+	"github.com/rs/zerolog"
+)
+
+// assertionSet is the collection of applied assertions along with the
+// assertions of each type (used for performance data).
+type assertionSet struct {
+	all      restart.RebootRequiredAsserters
+	registry restart.RebootRequiredAsserters
+	files    restart.RebootRequiredAsserters
+	kernel   restart.RebootRequiredAsserters
+	commands restart.RebootRequiredAsserters
+	uptime   restart.RebootRequiredAsserters
+}
+
+// getAssertionSet returns the applied assertions. See getAssertions and
+// getUptimeAssertions for the assertions retrieved.
+func getAssertionSet(cfg *config.Config, logger zerolog.Logger) (assertionSet, error) {
+	registryAssertions, fileAssertions, kernelAssertions, commandAssertions, err := getAssertions(cfg)
+	if err != nil {
+		return assertionSet{}, err
+	}
+
+	uptimeAssertions := getUptimeAssertions(cfg)
+
+	logger.Debug().
+		Int("registry_assertions", len(registryAssertions)).
+		Int("file_assertions", len(fileAssertions)).
+		Int("kernel_assertions", len(kernelAssertions)).
+		Int("command_assertions", len(commandAssertions)).
+		Int("uptime_assertions", len(uptimeAssertions)).
+		Str("windows_root", cfg.WindowsRoot).
+		Strs("definitions", cfg.Definitions).
+		Str("definitions_mode", cfg.DefinitionsMode).
+		Msg("Retrieved default reboot assertions")
+
+	logger.Debug().Msg("Finished retrieving reboot assertions")
+
+	allAssertions := make(
+		restart.RebootRequiredAsserters,
+		0,
+		len(registryAssertions)+len(fileAssertions)+len(kernelAssertions)+len(commandAssertions)+len(uptimeAssertions),
+	)
+	allAssertions = append(allAssertions, registryAssertions...)
+	allAssertions = append(allAssertions, fileAssertions...)
+	allAssertions = append(allAssertions, kernelAssertions...)
+	allAssertions = append(allAssertions, commandAssertions...)
+	allAssertions = append(allAssertions, uptimeAssertions...)
+
+	logger.Debug().
+		Int("all_assertions", len(allAssertions)).
+		Msg("All assertions retrieved")
+
+	return assertionSet{
+		all:      allAssertions,
+		registry: registryAssertions,
+		files:    fileAssertions,
+		kernel:   kernelAssertions,
+		commands: commandAssertions,
+		uptime:   uptimeAssertions,
+	}, nil
+}
+
+// getAssertions returns the default registry, file and kernel reboot
+// assertions along with any assertions from definition files. Command
+// assertions are only available from definition files.
+//
+// If the root of an offline Windows system was specified the default
+// Windows registry and file assertions are evaluated against that system
+// (kernel assertions are not applicable). If exported registry files were
+// specified the registry assertions are evaluated against the content of
+// those files instead of the registry of the local system. Assertions from
+// definition files are evaluated using the same sources and either extend
+// or replace the default assertions.
+func getAssertions(cfg *config.Config) (
+	registryAssertions restart.RebootRequiredAsserters,
+	fileAssertions restart.RebootRequiredAsserters,
+	kernelAssertions restart.RebootRequiredAsserters,
+	commandAssertions restart.RebootRequiredAsserters,
+	err error,
+) {
+	var (
+		backend   registry.Backend
+		lookupEnv func(key string) (string, bool)
+	)
+
+	switch {
+	case cfg.WindowsRoot != "":
+		hiveBackend, err := registry.LoadWindowsRoot(cfg.WindowsRoot)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		systemRoot := registry.OfflineSystemRoot(cfg.WindowsRoot, hiveBackend)
+		offlineEnv := map[string]string{
+			"SystemDrive": cfg.WindowsRoot,
+			"SystemRoot":  systemRoot,
+			"windir":      systemRoot,
+		}
+
+		backend = hiveBackend
+
+		// As on Windows, environment variable names are case-insensitive.
+		lookupEnv = func(key string) (string, bool) {
+			for name, value := range offlineEnv {
+				if strings.EqualFold(name, key) {
+					return value, true
+				}
+			}
+			return "", false
+		}
+
+		registryAssertions = registry.DefaultRebootRequiredAssertionsWithBackend(backend)
+		restart.UseEnvLookup(registryAssertions, lookupEnv)
+		fileAssertions = files.DefaultOfflineWindowsRebootRequiredAssertions(lookupEnv)
+		kernelAssertions = restart.RebootRequiredAsserters{}
+
+	case len(cfg.RegistryFiles) > 0:
+		regBackend, err := registry.LoadRegFiles(cfg.RegistryFiles...)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		backend = regBackend
+
+		registryAssertions = registry.DefaultRebootRequiredAssertionsWithBackend(backend)
+		fileAssertions = files.DefaultRebootRequiredAssertions()
+		kernelAssertions = kernel.DefaultRebootRequiredAssertions()
+
+	default:
+		registryAssertions = registry.DefaultRebootRequiredAssertions()
+		fileAssertions = files.DefaultRebootRequiredAssertions()
+		kernelAssertions = kernel.DefaultRebootRequiredAssertions()
+	}
+
+	commandAssertions = restart.RebootRequiredAsserters{}
+
+	if len(cfg.Definitions) == 0 {
+		return registryAssertions, fileAssertions, kernelAssertions, commandAssertions, nil
+	}
+
+	defined, err := definitions.LoadFiles(cfg.Definitions...)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	if backend != nil {
+		registry.UseBackend(defined, backend)
+	}
+
+	if lookupEnv != nil {
+		restart.UseEnvLookup(defined, lookupEnv)
+	}
+
+	if cfg.DefinitionsMode == config.DefinitionsModeReplace {
+		registryAssertions = restart.RebootRequiredAsserters{}
+		fileAssertions = restart.RebootRequiredAsserters{}
+		kernelAssertions = restart.RebootRequiredAsserters{}
+	}
+
+	for _, assertion := range defined {
+		switch assertion.(type) {
+		case *files.File, *files.FileContent, *files.FileGlob:
+			fileAssertions = append(fileAssertions, assertion)
+		case *command.Command:
+			commandAssertions = append(commandAssertions, assertion)
+		default:
+			registryAssertions = append(registryAssertions, assertion)
+		}
+	}
+
+	return registryAssertions, fileAssertions, kernelAssertions, commandAssertions, nil
+}
+
+// getUptimeAssertions returns the uptime assertion if an uptime WARNING or
+// CRITICAL threshold was specified. The uptime of the local system is not
+// applicable to an offline Windows system and is not evaluated.
+func getUptimeAssertions(cfg *config.Config) restart.RebootRequiredAsserters {
+	if cfg.WindowsRoot != "" || (cfg.UptimeWarning() == 0 && cfg.UptimeCritical() == 0) {
+		return restart.RebootRequiredAsserters{}
+	}
+
+	return restart.RebootRequiredAsserters{
+		uptime.NewUptime(cfg.UptimeWarning(), cfg.UptimeCritical()),
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// HTTP endpoints served in daemon mode.
+const (
+	metricsEndpoint string = "/metrics"
+	statusEndpoint  string = "/status"
+	healthEndpoint  string = "/healthz"
+)
+
+// daemonShutdownTimeout is the amount of time allowed for in-flight HTTP
+// requests to complete once a shutdown is requested.
+const daemonShutdownTimeout time.Duration = 10 * time.Second
+
+// daemonReadHeaderTimeout is the amount of time allowed to read the headers
+// of an HTTP request.
+const daemonReadHeaderTimeout time.Duration = 10 * time.Second
+
+// errNoEvaluation is returned to HTTP clients until the first evaluation has
+// completed.
+var errNoEvaluation = errors.New("no evaluation has completed yet")
+
+// daemonState is the most recent evaluation results served in daemon mode.
+// Results are rendered when recorded so that HTTP requests do not access
+// assertions while they are being evaluated again.
+type daemonState struct {
+	mu sync.RWMutex
+
+	// status is the most recent evaluation results as a JSON document.
+	status []byte
+
+	// metrics is the most recent evaluation results in the OpenMetrics text
+	// format.
+	metrics []byte
+
+	// updated is the time the most recent evaluation results were recorded.
+	updated time.Time
+
+	// staleAfter is the amount of time after which the most recent
+	// evaluation results are considered stale and the daemon unhealthy.
+	staleAfter time.Duration
+}
+
+// runDaemon evaluates the applied assertions on the user-specified interval
+// and serves the results of the most recent evaluation over HTTP until
+// interrupted (e.g., SIGTERM). The HTTP server is shut down gracefully once
+// interrupted. An error is returned if the HTTP endpoints cannot be served
+// or if the applied assertions cannot be retrieved.
+func runDaemon(cfg *config.Config, logger zerolog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	assertions, err := getValidatedAssertionSet(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddress, err)
+	}
+
+	// Results are considered stale once an evaluation has been missed.
+	state := &daemonState{staleAfter: 2*cfg.Interval + cfg.Timeout()}
+
+	server := &http.Server{
+		Handler:           state.handler(),
+		ReadHeaderTimeout: daemonReadHeaderTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	logger.Info().
+		Str("listen_address", listener.Addr().String()).
+		Dur("interval", cfg.Interval).
+		Msg("Daemon started")
+
+	loopErr := state.evaluateOnInterval(ctx, cfg, assertions, serveErr, logger)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), daemonShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("Failed to shut down HTTP server gracefully")
+
+		if loopErr == nil {
+			loopErr = err
+		}
+	}
+
+	logger.Info().Msg("Daemon stopped")
+
+	return loopErr
+}
+
+// getValidatedAssertionSet returns the applied assertions once validated.
+func getValidatedAssertionSet(cfg *config.Config, logger zerolog.Logger) (assertionSet, error) {
+	logger.Debug().Msg("Retrieving default reboot assertions")
+	assertions, err := getAssertionSet(cfg, logger)
+	if err != nil {
+		return assertionSet{}, fmt.Errorf("failed to retrieve reboot assertions: %w", err)
+	}
+
+	logger.Debug().Msg("Validating assertions collection")
+	if err := assertions.all.Validate(); err != nil {
+		return assertionSet{}, fmt.Errorf("failed to validate list of reboot evaluations: %w", err)
+	}
+
+	return assertions, nil
+}
+
+// evaluateOnInterval evaluates the given assertions on the user-specified
+// interval until the given context is done or the HTTP server fails. The
+// assertions are reset before each evaluation.
+func (ds *daemonState) evaluateOnInterval(
+	ctx context.Context,
+	cfg *config.Config,
+	assertions assertionSet,
+	serveErr <-chan error,
+	logger zerolog.Logger,
+) error {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		assertions.all.Reset()
+
+		results := ds.evaluate(ctx, cfg, assertions, logger)
+
+		// Assertions which did not complete may still be evaluating and
+		// cannot be safely reset; fresh assertions are retrieved instead.
+		if len(results.unfinished) > 0 && ctx.Err() == nil {
+			logger.Debug().
+				Int("assertions_unfinished", len(results.unfinished)).
+				Msg("Replacing unfinished assertions")
+
+			var err error
+			assertions, err = getValidatedAssertionSet(cfg, logger)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info().Msg("Shutdown requested")
+
+			return nil
+
+		case err := <-serveErr:
+			return fmt.Errorf("failed to serve HTTP endpoints: %w", err)
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluate evaluates the given assertions and records the results. The
+// results of an evaluation interrupted by a shutdown are not recorded.
+func (ds *daemonState) evaluate(
+	ctx context.Context,
+	cfg *config.Config,
+	assertions assertionSet,
+	logger zerolog.Logger,
+) evaluation {
+	// A new plugin is used for each evaluation so that the state, output
+	// and errors of earlier evaluations are not retained.
+	plugin := nagios.NewPlugin()
+
+	results := evaluate(ctx, cfg, plugin, assertions, logger)
+
+	if ctx.Err() != nil {
+		logger.Debug().Msg("Evaluation interrupted; discarding results")
+
+		return results
+	}
+
+	if err := ds.record(results, plugin, logger); err != nil {
+		logger.Error().Err(err).Msg("Failed to record evaluation results")
+
+		return results
+	}
+
+	logger.Debug().
+		Str("state", nagios.ExitCodeToStateLabel(plugin.ExitStatusCode)).
+		Dur("duration", results.duration).
+		Msg("Evaluation results recorded")
+
+	return results
+}
+
+// record renders the given evaluation results along with the service state,
+// one-line summary and errors recorded for the given plugin and replaces the
+// results served over HTTP.
+func (ds *daemonState) record(results evaluation, plugin *nagios.Plugin, logger zerolog.Logger) error {
+	status, err := json.MarshalIndent(results.jsonResult(plugin), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode status: %w", err)
+	}
+
+	var metrics bytes.Buffer
+	if err := reports.WriteOpenMetrics(&metrics, results.metrics(plugin, logger)); err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	ds.status = append(status, '\n')
+	ds.metrics = metrics.Bytes()
+	ds.updated = time.Now()
+
+	return nil
+}
+
+// snapshot returns the most recent evaluation results along with the time
+// they were recorded.
+func (ds *daemonState) snapshot() (status []byte, metrics []byte, updated time.Time) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	return ds.status, ds.metrics, ds.updated
+}
+
+// handler returns the HTTP handler for the endpoints served in daemon mode.
+func (ds *daemonState) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+metricsEndpoint, ds.serveMetrics)
+	mux.HandleFunc("GET "+statusEndpoint, ds.serveStatus)
+	mux.HandleFunc("GET "+healthEndpoint, ds.serveHealth)
+
+	return mux
+}
+
+// serveMetrics serves the most recent evaluation results in the OpenMetrics
+// text format.
+func (ds *daemonState) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	_, metrics, _ := ds.snapshot()
+	serveResults(w, reports.OpenMetricsContentType, metrics)
+}
+
+// serveStatus serves the most recent evaluation results as a JSON document.
+func (ds *daemonState) serveStatus(w http.ResponseWriter, _ *http.Request) {
+	status, _, _ := ds.snapshot()
+	serveResults(w, "application/json", status)
+}
+
+// serveHealth indicates whether evaluations are completing as expected. The
+// daemon is considered unhealthy until the first evaluation has completed
+// and once the most recent evaluation results are stale. The evaluation
+// results themselves (e.g., a needed reboot) do not affect health.
+func (ds *daemonState) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	_, _, updated := ds.snapshot()
+
+	switch {
+	case updated.IsZero():
+		http.Error(w, errNoEvaluation.Error(), http.StatusServiceUnavailable)
+
+	case time.Since(updated) > ds.staleAfter:
+		http.Error(
+			w,
+			fmt.Sprintf("most recent evaluation completed at %s", updated.Format(time.RFC3339)),
+			http.StatusServiceUnavailable,
+		)
+
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, "ok\n")
+	}
+}
+
+// serveResults serves the given rendered evaluation results or an error if
+// no evaluation has completed yet.
+func serveResults(w http.ResponseWriter, contentType string, body []byte) {
+	if body == nil {
+		http.Error(w, errNoEvaluation.Error(), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(body)
+}
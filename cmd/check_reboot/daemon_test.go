@@ -0,0 +1,105 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// TestDaemonEndpoints asserts that the daemon endpoints are unavailable
+// until the first evaluation results are recorded, serve the recorded
+// results afterwards and report stale results as unhealthy.
+func TestDaemonEndpoints(t *testing.T) {
+	t.Parallel()
+
+	state := &daemonState{staleAfter: time.Hour}
+	handler := state.handler()
+
+	get := func(endpoint string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, endpoint, nil))
+
+		return recorder
+	}
+
+	for _, endpoint := range []string{metricsEndpoint, statusEndpoint, healthEndpoint} {
+		if got := get(endpoint).Code; got != http.StatusServiceUnavailable {
+			t.Errorf("ERROR: got status code %d for %s before first evaluation; want %d", got, endpoint, http.StatusServiceUnavailable)
+		}
+	}
+
+	plugin := nagios.NewPlugin()
+	plugin.ServiceOutput = "OK: No (non-ignored) reboot assertions matched"
+
+	if err := state.record(evaluation{}, plugin, zerolog.Nop()); err != nil {
+		t.Fatalf("ERROR: failed to record evaluation results: %v", err)
+	}
+
+	tests := map[string]struct {
+		endpoint        string
+		wantContentType string
+		wantBody        string
+	}{
+		"metrics": {
+			endpoint:        metricsEndpoint,
+			wantContentType: reports.OpenMetricsContentType,
+			wantBody:        "check_restart_reboot_required 0\n",
+		},
+		"status": {
+			endpoint:        statusEndpoint,
+			wantContentType: "application/json",
+			wantBody:        `"summary": "OK: No (non-ignored) reboot assertions matched"`,
+		},
+		"health": {
+			endpoint:        healthEndpoint,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "ok\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			response := get(tt.endpoint)
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("ERROR: got status code %d; want %d", response.Code, http.StatusOK)
+			}
+
+			if got := response.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("ERROR: got content type %q; want %q", got, tt.wantContentType)
+			}
+
+			if got := response.Body.String(); !strings.Contains(got, tt.wantBody) {
+				t.Errorf("ERROR: response does not contain %q:\n%s", tt.wantBody, got)
+			}
+		})
+	}
+
+	stale := &daemonState{staleAfter: time.Minute}
+	if err := stale.record(evaluation{}, plugin, zerolog.Nop()); err != nil {
+		t.Fatalf("ERROR: failed to record evaluation results: %v", err)
+	}
+	stale.updated = time.Now().Add(-time.Hour)
+
+	recorder := httptest.NewRecorder()
+	stale.handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, healthEndpoint, nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("ERROR: got status code %d for stale results; want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+}
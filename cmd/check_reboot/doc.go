@@ -5,8 +5,8 @@
 // Licensed under the MIT License. See LICENSE file in the project root for
 // full license information.
 
-// Nagios plugin used to monitor for "reboot needed" status of Windows
-// systems. Tested on multiple Windows desktop and server variants.
+// Nagios plugin used to monitor for "reboot needed" status of Windows and
+// Linux systems. Tested on multiple Windows desktop and server variants.
 //
 // See our [GitHub repo]:
 //
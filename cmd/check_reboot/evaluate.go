@@ -0,0 +1,291 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// evaluation is the result of evaluating the applied assertions.
+type evaluation struct {
+	// completed is the collection of assertions which completed evaluation.
+	completed restart.RebootRequiredAsserters
+
+	// unfinished is the collection of assertions which did not complete
+	// evaluation.
+	unfinished restart.RebootRequiredAsserters
+
+	// identities is the identity of each assertion.
+	identities map[restart.RebootRequiredAsserter]string
+
+	// duration is the amount of time taken to evaluate all assertions.
+	duration time.Duration
+}
+
+// evaluate evaluates the given (validated) assertions and records the
+// overall service state, one-line summary, detailed report, performance
+// data and any errors for the given plugin. The given context is used as the
+// parent of the user-specified evaluation timeout.
+//
+// Assertions are expected to not have been evaluated (or to have been reset)
+// prior to calling this function.
+func evaluate(
+	ctx context.Context,
+	cfg *config.Config,
+	plugin *nagios.Plugin,
+	assertions assertionSet,
+	logger zerolog.Logger,
+) evaluation {
+	var results evaluation
+
+	// Assertions are identified (e.g., in the state file) before evaluation
+	// so that the identities are not affected by assertions which do not
+	// complete.
+	allAssertions := assertions.all
+	identities := restart.AssertionIdentities(allAssertions)
+	results.identities = identities
+
+	if cfg.Textfile != "" {
+		// Metrics are written once the final plugin state is known, including
+		// when evaluation is not attempted.
+		defer writeTextfile(cfg, plugin, &results, logger)
+	}
+
+	var (
+		pendingState restart.PendingState
+		err          error
+	)
+	if cfg.StateFile != "" {
+		pendingState, err = restart.LoadStateFile(cfg.StateFile)
+		if err != nil {
+			logger.Error().Err(err).Str("state_file", cfg.StateFile).Msg("Failed to load state file")
+
+			plugin.AddError(err)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Failed to load state file",
+				nagios.StateUNKNOWNLabel,
+			)
+
+			return results
+		}
+	}
+
+	maintenanceWindows, err := cfg.MaintenanceWindows()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse maintenance windows")
+
+		plugin.AddError(err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to parse maintenance windows",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return results
+	}
+
+	logger.Debug().
+		Dur("timeout", cfg.Timeout()).
+		Int("workers", cfg.Workers).
+		Msg("Evaluating reboot assertions")
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+
+	// Only the results of completed assertions are used; assertions which
+	// did not complete before the timeout are listed separately.
+	evaluationStart := time.Now()
+	allAssertions, unfinishedAssertions := allAssertions.EvaluateConcurrently(ctx, cfg.Workers)
+	results.completed, results.unfinished = allAssertions, unfinishedAssertions
+	results.duration = time.Since(evaluationStart)
+
+	if cfg.SlowThreshold > 0 {
+		for _, assertion := range allAssertions.SlowEvaluations(cfg.SlowThreshold) {
+			logger.Warn().
+				Str("assertion", assertion.String()).
+				Dur("duration", restart.EvaluationDuration(assertion)).
+				Dur("slow_threshold", cfg.SlowThreshold).
+				Msg("Assertion evaluation exceeded slow threshold")
+		}
+	}
+
+	expiredIgnorePatterns, err := applyIgnorePatterns(allAssertions, cfg, logger)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to apply ignore patterns")
+
+		plugin.AddError(err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to apply ignore patterns to reboot assertions",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return results
+	}
+
+	if cfg.StateFile != "" {
+		// Failing to record the pending state does not prevent reporting the
+		// results of this evaluation.
+		if err := trackPendingState(pendingState, identities, allAssertions, cfg, logger); err != nil {
+			logger.Error().Err(err).Str("state_file", cfg.StateFile).Msg("Failed to record pending state")
+
+			plugin.AddError(err)
+		}
+	}
+
+	// Maintenance windows and a scheduled reboot are applied on top of the
+	// service state of the evaluated assertions.
+	serviceState := allAssertions.ServiceState()
+	decision := maintenanceWindows.Apply(allAssertions, time.Now())
+
+	// Failing to detect a scheduled shutdown does not prevent reporting the
+	// results of this evaluation.
+	scheduled, err := getScheduledShutdown(cfg, time.Now(), logger)
+	if err != nil {
+		logger.Error().Err(err).Str("shutdown_root", cfg.ShutdownRoot).Msg("Failed to detect scheduled shutdown")
+
+		plugin.AddError(err)
+	}
+	decision = applyScheduledShutdown(decision, scheduled, allAssertions, cfg)
+
+	if decision.Applied {
+		logger.Debug().
+			Str("state", serviceState.Label).
+			Str("maintenance_state", decision.State.Label).
+			Str("reason", decision.Reason).
+			Msg("Service state adjusted")
+
+		serviceState = decision.State
+	}
+
+	pd := getPerfData(allAssertions, assertions.files, assertions.kernel, assertions.registry, assertions.commands, assertions.uptime)
+	if cfg.StateFile != "" {
+		pd = append(pd, getPendingPerfData(allAssertions, cfg.EscalateAfter, time.Now()))
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return results
+	}
+
+	switch {
+	case len(unfinishedAssertions) > 0:
+
+		logger.Error().
+			Dur("timeout", cfg.Timeout()).
+			Int("assertions_completed", len(allAssertions)).
+			Int("assertions_unfinished", len(unfinishedAssertions)).
+			Msg("Timeout reached before evaluation of all reboot assertions completed")
+
+		plugin.AddError(fmt.Errorf(
+			"%d assertions did not complete within %s: %w",
+			len(unfinishedAssertions),
+			cfg.Timeout(),
+			restart.ErrEvaluationTimeout,
+		))
+
+		if allAssertions.HasErrors(false) {
+			plugin.AddError(allAssertions.Errs(false)...)
+		}
+
+		plugin.ServiceOutput = reports.TimeoutOneLineSummary(allAssertions, unfinishedAssertions, cfg.Timeout())
+		plugin.LongServiceOutput = reports.TimeoutReport(unfinishedAssertions, cfg.Timeout()) +
+			reports.CheckRebootReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return results
+
+	case !allAssertions.IsOKState():
+
+		logger.Debug().Msg("case !allAssertions.IsOKState() triggered")
+
+		// A needed reboot is not considered a problem outside of a
+		// maintenance window.
+		if allAssertions.RebootRequired() && serviceState.ExitCode != nagios.StateOKExitCode {
+
+			// If emitted by default NSClient++ will send back stderr and
+			// stdout blended together.
+			//
+			// The standard deployment procedure (if emitting this at Error
+			// level) will likely become explicitly disabling logging entirely
+			// in order to avoid this message displaying within the Nagios web
+			// UI and notifications by default.
+			//
+			// Because it would be beneficial to have logging enabled by
+			// default and left on by the sysadmin, we need to ensure that only
+			// "real" issues are emitted by default.
+			logger.Debug().
+				Int("assertions_applied", allAssertions.NumApplied()).
+				Int("assertions_matched", allAssertions.NumMatched()).
+				Int("assertions_ignored", allAssertions.NumIgnored()).
+				Msg("Reboot assertions matched, reboot needed")
+
+			plugin.AddError(restart.ErrRebootRequired)
+		}
+
+		logger.Debug().Msg("allAssertions.RebootRequired() NOT triggered")
+
+		// Include all errors collected during evaluation. Don't include
+		// errors from assertions marked as ignored.
+		if allAssertions.HasErrors(false) {
+			logger.Error().
+				Int("assertions_applied", allAssertions.NumApplied()).
+				Int("assertions_matched", allAssertions.NumMatched()).
+				Int("assertions_ignored", allAssertions.NumIgnored()).
+				Int("errors", allAssertions.NumErrors(false)).
+				Msg("Errors encountered evaluating need for reboot")
+
+			plugin.AddError(allAssertions.Errs(false)...)
+		}
+
+		logger.Debug().Msg("allAssertions.HasErrors(false) NOT triggered")
+
+		plugin.ServiceOutput = reports.CheckRebootOneLineSummary(allAssertions, false, decision, scheduled)
+		plugin.LongServiceOutput = reports.CheckRebootReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = serviceState.ExitCode
+
+		return results
+
+	default:
+
+		logger.Debug().Msg("default case for overall plugin state triggered")
+
+		logger.Debug().
+			Int("num_reboot_assertions_applied", allAssertions.NumApplied()).
+			Int("num_reboot_assertions_matched", allAssertions.NumMatched()).
+			Msg("No (non-ignored) reboot assertions matched")
+
+		plugin.ServiceOutput = reports.CheckRebootOneLineSummary(allAssertions, false, decision, scheduled)
+		plugin.LongServiceOutput = reports.CheckRebootReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = serviceState.ExitCode
+
+		return results
+
+	}
+}
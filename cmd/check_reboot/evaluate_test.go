@@ -0,0 +1,54 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// TestEvaluateTextfileOnEarlyExit asserts that the textfile is written with
+// the final plugin state when evaluation is not attempted.
+func TestEvaluateTextfileOnEarlyExit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	stateFile := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(stateFile, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create state file: %v", err)
+	}
+
+	cfg := &config.Config{
+		StateFile: stateFile,
+		Textfile:  filepath.Join(dir, "check_restart.prom"),
+	}
+
+	plugin := nagios.NewPlugin()
+	evaluate(context.Background(), cfg, plugin, assertionSet{}, zerolog.Nop())
+
+	if plugin.ExitStatusCode != nagios.StateUNKNOWNExitCode {
+		t.Fatalf("ERROR: got exit code %d; want %d", plugin.ExitStatusCode, nagios.StateUNKNOWNExitCode)
+	}
+
+	content, err := os.ReadFile(cfg.Textfile)
+	if err != nil {
+		t.Fatalf("ERROR: textfile not written: %v", err)
+	}
+
+	if want := "check_restart_state 3\n"; !strings.Contains(string(content), want) {
+		t.Errorf("ERROR: textfile does not contain %q:\n%s", want, content)
+	}
+}
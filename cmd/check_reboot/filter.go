@@ -8,19 +8,29 @@
 package main
 
 import (
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
 	"github.com/atc0005/check-restart/internal/restart"
 	"github.com/atc0005/check-restart/internal/restart/files"
 	"github.com/atc0005/check-restart/internal/restart/registry"
 	"github.com/rs/zerolog"
 )
 
+// applyIgnorePatterns marks matched assertion paths as ignored using the
+// default ignored path entries (unless disabled) along with any
+// user-specified ignore patterns and ignore files. User-specified ignore
+// patterns which have expired are not applied and are returned so that they
+// may be reported.
 func applyIgnorePatterns(
 	allAssertions restart.RebootRequiredAsserters,
-	disableDefaultIgnored bool,
+	cfg *config.Config,
 	logger zerolog.Logger,
-) {
+) (restart.IgnorePatterns, error) {
+	var allIgnorePatterns restart.IgnorePatterns
+
 	switch {
-	case disableDefaultIgnored:
+	case cfg.DisableDefaultIgnored:
 		logger.Debug().Msg("Skipping use of default ignored path entries for reboot assertions")
 	default:
 		logger.Debug().Msg("Retrieving default ignored path entries for registry reboot assertions")
@@ -37,12 +47,40 @@ func applyIgnorePatterns(
 
 		logger.Debug().Msg("Finished retrieving default ignored path entries")
 
-		allIgnorePatterns := make([]string, 0, len(registryignorePatterns)+len(fileignorePatterns))
-		allIgnorePatterns = append(allIgnorePatterns, registryignorePatterns...)
-		allIgnorePatterns = append(allIgnorePatterns, fileignorePatterns...)
+		allIgnorePatterns = append(allIgnorePatterns, restart.SubstringIgnorePatterns(registryignorePatterns)...)
+		allIgnorePatterns = append(allIgnorePatterns, restart.SubstringIgnorePatterns(fileignorePatterns)...)
+	}
 
-		logger.Debug().Msg("Filtering reboot assertions")
+	userIgnorePatterns, err := cfg.IgnorePatterns()
+	if err != nil {
+		return nil, err
+	}
 
-		allAssertions.Filter(allIgnorePatterns)
+	fileIgnorePatterns, err := restart.LoadIgnoreFiles(cfg.IgnoreFiles...)
+	if err != nil {
+		return nil, err
 	}
+
+	logger.Debug().
+		Int("user_ignore_patterns", len(userIgnorePatterns)).
+		Int("ignore_file_patterns", len(fileIgnorePatterns)).
+		Msg("Retrieved user-specified ignore patterns")
+
+	activeIgnorePatterns, expiredIgnorePatterns := append(userIgnorePatterns, fileIgnorePatterns...).Partition(time.Now())
+	for _, ip := range expiredIgnorePatterns {
+		logger.Warn().
+			Str("ignore_pattern", ip.String()).
+			Str("owner", ip.Owner).
+			Str("comment", ip.Comment).
+			Time("expires", ip.Expires).
+			Msg("Ignore pattern has expired and is no longer applied")
+	}
+
+	allIgnorePatterns = append(allIgnorePatterns, activeIgnorePatterns...)
+
+	logger.Debug().Msg("Filtering reboot assertions")
+
+	allAssertions.Filter(allIgnorePatterns)
+
+	return expiredIgnorePatterns, nil
 }
@@ -9,9 +9,15 @@ package main
 
 import (
 	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+	"github.com/atc0005/check-restart/internal/restart/command"
+	"github.com/atc0005/check-restart/internal/restart/definitions"
 	"github.com/atc0005/check-restart/internal/restart/files"
+	"github.com/atc0005/check-restart/internal/restart/kernel"
+	"github.com/atc0005/check-restart/internal/restart/maintenance"
 	"github.com/atc0005/check-restart/internal/restart/registry"
 	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/check-restart/internal/restart/uptime"
 	"github.com/rs/zerolog"
 )
 
@@ -20,13 +26,25 @@ func handleLibraryLogging() {
 	case zerolog.GlobalLevel() == zerolog.DebugLevel ||
 		zerolog.GlobalLevel() == zerolog.TraceLevel:
 		restart.EnableLogging()
+		boot.EnableLogging()
+		command.EnableLogging()
+		definitions.EnableLogging()
 		files.EnableLogging()
+		kernel.EnableLogging()
+		maintenance.EnableLogging()
 		registry.EnableLogging()
 		reports.EnableLogging()
+		uptime.EnableLogging()
 	default:
 		restart.DisableLogging()
+		boot.DisableLogging()
+		command.DisableLogging()
+		definitions.DisableLogging()
 		files.DisableLogging()
+		kernel.DisableLogging()
+		maintenance.DisableLogging()
 		registry.DisableLogging()
 		reports.DisableLogging()
+		uptime.DisableLogging()
 	}
 }
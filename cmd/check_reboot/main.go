@@ -10,15 +10,13 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/atc0005/check-restart/internal/config"
-	"github.com/atc0005/check-restart/internal/restart"
-	"github.com/atc0005/check-restart/internal/restart/files"
-	"github.com/atc0005/check-restart/internal/restart/registry"
-	"github.com/atc0005/check-restart/internal/restart/reports"
 	"github.com/atc0005/go-nagios"
 
 	"github.com/rs/zerolog"
@@ -67,130 +65,62 @@ func main() {
 
 	log := cfg.Log.With().Logger()
 
-	log.Debug().Msg("Retrieving default registry reboot assertions")
-	registryAssertions := registry.DefaultRebootRequiredAssertions()
-	log.Debug().
-		Int("registry_assertions", len(registryAssertions)).
-		Msg("Retrieved default registry reboot assertions")
+	if cfg.Daemon {
+		// The Nagios plugin output is not used in daemon mode; the results
+		// of each evaluation are served over HTTP instead.
+		plugin.SetOutputTarget(io.Discard)
 
-	log.Debug().Msg("Retrieving default file reboot assertions")
-	fileAssertions := files.DefaultRebootRequiredAssertions()
-	log.Debug().
-		Int("file_assertions", len(fileAssertions)).
-		Msg("Retrieved default file reboot assertions")
+		if err := runDaemon(cfg, log); err != nil {
+			log.Error().Err(err).Msg("Daemon stopped unexpectedly")
 
-	log.Debug().Msg("Finished retrieving reboot assertions")
+			plugin.AddError(err)
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Daemon stopped unexpectedly",
+				nagios.StateUNKNOWNLabel,
+			)
+		}
 
-	allAssertions := make(restart.RebootRequiredAsserters, 0, len(registryAssertions)+len(fileAssertions))
-	allAssertions = append(allAssertions, registryAssertions...)
-	allAssertions = append(allAssertions, fileAssertions...)
+		return
+	}
 
-	log.Debug().
-		Int("all_assertions", len(allAssertions)).
-		Msg("All assertions retrieved")
+	// The Nagios plugin output is replaced by a JSON document emitted just
+	// before the plugin results (and exit code) are returned.
+	var results evaluation
+	if cfg.OutputFormat == config.OutputFormatJSON {
+		plugin.SetOutputTarget(io.Discard)
 
-	log.Debug().Msg("Validating assertions collection")
-	if err := allAssertions.Validate(); err != nil {
-		log.Error().Err(err).Msg("Failed to validate provided assertions")
+		defer results.writeJSON(os.Stdout, plugin, log)
+	}
+
+	log.Debug().Msg("Retrieving default reboot assertions")
+	assertions, err := getAssertionSet(cfg, log)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to retrieve reboot assertions")
 
 		plugin.AddError(err)
 		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
 		plugin.ServiceOutput = fmt.Sprintf(
-			"%s: Failed to validate list of reboot evaluations",
+			"%s: Failed to retrieve reboot assertions",
 			nagios.StateUNKNOWNLabel,
 		)
 
 		return
 	}
 
-	log.Debug().Msg("Evaluating reboot assertions")
-	allAssertions.Evaluate()
-
-	applyIgnorePatterns(allAssertions, cfg.DisableDefaultIgnored, log)
-
-	pd := getPerfData(allAssertions, fileAssertions, registryAssertions)
-	if err := plugin.AddPerfData(false, pd...); err != nil {
-		log.Error().
-			Err(err).
-			Msg("failed to add performance data")
+	log.Debug().Msg("Validating assertions collection")
+	if err := assertions.all.Validate(); err != nil {
+		log.Error().Err(err).Msg("Failed to validate provided assertions")
 
-		// Surface the error in plugin output.
 		plugin.AddError(err)
-
 		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
 		plugin.ServiceOutput = fmt.Sprintf(
-			"%s: Failed to process performance data metrics",
+			"%s: Failed to validate list of reboot evaluations",
 			nagios.StateUNKNOWNLabel,
 		)
 
 		return
 	}
 
-	switch {
-	case !allAssertions.IsOKState():
-
-		log.Debug().Msg("case !allAssertions.IsOKState() triggered")
-
-		if allAssertions.RebootRequired() {
-
-			// If emitted by default NSClient++ will send back stderr and
-			// stdout blended together.
-			//
-			// The standard deployment procedure (if emitting this at Error
-			// level) will likely become explicitly disabling logging entirely
-			// in order to avoid this message displaying within the Nagios web
-			// UI and notifications by default.
-			//
-			// Because it would be beneficial to have logging enabled by
-			// default and left on by the sysadmin, we need to ensure that only
-			// "real" issues are emitted by default.
-			log.Debug().
-				Int("assertions_applied", allAssertions.NumApplied()).
-				Int("assertions_matched", allAssertions.NumMatched()).
-				Int("assertions_ignored", allAssertions.NumIgnored()).
-				Msg("Reboot assertions matched, reboot needed")
-
-			plugin.AddError(restart.ErrRebootRequired)
-		}
-
-		log.Debug().Msg("allAssertions.RebootRequired() NOT triggered")
-
-		// Include all errors collected during evaluation. Don't include
-		// errors from assertions marked as ignored.
-		if allAssertions.HasErrors(false) {
-			log.Error().
-				Int("assertions_applied", allAssertions.NumApplied()).
-				Int("assertions_matched", allAssertions.NumMatched()).
-				Int("assertions_ignored", allAssertions.NumIgnored()).
-				Int("errors", allAssertions.NumErrors(false)).
-				Msg("Errors encountered evaluating need for reboot")
-
-			plugin.AddError(allAssertions.Errs(false)...)
-		}
-
-		log.Debug().Msg("allAssertions.HasErrors(false) NOT triggered")
-
-		plugin.ServiceOutput = reports.CheckRebootOneLineSummary(allAssertions, false)
-		plugin.LongServiceOutput = reports.CheckRebootReport(allAssertions, cfg.ShowIgnored, cfg.VerboseOutput)
-		plugin.ExitStatusCode = allAssertions.ServiceState().ExitCode
-
-		return
-
-	default:
-
-		log.Debug().Msg("default case for overall plugin state triggered")
-
-		log.Debug().
-			Int("num_reboot_assertions_applied", allAssertions.NumApplied()).
-			Int("num_reboot_assertions_matched", allAssertions.NumMatched()).
-			Msg("No (non-ignored) reboot assertions matched")
-
-		plugin.ServiceOutput = reports.CheckRebootOneLineSummary(allAssertions, false)
-		plugin.LongServiceOutput = reports.CheckRebootReport(allAssertions, cfg.ShowIgnored, cfg.VerboseOutput)
-		plugin.ExitStatusCode = allAssertions.ServiceState().ExitCode
-
-		return
-
-	}
-
+	results = evaluate(context.Background(), cfg, plugin, assertions, log)
 }
@@ -0,0 +1,100 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// jsonResult returns the evaluation results along with the service state,
+// one-line summary and errors recorded for the plugin as a machine-readable
+// document.
+func (e *evaluation) jsonResult(plugin *nagios.Plugin) reports.CheckRebootResult {
+	return reports.CheckRebootJSON(
+		e.completed,
+		e.unfinished,
+		e.identities,
+		pluginState(plugin),
+		strings.TrimSpace(plugin.ServiceOutput),
+		plugin.Errors,
+	)
+}
+
+// writeJSON emits the evaluation results along with the service state,
+// one-line summary and errors recorded for the plugin as a JSON document.
+// This is expected to be called before the plugin results are returned so
+// that the final plugin state is used.
+func (e *evaluation) writeJSON(w io.Writer, plugin *nagios.Plugin, logger zerolog.Logger) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(e.jsonResult(plugin)); err != nil {
+		logger.Error().Err(err).Msg("Failed to emit JSON output")
+	}
+}
+
+// metrics returns the evaluation results along with the service state
+// recorded for the plugin and the uptime of the local system as metrics. The
+// uptime metric is omitted if the uptime cannot be determined.
+func (e *evaluation) metrics(plugin *nagios.Plugin, logger zerolog.Logger) reports.PrometheusMetrics {
+	uptime, err := boot.Uptime()
+	if err != nil {
+		logger.Debug().Err(err).Msg("Failed to determine uptime; omitting uptime metric")
+	}
+
+	return reports.PrometheusMetrics{
+		Completed:          e.completed,
+		Unfinished:         e.unfinished,
+		Identities:         e.identities,
+		State:              pluginState(plugin),
+		EvaluationDuration: e.duration,
+		Uptime:             uptime,
+		Timestamp:          time.Now(),
+	}
+}
+
+// writeTextfile replaces the user-specified textfile with the given
+// evaluation results as metrics in the Prometheus text exposition format.
+// This is expected to be deferred before any evaluation results are recorded
+// so that the final results and plugin state are used. Failing to write the
+// textfile is recorded as a plugin error but does not change the plugin
+// state.
+func writeTextfile(
+	cfg *config.Config,
+	plugin *nagios.Plugin,
+	results *evaluation,
+	logger zerolog.Logger,
+) {
+	if err := reports.WritePrometheusTextfile(cfg.Textfile, results.metrics(plugin, logger)); err != nil {
+		logger.Error().Err(err).Str("textfile", cfg.Textfile).Msg("Failed to write textfile")
+
+		plugin.AddError(err)
+
+		return
+	}
+
+	logger.Debug().Str("textfile", cfg.Textfile).Msg("Wrote textfile")
+}
+
+// pluginState returns the service state associated with the exit code
+// recorded for the plugin.
+func pluginState(plugin *nagios.Plugin) nagios.ServiceState {
+	return nagios.ServiceState{
+		Label:    nagios.ExitCodeToStateLabel(plugin.ExitStatusCode),
+		ExitCode: plugin.ExitStatusCode,
+	}
+}
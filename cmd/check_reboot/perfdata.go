@@ -9,8 +9,11 @@ package main
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/uptime"
 	"github.com/atc0005/go-nagios"
 )
 
@@ -18,10 +21,15 @@ import (
 func getPerfData(
 	allAssertions restart.RebootRequiredAsserters,
 	fileAssertions restart.RebootRequiredAsserters,
+	kernelAssertions restart.RebootRequiredAsserters,
 	registryAssertions restart.RebootRequiredAsserters,
+	commandAssertions restart.RebootRequiredAsserters,
+	uptimeAssertions restart.RebootRequiredAsserters,
 ) []nagios.PerformanceData {
 
-	return []nagios.PerformanceData{
+	_, slowestDuration := allAssertions.SlowestEvaluation()
+
+	pd := []nagios.PerformanceData{
 		// The `time` (runtime) metric is appended at plugin exit, so do not
 		// duplicate it here.
 		{
@@ -32,10 +40,18 @@ func getPerfData(
 			Label: "evaluated_file_assertions",
 			Value: fmt.Sprintf("%d", len(fileAssertions)),
 		},
+		{
+			Label: "evaluated_kernel_assertions",
+			Value: fmt.Sprintf("%d", len(kernelAssertions)),
+		},
 		{
 			Label: "evaluated_registry_assertions",
 			Value: fmt.Sprintf("%d", len(registryAssertions)),
 		},
+		{
+			Label: "evaluated_command_assertions",
+			Value: fmt.Sprintf("%d", len(commandAssertions)),
+		},
 		{
 			Label: "matched_assertions",
 			Value: fmt.Sprintf("%d", allAssertions.NumMatched()),
@@ -48,6 +64,79 @@ func getPerfData(
 			Label: "errors",
 			Value: fmt.Sprintf("%d", allAssertions.NumErrors(false)),
 		},
+		{
+			Label:             "evaluation_time_total",
+			Value:             fmt.Sprintf("%d", allAssertions.TotalEvaluationDuration().Milliseconds()),
+			UnitOfMeasurement: "ms",
+		},
+		{
+			Label:             "evaluation_time_slowest",
+			Value:             fmt.Sprintf("%d", slowestDuration.Milliseconds()),
+			UnitOfMeasurement: "ms",
+		},
 	}
 
+	return append(pd, getUptimePerfData(allAssertions, uptimeAssertions)...)
+}
+
+// getUptimePerfData gathers the uptime metric (in seconds) along with the
+// specified thresholds for each evaluated uptime assertion. Uptime
+// assertions which did not complete or failed are skipped.
+func getUptimePerfData(
+	allAssertions restart.RebootRequiredAsserters,
+	uptimeAssertions restart.RebootRequiredAsserters,
+) []nagios.PerformanceData {
+	pd := make([]nagios.PerformanceData, 0, len(uptimeAssertions))
+
+	for _, assertion := range uptimeAssertions {
+		u, ok := assertion.(*uptime.Uptime)
+		if !ok || u.Err() != nil || !slices.Contains(allAssertions, assertion) {
+			continue
+		}
+
+		pd = append(pd, nagios.PerformanceData{
+			Label:             "uptime",
+			Value:             fmt.Sprintf("%d", int64(u.Uptime().Seconds())),
+			UnitOfMeasurement: "s",
+			Warn:              thresholdSeconds(u.Warning()),
+			Crit:              thresholdSeconds(u.Critical()),
+			Min:               "0",
+		})
+	}
+
+	return pd
+}
+
+// thresholdSeconds provides the given threshold in seconds for use as a
+// performance data threshold. An empty string is returned if the threshold
+// is not applied.
+func thresholdSeconds(threshold time.Duration) string {
+	if threshold == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", int64(threshold.Seconds()))
+}
+
+// getPendingPerfData gathers the amount of time (in seconds) the oldest
+// assertion which indicates a reboot is needed has been pending as recorded
+// in the state file. If specified, the escalation grace period is used as the
+// CRITICAL threshold.
+func getPendingPerfData(
+	allAssertions restart.RebootRequiredAsserters,
+	escalateAfter time.Duration,
+	now time.Time,
+) nagios.PerformanceData {
+	var pending time.Duration
+	if oldest := allAssertions.OldestPending(); !oldest.IsZero() {
+		pending = now.Sub(oldest)
+	}
+
+	return nagios.PerformanceData{
+		Label:             "pending_seconds",
+		Value:             fmt.Sprintf("%d", int64(pending.Seconds())),
+		UnitOfMeasurement: "s",
+		Crit:              thresholdSeconds(escalateAfter),
+		Min:               "0",
+	}
 }
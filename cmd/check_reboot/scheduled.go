@@ -0,0 +1,74 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+	"github.com/atc0005/check-restart/internal/restart/maintenance"
+	"github.com/atc0005/go-nagios"
+	"github.com/rs/zerolog"
+)
+
+// getScheduledShutdown returns the shutdown or reboot scheduled via systemd
+// within the user-specified shutdown root. The zero value is returned if no
+// shutdown is scheduled. A scheduled shutdown which should already have
+// occurred is disregarded as stale.
+func getScheduledShutdown(cfg *config.Config, now time.Time, logger zerolog.Logger) (boot.ScheduledShutdown, error) {
+	scheduled, ok, err := boot.Scheduled(cfg.ShutdownRoot)
+	switch {
+	case err != nil:
+		return boot.ScheduledShutdown{}, err
+
+	case !ok:
+		return boot.ScheduledShutdown{}, nil
+
+	case !scheduled.Time.After(now):
+		logger.Debug().
+			Str("mode", scheduled.Mode).
+			Time("scheduled", scheduled.Time).
+			Msg("Disregarding stale scheduled shutdown")
+
+		return boot.ScheduledShutdown{}, nil
+	}
+
+	logger.Debug().
+		Str("mode", scheduled.Mode).
+		Time("scheduled", scheduled.Time).
+		Msg("Shutdown scheduled")
+
+	return scheduled, nil
+}
+
+// applyScheduledShutdown adjusts the given maintenance window decision for
+// the given scheduled shutdown. If the user opted to do so, a needed reboot
+// is considered OK if a reboot has already been scheduled and no errors
+// occurred during evaluation. Otherwise the decision is returned unchanged.
+func applyScheduledShutdown(
+	decision maintenance.Decision,
+	scheduled boot.ScheduledShutdown,
+	assertions restart.RebootRequiredAsserters,
+	cfg *config.Config,
+) maintenance.Decision {
+	if !cfg.ScheduledRebootOK || !scheduled.IsReboot() ||
+		!assertions.RebootRequired() || assertions.HasErrors(false) {
+		return decision
+	}
+
+	// The scheduled reboot is noted separately in the plugin output.
+	return maintenance.Decision{
+		Applied: true,
+		State: nagios.ServiceState{
+			Label:    nagios.StateOKLabel,
+			ExitCode: nagios.StateOKExitCode,
+		},
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/rs/zerolog"
+)
+
+// trackPendingState records when each evaluated assertion was first found to
+// indicate that a reboot is needed using the user-specified state file. The
+// given identities are used to identify each assertion in the state file and
+// are expected to cover all assertions, including those which did not
+// complete evaluation. Assertions pending longer than the user-specified
+// escalation grace period are marked as escalated.
+func trackPendingState(
+	pendingState restart.PendingState,
+	identities map[restart.RebootRequiredAsserter]string,
+	evaluated restart.RebootRequiredAsserters,
+	cfg *config.Config,
+	logger zerolog.Logger,
+) error {
+	pendingState.Track(identities, evaluated, cfg.EscalateAfter, time.Now())
+
+	if err := pendingState.WriteStateFile(cfg.StateFile); err != nil {
+		return err
+	}
+
+	logger.Debug().
+		Str("state_file", cfg.StateFile).
+		Int("pending_entries", len(pendingState)).
+		Dur("escalate_after", cfg.EscalateAfter).
+		Msg("Recorded pending state")
+
+	return nil
+}
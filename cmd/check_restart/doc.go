@@ -0,0 +1,21 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Nagios plugin used to monitor for "restart needed" status of services
+// (processes) on Linux systems. Running processes are evaluated for use of
+// executables or shared libraries which have been deleted (e.g., replaced by
+// a package update) since the process was started.
+//
+// See our [GitHub repo]:
+//
+//   - to review documentation (including examples)
+//   - for the latest code
+//   - to file an issue or submit improvements for review and potential
+//     inclusion into the project
+//
+// [GitHub repo]: https://github.com/atc0005/check-restart
+package main
@@ -0,0 +1,76 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"time"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/procs"
+	"github.com/rs/zerolog"
+)
+
+// applyIgnorePatterns marks matched assertion paths as ignored using the
+// default ignored path entries (unless disabled) along with any
+// user-specified ignore patterns and ignore files. User-specified ignore
+// patterns which have expired are not applied and are returned so that they
+// may be reported.
+func applyIgnorePatterns(
+	allAssertions restart.RebootRequiredAsserters,
+	cfg *config.Config,
+	logger zerolog.Logger,
+) (restart.IgnorePatterns, error) {
+	var allIgnorePatterns restart.IgnorePatterns
+
+	switch {
+	case cfg.DisableDefaultIgnored:
+		logger.Debug().Msg("Skipping use of default ignored path entries for restart assertions")
+	default:
+		logger.Debug().Msg("Retrieving default ignored path entries for process assertions")
+		processIgnorePatterns := procs.DefaultRestartRequiredIgnoredPaths()
+		logger.Debug().
+			Int("process_ignore_patterns", len(processIgnorePatterns)).
+			Msg("Retrieved default process ignore path patterns")
+
+		allIgnorePatterns = append(allIgnorePatterns, restart.SubstringIgnorePatterns(processIgnorePatterns)...)
+	}
+
+	userIgnorePatterns, err := cfg.IgnorePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	fileIgnorePatterns, err := restart.LoadIgnoreFiles(cfg.IgnoreFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("user_ignore_patterns", len(userIgnorePatterns)).
+		Int("ignore_file_patterns", len(fileIgnorePatterns)).
+		Msg("Retrieved user-specified ignore patterns")
+
+	activeIgnorePatterns, expiredIgnorePatterns := append(userIgnorePatterns, fileIgnorePatterns...).Partition(time.Now())
+	for _, ip := range expiredIgnorePatterns {
+		logger.Warn().
+			Str("ignore_pattern", ip.String()).
+			Str("owner", ip.Owner).
+			Str("comment", ip.Comment).
+			Time("expires", ip.Expires).
+			Msg("Ignore pattern has expired and is no longer applied")
+	}
+
+	allIgnorePatterns = append(allIgnorePatterns, activeIgnorePatterns...)
+
+	logger.Debug().Msg("Filtering restart assertions")
+
+	allAssertions.Filter(allIgnorePatterns)
+
+	return expiredIgnorePatterns, nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/atc0005/check-restart/internal/config"
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/procs"
+	"github.com/atc0005/check-restart/internal/restart/reports"
+	"github.com/atc0005/go-nagios"
+
+	"github.com/rs/zerolog"
+)
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags.
+	cfg, cfgErr := config.New(config.AppType{RestartPlugin: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+
+		// We make some assumptions when setting up our logger as we do not
+		// have a working configuration based on sysadmin-specified choices.
+		consoleWriter := zerolog.ConsoleWriter{Out: os.Stderr, NoColor: true}
+		logger := zerolog.New(consoleWriter).With().Timestamp().Caller().Logger()
+
+		logger.Err(cfgErr).Msg("Error initializing application")
+
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	handleLibraryLogging()
+
+	log := cfg.Log.With().Logger()
+
+	log.Debug().Msg("Retrieving default process restart assertions")
+	allAssertions := procs.DefaultRestartRequiredAssertions(cfg.ProcRoot)
+	log.Debug().
+		Int("all_assertions", len(allAssertions)).
+		Str("proc_root", cfg.ProcRoot).
+		Msg("All assertions retrieved")
+
+	log.Debug().Msg("Validating assertions collection")
+	if err := allAssertions.Validate(); err != nil {
+		log.Error().Err(err).Msg("Failed to validate provided assertions")
+
+		plugin.AddError(err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to validate list of restart evaluations",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	log.Debug().
+		Dur("timeout", cfg.Timeout()).
+		Int("workers", cfg.Workers).
+		Msg("Evaluating restart assertions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Only the results of completed assertions are used; assertions which
+	// did not complete before the timeout are listed separately.
+	allAssertions, unfinishedAssertions := allAssertions.EvaluateConcurrently(ctx, cfg.Workers)
+
+	expiredIgnorePatterns, err := applyIgnorePatterns(allAssertions, cfg, log)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to apply ignore patterns")
+
+		plugin.AddError(err)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to apply ignore patterns to restart assertions",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	pd := getPerfData(allAssertions)
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	switch {
+	case len(unfinishedAssertions) > 0:
+
+		log.Error().
+			Dur("timeout", cfg.Timeout()).
+			Int("assertions_completed", len(allAssertions)).
+			Int("assertions_unfinished", len(unfinishedAssertions)).
+			Msg("Timeout reached before evaluation of all restart assertions completed")
+
+		plugin.AddError(fmt.Errorf(
+			"%d assertions did not complete within %s: %w",
+			len(unfinishedAssertions),
+			cfg.Timeout(),
+			restart.ErrEvaluationTimeout,
+		))
+
+		if allAssertions.HasErrors(false) {
+			plugin.AddError(allAssertions.Errs(false)...)
+		}
+
+		plugin.ServiceOutput = reports.TimeoutOneLineSummary(allAssertions, unfinishedAssertions, cfg.Timeout())
+		plugin.LongServiceOutput = reports.TimeoutReport(unfinishedAssertions, cfg.Timeout()) +
+			reports.CheckRestartReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+
+	case !allAssertions.IsOKState():
+
+		log.Debug().Msg("case !allAssertions.IsOKState() triggered")
+
+		if allAssertions.RebootRequired() {
+
+			// If emitted by default NSClient++ will send back stderr and
+			// stdout blended together.
+			//
+			// The standard deployment procedure (if emitting this at Error
+			// level) will likely become explicitly disabling logging entirely
+			// in order to avoid this message displaying within the Nagios web
+			// UI and notifications by default.
+			//
+			// Because it would be beneficial to have logging enabled by
+			// default and left on by the sysadmin, we need to ensure that only
+			// "real" issues are emitted by default.
+			log.Debug().
+				Int("assertions_applied", allAssertions.NumApplied()).
+				Int("assertions_matched", allAssertions.NumMatched()).
+				Int("assertions_ignored", allAssertions.NumIgnored()).
+				Msg("Restart assertions matched, service restart needed")
+
+			plugin.AddError(restart.ErrRestartRequired)
+		}
+
+		log.Debug().Msg("allAssertions.RebootRequired() NOT triggered")
+
+		// Include all errors collected during evaluation. Don't include
+		// errors from assertions marked as ignored.
+		if allAssertions.HasErrors(false) {
+			log.Error().
+				Int("assertions_applied", allAssertions.NumApplied()).
+				Int("assertions_matched", allAssertions.NumMatched()).
+				Int("assertions_ignored", allAssertions.NumIgnored()).
+				Int("errors", allAssertions.NumErrors(false)).
+				Msg("Errors encountered evaluating need for service restart")
+
+			plugin.AddError(allAssertions.Errs(false)...)
+		}
+
+		log.Debug().Msg("allAssertions.HasErrors(false) NOT triggered")
+
+		plugin.ServiceOutput = reports.CheckRestartOneLineSummary(allAssertions, false)
+		plugin.LongServiceOutput = reports.CheckRestartReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = allAssertions.ServiceState().ExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("default case for overall plugin state triggered")
+
+		log.Debug().
+			Int("num_restart_assertions_applied", allAssertions.NumApplied()).
+			Int("num_restart_assertions_matched", allAssertions.NumMatched()).
+			Msg("No (non-ignored) restart assertions matched")
+
+		plugin.ServiceOutput = reports.CheckRestartOneLineSummary(allAssertions, false)
+		plugin.LongServiceOutput = reports.CheckRestartReport(allAssertions, expiredIgnorePatterns, cfg.ShowIgnored, cfg.VerboseOutput)
+		plugin.ExitStatusCode = allAssertions.ServiceState().ExitCode
+
+		return
+
+	}
+
+}
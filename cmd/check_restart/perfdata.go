@@ -0,0 +1,63 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/procs"
+	"github.com/atc0005/go-nagios"
+)
+
+// getPerfData gathers performance data metrics that we wish to report.
+func getPerfData(allAssertions restart.RebootRequiredAsserters) []nagios.PerformanceData {
+
+	var scanned, affected, skipped int
+	for _, assertion := range allAssertions {
+		if v, ok := assertion.(*procs.Processes); ok {
+			scanned += v.NumScanned()
+			affected += v.NumAffected()
+			skipped += v.NumSkipped()
+		}
+	}
+
+	return []nagios.PerformanceData{
+		// The `time` (runtime) metric is appended at plugin exit, so do not
+		// duplicate it here.
+		{
+			Label: "evaluated_assertions",
+			Value: fmt.Sprintf("%d", len(allAssertions)),
+		},
+		{
+			Label: "matched_assertions",
+			Value: fmt.Sprintf("%d", allAssertions.NumMatched()),
+		},
+		{
+			Label: "ignored_assertions",
+			Value: fmt.Sprintf("%d", allAssertions.NumIgnored()),
+		},
+		{
+			Label: "errors",
+			Value: fmt.Sprintf("%d", allAssertions.NumErrors(false)),
+		},
+		{
+			Label: "processes_scanned",
+			Value: fmt.Sprintf("%d", scanned),
+		},
+		{
+			Label: "processes_affected",
+			Value: fmt.Sprintf("%d", affected),
+		},
+		{
+			Label: "processes_skipped",
+			Value: fmt.Sprintf("%d", skipped),
+		},
+	}
+
+}
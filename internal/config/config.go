@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -37,6 +38,10 @@ type AppType struct {
 	// Plugin represents an application used as a Nagios plugin.
 	Plugin bool
 
+	// RestartPlugin represents an application used as a Nagios plugin to
+	// monitor for services (processes) needing a restart.
+	RestartPlugin bool
+
 	// Inspector represents an application used for one-off or isolated
 	// checks. Unlike a Nagios plugin which is focused on specific attributes
 	// resulting in a severity-based outcome, an Inspector application is
@@ -77,6 +82,106 @@ type Config struct {
 	// matching assertion path entries as ignored in the final plugin output.
 	DisableDefaultIgnored bool
 
+	// Ignore is the collection of user-specified patterns used to mark
+	// matched assertion paths as ignored. These are applied in addition to
+	// any default ignored paths.
+	Ignore multiValueStringFlag
+
+	// IgnoreFiles is the collection of files listing patterns used to mark
+	// matched assertion paths as ignored.
+	IgnoreFiles multiValueStringFlag
+
+	// ProcRoot is the fully-qualified path to the proc filesystem used to
+	// evaluate running processes.
+	ProcRoot string
+
+	// RegistryFiles is the collection of exported registry files (.reg) used
+	// to evaluate registry assertions instead of the registry of the local
+	// system.
+	RegistryFiles multiValueStringFlag
+
+	// WindowsRoot is the path where the system drive of an offline Windows
+	// system is mounted. If specified, assertions are evaluated against the
+	// offline system instead of the local system.
+	WindowsRoot string
+
+	// Definitions is the collection of definition files listing additional
+	// reboot required assertions.
+	Definitions multiValueStringFlag
+
+	// DefinitionsMode indicates whether assertions from definition files
+	// extend or replace the built-in default assertions.
+	DefinitionsMode string
+
+	// timeout is the number of seconds allowed before evaluation of all
+	// assertions is abandoned.
+	timeout int
+
+	// Workers is the maximum number of assertions evaluated concurrently.
+	Workers int
+
+	// SlowThreshold is the amount of time after which the evaluation of an
+	// assertion is logged as slow. Zero disables this.
+	SlowThreshold time.Duration
+
+	// uptimeWarning is the number of days of uptime after which a reboot is
+	// needed and a WARNING state is indicated. Zero disables this threshold.
+	uptimeWarning int
+
+	// uptimeCritical is the number of days of uptime after which a reboot is
+	// needed and a CRITICAL state is indicated. Zero disables this
+	// threshold.
+	uptimeCritical int
+
+	// StateFile is the path to the file used to record when each assertion
+	// was first found to indicate that a reboot is needed. If not specified
+	// evaluations are stateless.
+	StateFile string
+
+	// EscalateAfter is the amount of time a reboot may be pending (as
+	// recorded in the state file) before a WARNING state is escalated to a
+	// CRITICAL state. Zero disables escalation.
+	EscalateAfter time.Duration
+
+	// MaintenanceWindow is the collection of user-specified maintenance
+	// window specifications. Outside of a maintenance window a needed reboot
+	// is not considered a problem.
+	MaintenanceWindow multiValueStringFlag
+
+	// MaintenanceTimezone is the IANA time zone name (e.g., Europe/Berlin)
+	// maintenance windows are specified in. If not specified the local time
+	// zone is used.
+	MaintenanceTimezone string
+
+	// ShutdownRoot is the filesystem root used to detect a shutdown or
+	// reboot scheduled via systemd.
+	ShutdownRoot string
+
+	// ScheduledRebootOK indicates whether a needed reboot is considered OK
+	// if a reboot has already been scheduled.
+	ScheduledRebootOK bool
+
+	// OutputFormat is the format of the evaluation results emitted by the
+	// plugin. The plugin exit code is the same for all formats.
+	OutputFormat string
+
+	// Textfile is the path to a file (e.g., within the node_exporter textfile
+	// collector directory) replaced with metrics in the Prometheus text
+	// exposition format after each evaluation.
+	Textfile string
+
+	// Daemon indicates whether assertions are re-evaluated on an interval
+	// with the results served over HTTP instead of evaluated once.
+	Daemon bool
+
+	// ListenAddress is the TCP network address (e.g., :9814) the HTTP
+	// endpoints are served on when running in daemon mode.
+	ListenAddress string
+
+	// Interval is the amount of time between evaluations when running in
+	// daemon mode.
+	Interval time.Duration
+
 	// Log is an embedded zerolog Logger initialized via config.New().
 	Log zerolog.Logger
 }
@@ -7,6 +7,8 @@
 
 package config
 
+import "time"
+
 const myAppName string = "check-restart"
 const myAppURL string = "https://github.com/atc0005/check-restart"
 
@@ -22,6 +24,29 @@ const (
 	verboseOutputFlagHelp         string = "Toggles emission of detailed output. This level of output is disabled by default."
 	showIgnoredFlagHelp           string = "Toggles emission of ignored assertion matches in the final plugin output. This is disabled by default."
 	disableDefaultIgnoredFlagHelp string = "Disables use of default ignored assertion path entries."
+	procRootFlagHelp              string = "Path to the proc filesystem used to evaluate running processes."
+	registryFileFlagHelp          string = "Path to an exported registry file (.reg) used to evaluate registry assertions instead of the registry of the local system. May be repeated; later files are applied on top of earlier files."
+	windowsRootFlagHelp           string = "Path where the system drive of an offline Windows system is mounted (e.g., /mnt/c). If specified, the default registry and file assertions are evaluated against the offline system using the registry hive files from that system."
+	ignoreFlagHelp                string = "Pattern used to mark matched assertion paths as ignored, in the form [TARGET:][KIND:]PATTERN. TARGET is one of registry, file, kernel, process, command, uptime or assertion=ASSERTION| and KIND is one of substring (the default), exact, glob or regex. May be repeated."
+	ignoreFileFlagHelp            string = "Path to a JSON file listing patterns used to mark matched assertion paths as ignored. May be repeated."
+	definitionsFlagHelp           string = "Path to a JSON file defining additional reboot required assertions. May be repeated."
+	definitionsModeFlagHelp       string = "Whether assertions from definition files extend or replace the built-in default assertions."
+	timeoutFlagHelp               string = "Timeout value in seconds allowed before evaluation of all assertions is abandoned and an UNKNOWN state is returned. Results from assertions which completed are still included."
+	workersFlagHelp               string = "Maximum number of assertions evaluated concurrently. Assertions are evaluated one at a time by default."
+	uptimeWarningFlagHelp         string = "Number of days of uptime after which a reboot is needed and a WARNING state is returned. Disabled by default."
+	uptimeCriticalFlagHelp        string = "Number of days of uptime after which a reboot is needed and a CRITICAL state is returned. Disabled by default."
+	stateFileFlagHelp             string = "Path to a file used to record when each assertion was first found to indicate that a reboot is needed. The file is created if it does not exist. Evaluations are stateless by default."
+	escalateAfterFlagHelp         string = "Amount of time (e.g., 72h) a reboot may be pending (as recorded in the state file) before a WARNING state is escalated to a CRITICAL state. Requires a state file. Disabled by default."
+	maintenanceWindowFlagHelp     string = "Recurring maintenance window in the form DAYS HH:MM-HH:MM (e.g., \"Sat,Sun 02:00-06:00\" or \"Mon-Fri 22:00-02:00\"). DAYS is a comma separated list of days or day ranges, or * for every day. Outside of a maintenance window a needed reboot is reported as OK. A reboot still needed after a maintenance window has passed (as recorded in the state file) is reported as CRITICAL. Requires the state-file flag. May be repeated."
+	maintenanceTimezoneFlagHelp   string = "IANA time zone name (e.g., Europe/Berlin) maintenance windows are specified in. The local time zone is used by default."
+	shutdownRootFlagHelp          string = "Path to the filesystem root used to detect a shutdown or reboot scheduled via systemd (e.g., shutdown -r +60). A scheduled shutdown is noted in the plugin output."
+	scheduledRebootOKFlagHelp     string = "Whether a needed reboot is reported as OK if a reboot has already been scheduled."
+	outputFormatFlagHelp          string = "Format of the evaluation results. The json format emits a versioned document listing the result of each assertion instead of the Nagios plugin output. The exit code is the same for all formats."
+	textfileFlagHelp              string = "Path to a .prom file (e.g., within the node_exporter textfile collector directory) replaced with metrics in the Prometheus text exposition format after each evaluation. Disabled by default."
+	daemonFlagHelp                string = "Whether assertions are re-evaluated on an interval with the results served over HTTP (/metrics, /status and /healthz) instead of evaluated once. The Nagios plugin output is not emitted."
+	listenAddressFlagHelp         string = "TCP network address (e.g., :9814 or 127.0.0.1:9814) the HTTP endpoints are served on in daemon mode."
+	intervalFlagHelp              string = "Amount of time (e.g., 5m) between evaluations in daemon mode."
+	slowThresholdFlagHelp         string = "Amount of time (e.g., 500ms, 2s) the evaluation of an assertion may take before a warning naming the assertion is logged. Disabled by default."
 )
 
 // shorthandFlagSuffix is appended to short flag help text to emphasize that
@@ -47,19 +72,104 @@ const (
 	DisableDefaultIgnoredFlagLong  string = "disable-default-ignored"
 	LogLevelFlagLong               string = "log-level"
 	LogLevelFlagShort              string = "ll"
+	ProcRootFlagLong               string = "proc-root"
+	ProcRootFlagShort              string = "pr"
+	RegistryFileFlagLong           string = "registry-file"
+	RegistryFileFlagShort          string = "rf"
+	WindowsRootFlagLong            string = "windows-root"
+	WindowsRootFlagShort           string = "wr"
+	IgnoreFlagLong                 string = "ignore"
+	IgnoreFlagShort                string = "ig"
+	IgnoreFileFlagLong             string = "ignore-file"
+	IgnoreFileFlagShort            string = "if"
+	DefinitionsFlagLong            string = "definitions"
+	DefinitionsFlagShort           string = "df"
+	DefinitionsModeFlagLong        string = "definitions-mode"
+	DefinitionsModeFlagShort       string = "dm"
+	WorkersFlagLong                string = "workers"
+	WorkersFlagShort               string = "w"
+	SlowThresholdFlagLong          string = "slow-threshold"
+	SlowThresholdFlagShort         string = "st"
+	UptimeWarningFlagLong          string = "uptime-warning"
+	UptimeWarningFlagShort         string = "uw"
+	UptimeCriticalFlagLong         string = "uptime-critical"
+	UptimeCriticalFlagShort        string = "uc"
+	StateFileFlagLong              string = "state-file"
+	StateFileFlagShort             string = "sf"
+	EscalateAfterFlagLong          string = "escalate-after"
+	EscalateAfterFlagShort         string = "ea"
+	MaintenanceWindowFlagLong      string = "maintenance-window"
+	MaintenanceWindowFlagShort     string = "mw"
+	MaintenanceTimezoneFlagLong    string = "maintenance-timezone"
+	MaintenanceTimezoneFlagShort   string = "mtz"
+	ShutdownRootFlagLong           string = "shutdown-root"
+	ShutdownRootFlagShort          string = "shr"
+	ScheduledRebootOKFlagLong      string = "scheduled-reboot-ok"
+	ScheduledRebootOKFlagShort     string = "sro"
+	OutputFormatFlagLong           string = "output"
+	OutputFormatFlagShort          string = "o"
+	TextfileFlagLong               string = "textfile"
+	TextfileFlagShort              string = "tf"
+	DaemonFlagLong                 string = "daemon"
+	DaemonFlagShort                string = "d"
+	ListenAddressFlagLong          string = "listen-address"
+	ListenAddressFlagShort         string = "la"
+	IntervalFlagLong               string = "interval"
+	IntervalFlagShort              string = "iv"
 )
 
 // Default flag settings if not overridden by user input
 const (
-	defaultLogLevel              string = "info"
-	defaultBranding              bool   = false
-	defaultVerboseOutput         bool   = false
-	defaultShowIgnored           bool   = false
-	defaultDisableDefaultIgnored bool   = false
-	defaultDisplayVersionAndExit bool   = false
+	defaultLogLevel              string        = "info"
+	defaultBranding              bool          = false
+	defaultVerboseOutput         bool          = false
+	defaultShowIgnored           bool          = false
+	defaultDisableDefaultIgnored bool          = false
+	defaultDisplayVersionAndExit bool          = false
+	defaultProcRoot              string        = "/proc"
+	defaultWindowsRoot           string        = ""
+	defaultDefinitionsMode       string        = DefinitionsModeExtend
+	defaultTimeout               int           = 30
+	defaultWorkers               int           = 1
+	defaultUptimeWarning         int           = 0
+	defaultUptimeCritical        int           = 0
+	defaultStateFile             string        = ""
+	defaultEscalateAfter         time.Duration = 0
+	defaultSlowThreshold         time.Duration = 0
+	defaultMaintenanceTimezone   string        = ""
+	defaultShutdownRoot          string        = "/"
+	defaultScheduledRebootOK     bool          = false
+	defaultOutputFormat          string        = OutputFormatNagios
+	defaultTextfile              string        = ""
+	defaultDaemon                bool          = false
+	defaultListenAddress         string        = ":9814"
+	defaultInterval              time.Duration = 5 * time.Minute
+)
+
+// Supported definitions modes.
+const (
+	// DefinitionsModeExtend indicates that assertions from definition files
+	// are evaluated in addition to the built-in default assertions.
+	DefinitionsModeExtend string = "extend"
+
+	// DefinitionsModeReplace indicates that assertions from definition files
+	// are evaluated instead of the built-in default assertions.
+	DefinitionsModeReplace string = "replace"
+)
+
+// Supported output formats.
+const (
+	// OutputFormatNagios emits the evaluation results as Nagios plugin
+	// output.
+	OutputFormatNagios string = "nagios"
+
+	// OutputFormatJSON emits the evaluation results as a versioned JSON
+	// document.
+	OutputFormatJSON string = "json"
 )
 
 const (
-	appTypePlugin    string = "plugin"
-	appTypeInspector string = "inspector"
+	appTypePlugin        string = "plugin"
+	appTypeRestartPlugin string = "restart-plugin"
+	appTypeInspector     string = "inspector"
 )
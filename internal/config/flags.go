@@ -11,8 +11,34 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 )
 
+// multiValueStringFlag is a custom type that satisfies the flag.Value
+// interface in order to accept multiple string values for a flag which may
+// be repeated.
+type multiValueStringFlag []string
+
+// String returns a comma separated string consisting of all slice elements.
+func (mvs *multiValueStringFlag) String() string {
+	// From the `flag` package docs:
+	// "The flag package may call the String method with a zero-valued
+	// receiver, such as a nil pointer."
+	if mvs == nil {
+		return ""
+	}
+
+	return strings.Join(*mvs, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (mvs *multiValueStringFlag) Set(value string) error {
+	*mvs = append(*mvs, strings.TrimSpace(value))
+
+	return nil
+}
+
 // supportedValuesFlagHelpText is a flag package helper function that combines
 // base help text with a list of supported values for the flag.
 func supportedValuesFlagHelpText(baseHelpText string, supportedValues []string) string {
@@ -45,7 +71,7 @@ func (c *Config) handleFlagsConfig(appType AppType) {
 
 	// Flags specific to one application type or the other
 	switch {
-	case appType.Plugin:
+	case appType.Plugin, appType.RestartPlugin:
 
 		// Override the default Help output with a brief lead-in summary of
 		// the expected syntax and project version.
@@ -58,6 +84,89 @@ func (c *Config) handleFlagsConfig(appType AppType) {
 
 		appDescription = "Nagios plugin used to monitor for the need to reboot a system or services."
 
+		if appType.RestartPlugin {
+			appDescription = "Nagios plugin used to monitor for services (processes) needing a restart."
+
+			flag.StringVar(&c.ProcRoot, ProcRootFlagShort, defaultProcRoot, procRootFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.ProcRoot, ProcRootFlagLong, defaultProcRoot, procRootFlagHelp)
+		}
+
+		if appType.Plugin {
+			flag.Var(&c.RegistryFiles, RegistryFileFlagShort, registryFileFlagHelp+shorthandFlagSuffix)
+			flag.Var(&c.RegistryFiles, RegistryFileFlagLong, registryFileFlagHelp)
+
+			flag.StringVar(&c.WindowsRoot, WindowsRootFlagShort, defaultWindowsRoot, windowsRootFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.WindowsRoot, WindowsRootFlagLong, defaultWindowsRoot, windowsRootFlagHelp)
+
+			flag.Var(&c.Definitions, DefinitionsFlagShort, definitionsFlagHelp+shorthandFlagSuffix)
+			flag.Var(&c.Definitions, DefinitionsFlagLong, definitionsFlagHelp)
+
+			flag.StringVar(
+				&c.DefinitionsMode,
+				DefinitionsModeFlagShort,
+				defaultDefinitionsMode,
+				supportedValuesFlagHelpText(definitionsModeFlagHelp, supportedDefinitionsModes())+shorthandFlagSuffix,
+			)
+			flag.StringVar(
+				&c.DefinitionsMode,
+				DefinitionsModeFlagLong,
+				defaultDefinitionsMode,
+				supportedValuesFlagHelpText(definitionsModeFlagHelp, supportedDefinitionsModes()),
+			)
+
+			flag.DurationVar(&c.SlowThreshold, SlowThresholdFlagShort, defaultSlowThreshold, slowThresholdFlagHelp+shorthandFlagSuffix)
+			flag.DurationVar(&c.SlowThreshold, SlowThresholdFlagLong, defaultSlowThreshold, slowThresholdFlagHelp)
+
+			flag.IntVar(&c.uptimeWarning, UptimeWarningFlagShort, defaultUptimeWarning, uptimeWarningFlagHelp+shorthandFlagSuffix)
+			flag.IntVar(&c.uptimeWarning, UptimeWarningFlagLong, defaultUptimeWarning, uptimeWarningFlagHelp)
+
+			flag.IntVar(&c.uptimeCritical, UptimeCriticalFlagShort, defaultUptimeCritical, uptimeCriticalFlagHelp+shorthandFlagSuffix)
+			flag.IntVar(&c.uptimeCritical, UptimeCriticalFlagLong, defaultUptimeCritical, uptimeCriticalFlagHelp)
+
+			flag.StringVar(&c.StateFile, StateFileFlagShort, defaultStateFile, stateFileFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.StateFile, StateFileFlagLong, defaultStateFile, stateFileFlagHelp)
+
+			flag.DurationVar(&c.EscalateAfter, EscalateAfterFlagShort, defaultEscalateAfter, escalateAfterFlagHelp+shorthandFlagSuffix)
+			flag.DurationVar(&c.EscalateAfter, EscalateAfterFlagLong, defaultEscalateAfter, escalateAfterFlagHelp)
+
+			flag.Var(&c.MaintenanceWindow, MaintenanceWindowFlagShort, maintenanceWindowFlagHelp+shorthandFlagSuffix)
+			flag.Var(&c.MaintenanceWindow, MaintenanceWindowFlagLong, maintenanceWindowFlagHelp)
+
+			flag.StringVar(&c.MaintenanceTimezone, MaintenanceTimezoneFlagShort, defaultMaintenanceTimezone, maintenanceTimezoneFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.MaintenanceTimezone, MaintenanceTimezoneFlagLong, defaultMaintenanceTimezone, maintenanceTimezoneFlagHelp)
+
+			flag.StringVar(&c.ShutdownRoot, ShutdownRootFlagShort, defaultShutdownRoot, shutdownRootFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.ShutdownRoot, ShutdownRootFlagLong, defaultShutdownRoot, shutdownRootFlagHelp)
+
+			flag.BoolVar(&c.ScheduledRebootOK, ScheduledRebootOKFlagShort, defaultScheduledRebootOK, scheduledRebootOKFlagHelp+shorthandFlagSuffix)
+			flag.BoolVar(&c.ScheduledRebootOK, ScheduledRebootOKFlagLong, defaultScheduledRebootOK, scheduledRebootOKFlagHelp)
+
+			flag.StringVar(
+				&c.OutputFormat,
+				OutputFormatFlagShort,
+				defaultOutputFormat,
+				supportedValuesFlagHelpText(outputFormatFlagHelp, supportedOutputFormats())+shorthandFlagSuffix,
+			)
+			flag.StringVar(
+				&c.OutputFormat,
+				OutputFormatFlagLong,
+				defaultOutputFormat,
+				supportedValuesFlagHelpText(outputFormatFlagHelp, supportedOutputFormats()),
+			)
+
+			flag.StringVar(&c.Textfile, TextfileFlagShort, defaultTextfile, textfileFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.Textfile, TextfileFlagLong, defaultTextfile, textfileFlagHelp)
+
+			flag.BoolVar(&c.Daemon, DaemonFlagShort, defaultDaemon, daemonFlagHelp+shorthandFlagSuffix)
+			flag.BoolVar(&c.Daemon, DaemonFlagLong, defaultDaemon, daemonFlagHelp)
+
+			flag.StringVar(&c.ListenAddress, ListenAddressFlagShort, defaultListenAddress, listenAddressFlagHelp+shorthandFlagSuffix)
+			flag.StringVar(&c.ListenAddress, ListenAddressFlagLong, defaultListenAddress, listenAddressFlagHelp)
+
+			flag.DurationVar(&c.Interval, IntervalFlagShort, defaultInterval, intervalFlagHelp+shorthandFlagSuffix)
+			flag.DurationVar(&c.Interval, IntervalFlagLong, defaultInterval, intervalFlagHelp)
+		}
+
 		flag.BoolVar(&c.EmitBranding, BrandingFlag, defaultBranding, brandingFlagHelp)
 
 		flag.BoolVar(&c.VerboseOutput, VerboseFlagShort, defaultVerboseOutput, verboseOutputFlagHelp+shorthandFlagSuffix)
@@ -69,6 +178,18 @@ func (c *Config) handleFlagsConfig(appType AppType) {
 		flag.BoolVar(&c.DisableDefaultIgnored, DisableDefaultIgnoredFlagShort, defaultDisableDefaultIgnored, disableDefaultIgnoredFlagHelp+shorthandFlagSuffix)
 		flag.BoolVar(&c.DisableDefaultIgnored, DisableDefaultIgnoredFlagLong, defaultDisableDefaultIgnored, disableDefaultIgnoredFlagHelp)
 
+		flag.Var(&c.Ignore, IgnoreFlagShort, ignoreFlagHelp+shorthandFlagSuffix)
+		flag.Var(&c.Ignore, IgnoreFlagLong, ignoreFlagHelp)
+
+		flag.Var(&c.IgnoreFiles, IgnoreFileFlagShort, ignoreFileFlagHelp+shorthandFlagSuffix)
+		flag.Var(&c.IgnoreFiles, IgnoreFileFlagLong, ignoreFileFlagHelp)
+
+		flag.IntVar(&c.timeout, TimeoutFlagShort, defaultTimeout, timeoutFlagHelp+shorthandFlagSuffix)
+		flag.IntVar(&c.timeout, TimeoutFlagLong, defaultTimeout, timeoutFlagHelp)
+
+		flag.IntVar(&c.Workers, WorkersFlagShort, defaultWorkers, workersFlagHelp+shorthandFlagSuffix)
+		flag.IntVar(&c.Workers, WorkersFlagLong, defaultWorkers, workersFlagHelp)
+
 	case appType.Inspector:
 
 		// Override the default Help output with a brief lead-in summary of
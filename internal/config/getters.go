@@ -7,6 +7,13 @@
 
 package config
 
+import (
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/maintenance"
+)
+
 // supportedLogLevels returns a list of valid log levels supported by tools in
 // this project.
 func supportedLogLevels() []string {
@@ -21,3 +28,68 @@ func supportedLogLevels() []string {
 		LogLevelTrace,
 	}
 }
+
+// supportedDefinitionsModes returns a list of valid modes for applying
+// assertions from definition files.
+func supportedDefinitionsModes() []string {
+	return []string{
+		DefinitionsModeExtend,
+		DefinitionsModeReplace,
+	}
+}
+
+// supportedOutputFormats returns a list of valid formats for emitting
+// evaluation results.
+func supportedOutputFormats() []string {
+	return []string{
+		OutputFormatNagios,
+		OutputFormatJSON,
+	}
+}
+
+// IgnorePatterns returns the parsed user-specified ignore patterns.
+func (c Config) IgnorePatterns() (restart.IgnorePatterns, error) {
+	ignorePatterns := make(restart.IgnorePatterns, 0, len(c.Ignore))
+	for _, pattern := range c.Ignore {
+		ip, err := restart.ParseIgnorePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		ignorePatterns = append(ignorePatterns, ip)
+	}
+
+	return ignorePatterns, nil
+}
+
+// MaintenanceWindows returns the parsed user-specified maintenance windows
+// in the user-specified time zone.
+func (c Config) MaintenanceWindows() (maintenance.Windows, error) {
+	location, err := maintenance.LoadLocation(c.MaintenanceTimezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return maintenance.ParseWindows(c.MaintenanceWindow, location)
+}
+
+// Timeout converts the user-specified timeout value in seconds to an
+// appropriate time duration value for use with setting a deadline for the
+// evaluation of all assertions.
+func (c Config) Timeout() time.Duration {
+	return time.Duration(c.timeout) * time.Second
+}
+
+// UptimeWarning converts the user-specified uptime WARNING threshold in days
+// to an appropriate time duration value. Zero indicates that the threshold
+// is not applied.
+func (c Config) UptimeWarning() time.Duration {
+	return time.Duration(c.uptimeWarning) * 24 * time.Hour
+}
+
+// UptimeCritical converts the user-specified uptime CRITICAL threshold in
+// days to an appropriate time duration value. Zero indicates that the
+// threshold is not applied.
+func (c Config) UptimeCritical() time.Duration {
+	return time.Duration(c.uptimeCritical) * 24 * time.Hour
+}
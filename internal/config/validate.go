@@ -9,6 +9,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/atc0005/check-restart/internal/textutils"
 )
@@ -20,7 +21,205 @@ func (c Config) validate(appType AppType) error {
 	switch {
 	case appType.Inspector:
 
-	case appType.Plugin:
+	case appType.Plugin, appType.RestartPlugin:
+
+		if appType.RestartPlugin && c.ProcRoot == "" {
+			return fmt.Errorf(
+				"%w: missing proc filesystem root path",
+				ErrUnsupportedOption,
+			)
+		}
+
+		if c.timeout < 1 {
+			return fmt.Errorf(
+				"%w: invalid timeout value %d provided; minimum value is 1",
+				ErrUnsupportedOption,
+				c.timeout,
+			)
+		}
+
+		if c.Workers < 1 {
+			return fmt.Errorf(
+				"%w: invalid number of workers %d provided; minimum value is 1",
+				ErrUnsupportedOption,
+				c.Workers,
+			)
+		}
+
+		for _, registryFile := range c.RegistryFiles {
+			if registryFile == "" {
+				return fmt.Errorf(
+					"%w: empty registry file path",
+					ErrUnsupportedOption,
+				)
+			}
+		}
+
+		if c.WindowsRoot != "" && len(c.RegistryFiles) > 0 {
+			return fmt.Errorf(
+				"%w: registry files cannot be used with an offline Windows system root",
+				ErrUnsupportedOption,
+			)
+		}
+
+		if _, err := c.IgnorePatterns(); err != nil {
+			return fmt.Errorf(
+				"%w: %v",
+				ErrUnsupportedOption,
+				err,
+			)
+		}
+
+		for _, ignoreFile := range c.IgnoreFiles {
+			if ignoreFile == "" {
+				return fmt.Errorf(
+					"%w: empty ignore file path",
+					ErrUnsupportedOption,
+				)
+			}
+		}
+
+		for _, definitionsFile := range c.Definitions {
+			if definitionsFile == "" {
+				return fmt.Errorf(
+					"%w: empty definitions file path",
+					ErrUnsupportedOption,
+				)
+			}
+		}
+
+		if appType.Plugin {
+			supportedDefinitionsModes := supportedDefinitionsModes()
+			if !textutils.InList(c.DefinitionsMode, supportedDefinitionsModes, false) {
+				return fmt.Errorf(
+					"%w: invalid definitions mode;"+
+						" got %v, expected one of %v",
+					ErrUnsupportedOption,
+					c.DefinitionsMode,
+					supportedDefinitionsModes,
+				)
+			}
+
+			if c.DefinitionsMode == DefinitionsModeReplace && len(c.Definitions) == 0 {
+				return fmt.Errorf(
+					"%w: definitions mode %q requires at least one definitions file",
+					ErrUnsupportedOption,
+					DefinitionsModeReplace,
+				)
+			}
+
+			if c.SlowThreshold < 0 {
+				return fmt.Errorf(
+					"%w: invalid slow threshold %s provided; value must not be negative",
+					ErrUnsupportedOption,
+					c.SlowThreshold,
+				)
+			}
+
+			if c.uptimeWarning < 0 || c.uptimeCritical < 0 {
+				return fmt.Errorf(
+					"%w: invalid uptime thresholds (warning: %d, critical: %d) provided; values must not be negative",
+					ErrUnsupportedOption,
+					c.uptimeWarning,
+					c.uptimeCritical,
+				)
+			}
+
+			if c.uptimeWarning > 0 && c.uptimeCritical > 0 && c.uptimeWarning > c.uptimeCritical {
+				return fmt.Errorf(
+					"%w: uptime warning threshold of %d days exceeds critical threshold of %d days",
+					ErrUnsupportedOption,
+					c.uptimeWarning,
+					c.uptimeCritical,
+				)
+			}
+
+			if c.EscalateAfter < 0 {
+				return fmt.Errorf(
+					"%w: invalid escalation grace period %s provided; value must not be negative",
+					ErrUnsupportedOption,
+					c.EscalateAfter,
+				)
+			}
+
+			if c.EscalateAfter > 0 && c.StateFile == "" {
+				return fmt.Errorf(
+					"%w: escalation grace period requires a state file",
+					ErrUnsupportedOption,
+				)
+			}
+
+			supportedOutputFormats := supportedOutputFormats()
+			if !textutils.InList(c.OutputFormat, supportedOutputFormats, false) {
+				return fmt.Errorf(
+					"%w: invalid output format;"+
+						" got %v, expected one of %v",
+					ErrUnsupportedOption,
+					c.OutputFormat,
+					supportedOutputFormats,
+				)
+			}
+
+			// The textfile collector only reads files with a .prom extension.
+			if c.Textfile != "" && !strings.HasSuffix(c.Textfile, ".prom") {
+				return fmt.Errorf(
+					"%w: invalid textfile %q; a .prom file extension is required",
+					ErrUnsupportedOption,
+					c.Textfile,
+				)
+			}
+
+			if c.Daemon {
+				if c.Interval <= 0 {
+					return fmt.Errorf(
+						"%w: invalid interval %s provided; value must be greater than zero",
+						ErrUnsupportedOption,
+						c.Interval,
+					)
+				}
+
+				if c.ListenAddress == "" {
+					return fmt.Errorf(
+						"%w: empty listen address",
+						ErrUnsupportedOption,
+					)
+				}
+
+				// The JSON document is served by the /status endpoint instead.
+				if c.OutputFormat == OutputFormatJSON {
+					return fmt.Errorf(
+						"%w: output format %q is not supported in daemon mode",
+						ErrUnsupportedOption,
+						c.OutputFormat,
+					)
+				}
+			}
+
+			if c.ShutdownRoot == "" {
+				return fmt.Errorf(
+					"%w: empty shutdown root path",
+					ErrUnsupportedOption,
+				)
+			}
+
+			if _, err := c.MaintenanceWindows(); err != nil {
+				return fmt.Errorf(
+					"%w: %v",
+					ErrUnsupportedOption,
+					err,
+				)
+			}
+
+			// Without a state file a reboot still needed after a maintenance
+			// window has passed cannot be detected and would be reported as
+			// OK indefinitely.
+			if len(c.MaintenanceWindow) > 0 && c.StateFile == "" {
+				return fmt.Errorf(
+					"%w: maintenance windows require a state file",
+					ErrUnsupportedOption,
+				)
+			}
+		}
 
 		// Validate the specified logging level
 		supportedLogLevels := supportedLogLevels()
@@ -0,0 +1,52 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateMaintenanceWindowStateFile asserts that maintenance windows
+// are only accepted along with a state file.
+func TestValidateMaintenanceWindowStateFile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		stateFile string
+		wantErr   bool
+	}{
+		"without state file": {stateFile: "", wantErr: true},
+		"with state file":    {stateFile: "/var/lib/check-restart/state.json", wantErr: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := Config{
+				MaintenanceWindow: multiValueStringFlag{"Sat 02:00-06:00"},
+				StateFile:         tt.stateFile,
+				timeout:           defaultTimeout,
+				Workers:           defaultWorkers,
+				DefinitionsMode:   defaultDefinitionsMode,
+				OutputFormat:      defaultOutputFormat,
+				ShutdownRoot:      defaultShutdownRoot,
+				LoggingLevel:      defaultLogLevel,
+			}
+
+			err := c.validate(AppType{Plugin: true})
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrUnsupportedOption):
+				t.Errorf("ERROR: expected ErrUnsupportedOption; got %v", err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("ERROR: unexpected error: %v", err)
+			}
+		})
+	}
+}
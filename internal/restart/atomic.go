@@ -0,0 +1,69 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomically replaces the given file with the content written by
+// the given function. The content is written to a temporary file in the
+// same directory which is then renamed over the given file so that readers
+// never observe a partially written file and an interrupted write does not
+// leave a partial file behind. The given file is replaced with the given
+// permissions; unlike os.WriteFile the umask is not applied.
+func WriteFileAtomically(filename string, perm os.FileMode, write func(w io.Writer) error) error {
+	dir, base := filepath.Split(filepath.Clean(filename))
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	// Remove the temporary file if not renamed to the given file.
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Printf("error removing temporary file %q: %v", tmp.Name(), err)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// Temporary files are created with 0600 permissions; the permissions are
+	// applied before the file is renamed so that readers never observe the
+	// file with other permissions.
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set permissions of temporary file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filename, err)
+	}
+
+	return nil
+}
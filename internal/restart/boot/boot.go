@@ -0,0 +1,32 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupportedPlatform indicates that determining the boot time is not
+// supported on the current platform.
+var ErrUnsupportedPlatform = errors.New("boot time not supported on this platform")
+
+// ErrInvalidBootTime indicates that the boot time could not be parsed from
+// the source used to determine it.
+var ErrInvalidBootTime = errors.New("invalid boot time")
+
+// ErrInvalidUptime indicates that the uptime could not be parsed from the
+// source used to determine it.
+var ErrInvalidUptime = errors.New("invalid uptime")
+
+// Provider is a function which returns the time the system was last booted.
+type Provider func() (time.Time, error)
+
+// UptimeProvider is a function which returns the amount of time elapsed
+// since the system was last booted.
+type UptimeProvider func() (time.Duration, error)
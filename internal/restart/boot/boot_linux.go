@@ -0,0 +1,126 @@
+//go:build linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procStatPath is the path to the kernel/system statistics file which
+// records the boot time.
+const procStatPath string = "/proc/stat"
+
+// procUptimePath is the path to the file which records the number of seconds
+// elapsed since the system was started.
+const procUptimePath string = "/proc/uptime"
+
+// Time returns the time the system was last booted as recorded by the btime
+// entry of /proc/stat.
+func Time() (time.Time, error) {
+	fh, err := os.Open(procStatPath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open %s: %w", procStatPath, err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", procStatPath, err)
+		}
+	}()
+
+	bootTime, err := parseProcStat(fh)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", procStatPath, err)
+	}
+
+	logger.Printf("Boot time %s retrieved from %s", bootTime.Format(time.RFC3339), procStatPath)
+
+	return bootTime, nil
+}
+
+// parseProcStat retrieves the boot time from the btime entry (seconds since
+// the epoch) of /proc/stat content.
+func parseProcStat(r io.Reader) (time.Time, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || seconds <= 0 {
+			return time.Time{}, fmt.Errorf("btime value %q: %w", fields[1], ErrInvalidBootTime)
+		}
+
+		return time.Unix(seconds, 0), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Time{}, fmt.Errorf("btime entry not found: %w", ErrInvalidBootTime)
+}
+
+// Uptime returns the amount of time elapsed since the system was started as
+// recorded by /proc/uptime.
+func Uptime() (time.Duration, error) {
+	fh, err := os.Open(procUptimePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", procUptimePath, err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", procUptimePath, err)
+		}
+	}()
+
+	uptime, err := parseProcUptime(fh)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", procUptimePath, err)
+	}
+
+	logger.Printf("Uptime %s retrieved from %s", uptime, procUptimePath)
+
+	return uptime, nil
+}
+
+// parseProcUptime retrieves the uptime from the first field (seconds since
+// the system was started) of /proc/uptime content.
+func parseProcUptime(r io.Reader) (time.Duration, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("uptime entry not found: %w", ErrInvalidUptime)
+	}
+
+	fields := strings.Fields(scanner.Text())
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("uptime entry not found: %w", ErrInvalidUptime)
+	}
+
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("uptime value %q: %w", fields[0], ErrInvalidUptime)
+	}
+
+	return time.Duration(seconds * float64(time.Second)).Truncate(time.Second), nil
+}
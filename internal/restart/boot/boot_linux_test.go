@@ -0,0 +1,67 @@
+//go:build linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseProcStat asserts that the boot time is parsed from the btime
+// entry of /proc/stat content.
+func TestParseProcStat(t *testing.T) {
+	t.Parallel()
+
+	const content = `cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+intr 199292231 21 10 0 0 0 0 3 0 1 0 0 0 0 0 0 0 0
+ctxt 1990473
+btime 1062191376
+processes 2915
+`
+
+	got, err := parseProcStat(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse content: %v", err)
+	}
+
+	if want := time.Unix(1062191376, 0); !got.Equal(want) {
+		t.Errorf("ERROR: got boot time %s; want %s", got, want)
+	}
+
+	for _, invalid := range []string{"ctxt 1990473\n", "btime soon\n"} {
+		if _, err := parseProcStat(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidBootTime) {
+			t.Errorf("ERROR: expected ErrInvalidBootTime for %q; got %v", invalid, err)
+		}
+	}
+}
+
+// TestParseProcUptime asserts that the uptime is parsed from the first field
+// of /proc/uptime content.
+func TestParseProcUptime(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseProcUptime(strings.NewReader("3888000.57 15372415.23\n"))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse content: %v", err)
+	}
+
+	if want := 45 * 24 * time.Hour; got != want {
+		t.Errorf("ERROR: got uptime %s; want %s", got, want)
+	}
+
+	for _, invalid := range []string{"", "soon 15372415.23\n", "-1.00 0.00\n"} {
+		if _, err := parseProcUptime(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidUptime) {
+			t.Errorf("ERROR: expected ErrInvalidUptime for %q; got %v", invalid, err)
+		}
+	}
+}
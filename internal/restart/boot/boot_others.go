@@ -0,0 +1,26 @@
+//go:build !linux && !windows
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"time"
+)
+
+// Time returns ErrUnsupportedPlatform; determining the boot time is only
+// supported on Linux and Windows systems.
+func Time() (time.Time, error) {
+	return time.Time{}, ErrUnsupportedPlatform
+}
+
+// Uptime returns ErrUnsupportedPlatform; determining the uptime is only
+// supported on Linux and Windows systems.
+func Uptime() (time.Duration, error) {
+	return 0, ErrUnsupportedPlatform
+}
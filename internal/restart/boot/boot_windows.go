@@ -0,0 +1,55 @@
+//go:build windows
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// procGetTickCount64 retrieves the number of milliseconds that have elapsed
+// since the system was started.
+//
+// nolint:gochecknoglobals
+var procGetTickCount64 = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetTickCount64")
+
+// Time returns the time the system was last booted as determined by the
+// number of milliseconds elapsed since the system was started.
+func Time() (time.Time, error) {
+	uptime, err := Uptime()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	bootTime := time.Now().Add(-uptime).Truncate(time.Second)
+
+	logger.Printf("Boot time %s determined from uptime %s", bootTime.Format(time.RFC3339), uptime)
+
+	return bootTime, nil
+}
+
+// Uptime returns the amount of time elapsed since the system was started as
+// determined by the number of milliseconds reported by GetTickCount64.
+func Uptime() (time.Duration, error) {
+	if err := procGetTickCount64.Find(); err != nil {
+		return 0, fmt.Errorf("failed to locate GetTickCount64: %w", err)
+	}
+
+	// GetTickCount64 does not fail; the return value is the tick count.
+	ticks, _, _ := procGetTickCount64.Call()
+
+	uptime := time.Duration(ticks) * time.Millisecond
+
+	logger.Printf("Uptime %s determined from tick count %d", uptime, ticks)
+
+	return uptime, nil
+}
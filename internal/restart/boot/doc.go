@@ -0,0 +1,11 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package boot provides functionality used to determine when the system was
+// last booted, how long it has been running since and whether a shutdown or
+// reboot has already been scheduled.
+package boot
@@ -0,0 +1,136 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduledShutdownPath is the path (relative to the filesystem root) to the
+// file used by systemd to record a scheduled shutdown or reboot (e.g., via
+// shutdown -r +60).
+const ScheduledShutdownPath string = "run/systemd/shutdown/scheduled"
+
+// ErrInvalidScheduledShutdown indicates that a scheduled shutdown could not
+// be parsed from the source used to record it.
+var ErrInvalidScheduledShutdown = errors.New("invalid scheduled shutdown")
+
+// Supported scheduled shutdown modes.
+const (
+	ShutdownModePoweroff string = "poweroff"
+	ShutdownModeReboot   string = "reboot"
+	ShutdownModeHalt     string = "halt"
+	ShutdownModeKexec    string = "kexec"
+)
+
+// ScheduledShutdown is a shutdown or reboot scheduled by an administrator.
+type ScheduledShutdown struct {
+	// Time is when the shutdown or reboot is scheduled to occur.
+	Time time.Time
+
+	// Mode is the scheduled action (e.g., reboot or poweroff).
+	Mode string
+}
+
+// IsReboot indicates whether the scheduled shutdown restarts the system.
+func (ss ScheduledShutdown) IsReboot() bool {
+	return ss.Mode == ShutdownModeReboot || ss.Mode == ShutdownModeKexec
+}
+
+// String provides a brief description of the scheduled shutdown suitable
+// for display (e.g., "reboot scheduled for 2023-05-10T14:00:00Z").
+func (ss ScheduledShutdown) String() string {
+	return fmt.Sprintf("%s scheduled for %s", ss.Mode, ss.Time.Format(time.RFC3339))
+}
+
+// Scheduled returns the shutdown or reboot recorded by systemd as scheduled
+// within the given filesystem root (e.g., "/"). A boolean value is returned
+// indicating whether a shutdown or reboot has been scheduled.
+func Scheduled(root string) (ScheduledShutdown, bool, error) {
+	filename := filepath.Join(root, filepath.FromSlash(ScheduledShutdownPath))
+
+	fh, err := os.Open(filepath.Clean(filename))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logger.Printf("Scheduled shutdown file %s not found; no shutdown scheduled", filename)
+
+		return ScheduledShutdown{}, false, nil
+
+	case err != nil:
+		return ScheduledShutdown{}, false, fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", filename, err)
+		}
+	}()
+
+	scheduled, err := ParseScheduledShutdown(fh)
+	if err != nil {
+		return ScheduledShutdown{}, false, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	logger.Printf("Scheduled shutdown (%s) retrieved from %s", scheduled, filename)
+
+	return scheduled, true, nil
+}
+
+// ParseScheduledShutdown retrieves the scheduled shutdown from the USEC
+// (microseconds since the epoch) and MODE entries of systemd scheduled
+// shutdown file content:
+//
+//	USEC=1683727200000000
+//	WARN_WALL=1
+//	MODE=reboot
+func ParseScheduledShutdown(r io.Reader) (ScheduledShutdown, error) {
+	var scheduled ScheduledShutdown
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "USEC":
+			usec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || usec <= 0 {
+				return ScheduledShutdown{}, fmt.Errorf("USEC value %q: %w", value, ErrInvalidScheduledShutdown)
+			}
+
+			scheduled.Time = time.UnixMicro(usec)
+
+		case "MODE":
+			scheduled.Mode = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return ScheduledShutdown{}, err
+	}
+
+	switch {
+	case scheduled.Time.IsZero():
+		return ScheduledShutdown{}, fmt.Errorf("USEC entry not found: %w", ErrInvalidScheduledShutdown)
+
+	case scheduled.Mode == "":
+		return ScheduledShutdown{}, fmt.Errorf("MODE entry not found: %w", ErrInvalidScheduledShutdown)
+	}
+
+	return scheduled, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package boot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestScheduled asserts that a reboot scheduled via systemd is retrieved
+// from a scheduled shutdown file within the given filesystem root and that
+// a missing file indicates that no shutdown is scheduled.
+func TestScheduled(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	if _, ok, err := Scheduled(root); ok || err != nil {
+		t.Fatalf("ERROR: got scheduled %t (%v) for missing file; want none", ok, err)
+	}
+
+	filename := filepath.Join(root, filepath.FromSlash(ScheduledShutdownPath))
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		t.Fatalf("ERROR: failed to create fixture directory: %v", err)
+	}
+
+	const content = "USEC=1683727200000000\nWARN_WALL=1\nMODE=reboot\n"
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create fixture file: %v", err)
+	}
+
+	scheduled, ok, err := Scheduled(root)
+	if err != nil || !ok {
+		t.Fatalf("ERROR: got scheduled %t (%v); want scheduled reboot", ok, err)
+	}
+
+	if want := time.Date(2023, 5, 10, 14, 0, 0, 0, time.UTC); !scheduled.Time.Equal(want) || !scheduled.IsReboot() {
+		t.Errorf("ERROR: got %s (reboot %t); want reboot at %s", scheduled, scheduled.IsReboot(), want)
+	}
+
+	if got, want := scheduled.String(), "reboot scheduled for "; !strings.HasPrefix(got, want) {
+		t.Errorf("ERROR: got %q; want prefix %q", got, want)
+	}
+}
+
+// TestParseScheduledShutdown asserts that incomplete or invalid scheduled
+// shutdown file content is rejected and that a scheduled poweroff is not
+// considered a reboot.
+func TestParseScheduledShutdown(t *testing.T) {
+	t.Parallel()
+
+	scheduled, err := ParseScheduledShutdown(strings.NewReader("USEC=1683727200000000\nMODE=poweroff\n"))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse content: %v", err)
+	}
+
+	if scheduled.IsReboot() {
+		t.Errorf("ERROR: scheduled poweroff considered a reboot")
+	}
+
+	for _, invalid := range []string{
+		"",
+		"MODE=reboot\n",
+		"USEC=1683727200000000\n",
+		"USEC=soon\nMODE=reboot\n",
+	} {
+		if _, err := ParseScheduledShutdown(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidScheduledShutdown) {
+			t.Errorf("ERROR: expected ErrInvalidScheduledShutdown for %q; got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,908 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserter implementation isn't correct.
+var _ restart.RebootRequiredAsserter = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithDataDisplay implementation isn't correct.
+var _ restart.RebootRequiredAsserterWithDataDisplay = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var _ restart.EvaluationTimer = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.ContextEvaluator implementation isn't correct.
+var _ restart.ContextEvaluator = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var _ restart.PendingTracker = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*Command)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*Command)(nil)
+
+// Add "implements assertions" to fail the build if the restart.MatchedPath
+// implementation isn't correct.
+var _ restart.MatchedPath = (*MatchedPath)(nil)
+
+// DefaultTimeout is the maximum amount of time a command is allowed to run
+// if a timeout is not specified.
+const DefaultTimeout time.Duration = 10 * time.Second
+
+// waitDelay is the maximum amount of time to wait for the output of a
+// command to be collected after the command has been stopped (e.g., because
+// a child process still holds the output open).
+const waitDelay time.Duration = time.Second
+
+// batchSeparator separates the key and value of a line of batch output
+// (e.g., NEEDRESTART-KSTA: 3).
+const batchSeparator string = ":"
+
+// ErrCommandTimeout indicates that a command did not complete within the
+// specified timeout.
+var ErrCommandTimeout = errors.New("command timed out")
+
+// ErrUnexpectedExitCode indicates that a command exited with a code not
+// listed as either indicating a reboot or success.
+var ErrUnexpectedExitCode = errors.New("unexpected exit code")
+
+// CommandRebootEvidence indicates what evidence is required for a Command
+// in order to determine that a reboot is needed.
+//
+//nolint:revive
+type CommandRebootEvidence struct {
+	// ExitCodeMatched is an evidence "marker" that if satisfied indicates the
+	// need for a reboot. This marker is satisfied if the command exits with
+	// one of the specified reboot exit codes.
+	ExitCodeMatched bool
+
+	// OutputMatched is an evidence "marker" that if satisfied indicates the
+	// need for a reboot. This marker is satisfied if a line of the command
+	// output matches the specified regular expression.
+	OutputMatched bool
+
+	// BatchValueMatched is an evidence "marker" that if satisfied indicates
+	// the need for a reboot. This marker is satisfied if the batch (KEY:
+	// value) output of the command lists the specified key with one of the
+	// specified values (or any value if none are specified).
+	BatchValueMatched bool
+}
+
+// CommandAssertions is a collection of requirements for a Command.
+//
+//nolint:revive
+type CommandAssertions struct {
+	// CommandRequired indicates that the command must be found. If the
+	// command is not found, an error is returned.
+	CommandRequired bool
+}
+
+// CommandRuntime is a collection of values for a Command that are set during
+// evaluation. Unlike the static values set for a Command (e.g., arguments,
+// expected evidence), these values are not known until execution or runtime.
+//
+//nolint:revive
+type CommandRuntime struct {
+	// err records any error that occurs while performing an evaluation.
+	err error
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound CommandRebootEvidence
+
+	// exitCode is the exit code of the command.
+	exitCode int
+
+	// matchedLine is the line of output matched by the specified regular
+	// expression or batch key.
+	matchedLine string
+
+	// pathsMatched is a collection of path values that were matched during
+	// evaluation of specified reboot required assertions.
+	pathsMatched MatchedPathIndex
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
+}
+
+// MatchedPathIndex is a collection of path values that were matched during
+// evaluation of specified reboot required assertions.
+type MatchedPathIndex map[string]MatchedPath
+
+// MatchedPath represents a command that was matched when performing an
+// evaluation of a "reboot required" assertion.
+type MatchedPath struct {
+	// root is the command name or path.
+	root string
+
+	// relative is the list of command arguments.
+	relative string
+
+	// base is the last element of the command name or path.
+	base string
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a reboot is needed.
+	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
+}
+
+// Command represents an external command whose exit code or output (if
+// requirements met) indicate a reboot is needed. The command is run directly
+// (not via a shell) and is stopped if it does not complete within the
+// specified timeout.
+type Command struct {
+	// argv is the command name (or path) followed by any arguments.
+	argv []string
+
+	// timeout is the maximum amount of time the command is allowed to run.
+	timeout time.Duration
+
+	// rebootExitCodes is the list of exit codes which indicate a reboot is
+	// needed.
+	rebootExitCodes []int
+
+	// okExitCodes is the list of exit codes which indicate the command ran
+	// successfully without indicating a reboot is needed.
+	okExitCodes []int
+
+	// pattern is the regular expression matched against each line of the
+	// command output.
+	pattern string
+
+	// re is the compiled form of pattern.
+	re *regexp.Regexp
+
+	// batchKey is the key of the batch (KEY: value) output evaluated.
+	batchKey string
+
+	// batchValues is the list of values for batchKey which indicate a
+	// reboot is needed.
+	batchValues []string
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime CommandRuntime
+
+	// evidenceExpected indicates what evidence is used to determine that a
+	// reboot is needed.
+	evidenceExpected CommandRebootEvidence
+
+	// requirements indicates what requirements must be met. If not met, this
+	// indicates that an error has occurred.
+	requirements CommandAssertions
+}
+
+// NewCommand creates a Command assertion for the given command name (or
+// path) and arguments. If not specified, DefaultTimeout is used.
+func NewCommand(argv []string, timeout time.Duration, evidence CommandRebootEvidence, requirements CommandAssertions) *Command {
+	return &Command{
+		argv:             argv,
+		timeout:          timeout,
+		evidenceExpected: evidence,
+		requirements:     requirements,
+	}
+}
+
+// SetExitCodes sets the exit codes which indicate a reboot is needed and
+// those which indicate the command ran successfully. If no successful exit
+// codes are specified, 0 is used.
+func (c *Command) SetExitCodes(rebootExitCodes []int, okExitCodes []int) {
+	c.rebootExitCodes = rebootExitCodes
+	c.okExitCodes = okExitCodes
+}
+
+// SetOutputPattern sets the regular expression matched against each line of
+// the command output.
+func (c *Command) SetOutputPattern(pattern string) {
+	c.pattern = pattern
+	c.re = nil
+}
+
+// SetBatchMatch sets the key (and values) of the batch (KEY: value) output
+// which indicate a reboot is needed. If no values are specified, any value
+// listed for the key indicates a reboot is needed.
+func (c *Command) SetBatchMatch(key string, values []string) {
+	c.batchKey = key
+	c.batchValues = values
+}
+
+// Err exposes the underlying error (if any) as-is.
+func (c *Command) Err() error {
+	return c.runtime.err
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (c *Command) EvaluationDuration() time.Duration {
+	return c.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (c *Command) SetEvaluationDuration(duration time.Duration) {
+	c.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (c *Command) PendingSince() time.Time {
+	return c.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (c *Command) Escalated() bool {
+	return c.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (c *Command) SetPending(since time.Time, escalated bool) {
+	c.runtime.pendingSince = since
+	c.runtime.escalated = escalated
+}
+
+// Timeout returns the maximum amount of time the command is allowed to run.
+func (c *Command) Timeout() time.Duration {
+	if c.timeout == 0 {
+		return DefaultTimeout
+	}
+
+	return c.timeout
+}
+
+// OKExitCodes returns the list of exit codes which indicate the command ran
+// successfully without indicating a reboot is needed.
+func (c *Command) OKExitCodes() []int {
+	if len(c.okExitCodes) == 0 {
+		return []int{0}
+	}
+
+	return c.okExitCodes
+}
+
+// ExitCode returns the exit code of the command recorded during an earlier
+// evaluation.
+func (c *Command) ExitCode() int {
+	return c.runtime.exitCode
+}
+
+// MatchedLine returns the line of output matched by the regular expression
+// or batch key during an earlier evaluation.
+func (c *Command) MatchedLine() string {
+	return c.runtime.matchedLine
+}
+
+// Validate performs basic validation. An error is returned for any validation
+// failures.
+func (c *Command) Validate() error {
+	if len(c.argv) == 0 || c.argv[0] == "" {
+		return fmt.Errorf(
+			"command not specified: %w",
+			restart.ErrMissingValue,
+		)
+	}
+
+	if c.timeout < 0 {
+		return fmt.Errorf(
+			"invalid timeout %s for command %s: %w",
+			c.timeout,
+			c,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	evidence := c.evidenceExpected
+	if !evidence.ExitCodeMatched && !evidence.OutputMatched && !evidence.BatchValueMatched {
+		return fmt.Errorf(
+			"command evidence not specified for command %s: %w",
+			c,
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	switch {
+	case evidence.ExitCodeMatched != (len(c.rebootExitCodes) > 0):
+		return fmt.Errorf(
+			"reboot exit codes must be specified with (and only with) the ExitCodeMatched evidence for command %s: %w",
+			c,
+			restart.ErrInvalidRebootEvidence,
+		)
+
+	case evidence.OutputMatched != (c.pattern != ""):
+		return fmt.Errorf(
+			"output pattern must be specified with (and only with) the OutputMatched evidence for command %s: %w",
+			c,
+			restart.ErrInvalidRebootEvidence,
+		)
+
+	case evidence.BatchValueMatched != (c.batchKey != ""):
+		return fmt.Errorf(
+			"batch key must be specified with (and only with) the BatchValueMatched evidence for command %s: %w",
+			c,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	for _, code := range c.rebootExitCodes {
+		if containsInt(c.OKExitCodes(), code) {
+			return fmt.Errorf(
+				"exit code %d listed as both reboot and successful exit code for command %s: %w",
+				code,
+				c,
+				restart.ErrInvalidRebootEvidence,
+			)
+		}
+	}
+
+	if c.pattern != "" {
+		re, err := regexp.Compile(c.pattern)
+		if err != nil {
+			return fmt.Errorf(
+				"invalid output pattern %q for command %s: %v: %w",
+				c.pattern,
+				c,
+				err,
+				restart.ErrInvalidRebootEvidence,
+			)
+		}
+		c.re = re
+	}
+
+	return nil
+}
+
+// String provides the command name (or path) and arguments. Arguments
+// containing whitespace or quotes are quoted.
+func (c *Command) String() string {
+	return quoteArgs(c.argv)
+}
+
+// DataDisplay provides a string representation of the command exit code and
+// matched output for display purposes.
+func (c *Command) DataDisplay() string {
+	if c.runtime.matchedLine != "" {
+		return fmt.Sprintf(
+			"Exit code: %d, Output: %s",
+			c.runtime.exitCode,
+			c.runtime.matchedLine,
+		)
+	}
+
+	return fmt.Sprintf("Exit code: %d", c.runtime.exitCode)
+}
+
+// Evaluate applies the specified assertion to determine if a reboot is
+// necessary.
+func (c *Command) Evaluate() {
+	c.EvaluateContext(context.Background())
+}
+
+// EvaluateContext applies the specified assertion to determine if a reboot
+// is necessary. The command is stopped if it does not complete within the
+// specified timeout or once the given context is done.
+func (c *Command) EvaluateContext(parent context.Context) {
+	logger.Printf("Given command: %s", c)
+
+	if c.pattern != "" && c.re == nil {
+		re, err := regexp.Compile(c.pattern)
+		if err != nil {
+			c.runtime.err = fmt.Errorf(
+				"invalid output pattern %q for command %s: %w",
+				c.pattern,
+				c,
+				err,
+			)
+
+			return
+		}
+		c.re = re
+	}
+
+	ctx, cancel := context.WithTimeout(parent, c.Timeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	// The command is run directly; arguments are not interpreted by a shell.
+	//
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	err := cmd.Run()
+
+	logger.Printf("Command %s completed after %s: %v", c, time.Since(start), err)
+
+	var exitErr *exec.ExitError
+
+	switch {
+	case parent.Err() != nil:
+		c.runtime.err = fmt.Errorf(
+			"command %s stopped before completing: %w",
+			c,
+			parent.Err(),
+		)
+
+		return
+
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		c.runtime.err = fmt.Errorf(
+			"command %s did not complete within %s: %w",
+			c,
+			c.Timeout(),
+			ErrCommandTimeout,
+		)
+
+		return
+
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist):
+		if c.requirements.CommandRequired {
+			logger.Printf("Command %s not found, but marked as required.", c)
+
+			c.runtime.err = fmt.Errorf(
+				"command %s not found, but marked as required: %w",
+				c,
+				restart.ErrMissingRequiredItem,
+			)
+
+			return
+		}
+
+		logger.Printf("Command %s not found, reboot not required due to this command.", c)
+
+		c.runtime.err = fmt.Errorf(
+			"command %s not found, but not marked as required: %w",
+			c,
+			restart.ErrMissingOptionalItem,
+		)
+
+		return
+
+	case errors.As(err, &exitErr):
+		c.runtime.exitCode = exitErr.ExitCode()
+
+	case err != nil:
+		c.runtime.err = fmt.Errorf("failed to run command %s: %w", c, err)
+
+		return
+	}
+
+	logger.Printf("Command %s exited with code %d", c, c.runtime.exitCode)
+
+	switch {
+	case containsInt(c.rebootExitCodes, c.runtime.exitCode):
+		logger.Println("Reboot Required!")
+		c.SetFoundEvidenceExitCodeMatched()
+
+	case !containsInt(c.OKExitCodes(), c.runtime.exitCode):
+		errMsg := firstLine(stderr.Bytes())
+		if errMsg == "" {
+			errMsg = "no error output"
+		}
+
+		c.runtime.err = fmt.Errorf(
+			"command %s exited with code %d (%s): %w",
+			c,
+			c.runtime.exitCode,
+			errMsg,
+			ErrUnexpectedExitCode,
+		)
+
+		return
+	}
+
+	if c.evidenceExpected.OutputMatched {
+		if line, ok := matchLine(stdout.Bytes(), c.re); ok {
+			logger.Println("Reboot Required!")
+			c.runtime.matchedLine = line
+			c.SetFoundEvidenceOutputMatched()
+		}
+	}
+
+	if c.evidenceExpected.BatchValueMatched {
+		batch := ParseBatchOutput(&stdout)
+
+		for _, value := range batch[c.batchKey] {
+			if len(c.batchValues) == 0 || containsString(c.batchValues, value) {
+				logger.Println("Reboot Required!")
+				c.runtime.matchedLine = c.batchKey + batchSeparator + " " + value
+				c.SetFoundEvidenceBatchValueMatched()
+
+				break
+			}
+		}
+	}
+
+	if c.HasEvidence() {
+		c.AddMatchedPath(c.String())
+	}
+}
+
+// Reset discards the results of an earlier evaluation so that the Command may
+// be evaluated again.
+func (c *Command) Reset() {
+	c.runtime = CommandRuntime{}
+}
+
+// ParseBatchOutput parses batch output (e.g., as emitted by needrestart -b)
+// consisting of KEY: value lines. Lines without a separator are skipped. The
+// values for each key are listed in the order given.
+func ParseBatchOutput(r io.Reader) map[string][]string {
+	batch := make(map[string][]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), batchSeparator)
+		if !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		batch[key] = append(batch[key], strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Printf("error reading batch output: %v", err)
+	}
+
+	return batch
+}
+
+// matchLine returns the first line of output matching the given regular
+// expression.
+func matchLine(output []byte, re *regexp.Regexp) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) {
+			return strings.TrimSpace(scanner.Text()), true
+		}
+	}
+
+	return "", false
+}
+
+// firstLine returns the first non-empty line of the given output.
+func firstLine(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// quoteArgs joins the given arguments using a space, quoting arguments which
+// contain whitespace or quotes.
+func quoteArgs(argv []string) string {
+	quoted := make([]string, 0, len(argv))
+	for _, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// containsInt indicates whether the given list contains the given value.
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsString indicates whether the given list contains the given value.
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddMatchedPath records given command strings as successful assertion
+// matches. Duplicate entries are ignored.
+func (c *Command) AddMatchedPath(paths ...string) {
+	if c.runtime.pathsMatched == nil {
+		c.runtime.pathsMatched = make(MatchedPathIndex)
+	}
+
+	for _, path := range paths {
+		if _, ok := c.runtime.pathsMatched[path]; !ok {
+			name := c.argv[0]
+
+			c.runtime.pathsMatched[path] = MatchedPath{
+				root:     name,
+				relative: strings.TrimSpace(strings.TrimPrefix(path, quoteArgs(c.argv[:1]))),
+				base:     filepath.Base(name),
+			}
+		}
+	}
+}
+
+// MatchedPaths returns all recorded paths from successful assertion matches.
+func (c *Command) MatchedPaths() restart.MatchedPaths {
+	pathStrings := make([]string, 0, len(c.runtime.pathsMatched))
+	matchedPaths := make(restart.MatchedPaths, 0, len(c.runtime.pathsMatched))
+
+	for path := range c.runtime.pathsMatched {
+		pathStrings = append(pathStrings, path)
+	}
+
+	sort.Strings(pathStrings)
+
+	for _, path := range pathStrings {
+		matchedPaths = append(matchedPaths, c.runtime.pathsMatched[path])
+	}
+
+	return matchedPaths
+}
+
+// Filter uses the list of specified ignore patterns to mark each matched path
+// for the Command as ignored *IF* a match is found.
+//
+// If no matched paths are recorded Filter makes no changes. Filter should be
+// called before performing final state evaluation.
+func (c *Command) Filter(ignorePatterns restart.IgnorePatterns) {
+	if len(ignorePatterns) == 0 {
+		logger.Printf("0 ignore patterns specified for %q; skipping Filter", c)
+		return
+	}
+
+	for originalPathString, matchedPath := range c.runtime.pathsMatched {
+		if ignorePattern, ok := ignorePatterns.Match(restart.IgnoreTargetCommand, originalPathString, c.String()); ok {
+			logger.Printf("marking matched path %q as ignored", originalPathString)
+
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			c.runtime.pathsMatched[originalPathString] = matchedPath
+		}
+	}
+}
+
+// ExpectedEvidence returns the specified evidence that (if found) indicates a
+// reboot is needed.
+func (c *Command) ExpectedEvidence() CommandRebootEvidence {
+	return c.evidenceExpected
+}
+
+// DiscoveredEvidence returns the discovered evidence from an earlier
+// evaluation.
+func (c *Command) DiscoveredEvidence() CommandRebootEvidence {
+	return c.runtime.evidenceFound
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (c *Command) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(c.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (c *Command) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(c.runtime.evidenceFound)
+}
+
+// SetFoundEvidenceExitCodeMatched records that the ExitCodeMatched reboot
+// evidence was found.
+func (c *Command) SetFoundEvidenceExitCodeMatched() {
+	logger.Printf("Recording that the ExitCodeMatched evidence was found for %q", c)
+	c.runtime.evidenceFound.ExitCodeMatched = true
+}
+
+// SetFoundEvidenceOutputMatched records that the OutputMatched reboot
+// evidence was found.
+func (c *Command) SetFoundEvidenceOutputMatched() {
+	logger.Printf("Recording that the OutputMatched evidence was found for %q", c)
+	c.runtime.evidenceFound.OutputMatched = true
+}
+
+// SetFoundEvidenceBatchValueMatched records that the BatchValueMatched
+// reboot evidence was found.
+func (c *Command) SetFoundEvidenceBatchValueMatched() {
+	logger.Printf("Recording that the BatchValueMatched evidence was found for %q", c)
+	c.runtime.evidenceFound.BatchValueMatched = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (c *Command) HasEvidence() bool {
+	return c.runtime.evidenceFound.ExitCodeMatched ||
+		c.runtime.evidenceFound.OutputMatched ||
+		c.runtime.evidenceFound.BatchValueMatched
+}
+
+// Ignored indicates whether the Command has been marked as ignored.
+func (c *Command) Ignored() bool {
+	if len(c.runtime.pathsMatched) == 0 {
+		return false
+	}
+
+	for _, v := range c.runtime.pathsMatched {
+		if !v.ignored {
+			return false
+		}
+	}
+
+	// The Command is ignored *only* if all recorded match path entries are
+	// marked as ignored.
+	return true
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the Command has been marked as ignored (all recorded matched
+// paths marked as ignored) the need for a reboot is not indicated.
+func (c *Command) RebootRequired() bool {
+	return !c.Ignored() && c.HasEvidence()
+}
+
+// IsCriticalState indicates whether an evaluation determined that the
+// Command is in a CRITICAL state. Whether the Command has been marked as
+// Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+//
+// A command which did not complete within the timeout is neither in a
+// CRITICAL nor an OK state; this results in an UNKNOWN state.
+func (c *Command) IsCriticalState() bool {
+	switch {
+	case !c.Ignored() && c.RebootRequired():
+		return false
+	case !c.Ignored() && c.Err() != nil:
+		return !errors.Is(c.Err(), restart.ErrMissingOptionalItem) &&
+			!errors.Is(c.Err(), ErrCommandTimeout)
+	default:
+		return false
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the Command
+// is in a WARNING state. Whether the Command has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior
+// to calling this method.
+func (c *Command) IsWarningState() bool {
+	return !c.Ignored() && c.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the Command is
+// in an OK state. Whether the Command has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior
+// to calling this method.
+func (c *Command) IsOKState() bool {
+	switch {
+	case c.Ignored():
+		return true
+	case c.RebootRequired():
+		return false
+	case c.Err() != nil:
+		return errors.Is(c.Err(), restart.ErrMissingOptionalItem)
+	default:
+		return true
+	}
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (c *Command) RebootReasons() []string {
+	reasons := make([]string, 0, 3)
+
+	if c.runtime.evidenceFound.ExitCodeMatched {
+		reasons = append(reasons, fmt.Sprintf(
+			"Command %s exited with code %d",
+			c,
+			c.runtime.exitCode,
+		))
+	}
+
+	if c.runtime.evidenceFound.OutputMatched || c.runtime.evidenceFound.BatchValueMatched {
+		reasons = append(reasons, fmt.Sprintf(
+			"Command %s output: %s",
+			c,
+			c.runtime.matchedLine,
+		))
+	}
+
+	return reasons
+}
+
+// Root returns the left-most element of a matched path. For a command this
+// is the command name or path.
+func (mp MatchedPath) Root() string {
+	return mp.root
+}
+
+// Rel returns the relative (unqualified) element of a matched path. For a
+// command this is the list of arguments.
+func (mp MatchedPath) Rel() string {
+	return mp.relative
+}
+
+// Base returns the last or right-most "leaf" element of a matched path. For
+// a command this is the last element of the command name or path.
+func (mp MatchedPath) Base() string {
+	return mp.base
+}
+
+// Full returns the qualified matched path value. For a command this is the
+// command name (or path) and arguments.
+func (mp MatchedPath) Full() string {
+	if mp.relative == "" {
+		return quoteArgs([]string{mp.root})
+	}
+
+	return quoteArgs([]string{mp.root}) + " " + mp.relative
+}
+
+// String provides a human readable version of the matched path value.
+func (mp MatchedPath) String() string {
+	return mp.Full()
+}
+
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
@@ -0,0 +1,302 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// helperEnvVar is set when the test binary is run as a command by
+// helperArgv.
+const helperEnvVar string = "CHECK_RESTART_COMMAND_HELPER"
+
+// TestMain runs the test binary as a helper command (writing the given
+// output and exiting with the given code) if requested by helperArgv.
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnvVar) == "" {
+		os.Exit(m.Run())
+	}
+
+	args := os.Args[1:]
+	if len(args) < 2 {
+		os.Exit(255)
+	}
+
+	if args[0] == "sleep" {
+		time.Sleep(time.Minute)
+	}
+
+	code, err := strconv.Atoi(args[0])
+	if err != nil {
+		os.Exit(255)
+	}
+
+	fmt.Print(strings.Join(args[1:], "\n"))
+	fmt.Fprint(os.Stderr, "helper error output")
+
+	os.Exit(code)
+}
+
+// helperArgv returns the argv used to run the test binary as a helper
+// command which writes the given lines of output and exits with the given
+// code (or sleeps if code is "sleep").
+func helperArgv(t *testing.T, code string, lines ...string) []string {
+	t.Helper()
+
+	t.Setenv(helperEnvVar, "1")
+
+	return append([]string{os.Args[0], code}, append(lines, "")...)
+}
+
+// TestCommandEvaluate asserts that reboot evidence is found from the exit
+// code and output of a command and that failures map to the expected state.
+func TestCommandEvaluate(t *testing.T) {
+	tests := map[string]struct {
+		code         string
+		lines        []string
+		evidence     CommandRebootEvidence
+		rebootCodes  []int
+		pattern      string
+		batchKey     string
+		batchValues  []string
+		timeout      time.Duration
+		wantEvidence bool
+		wantErr      error
+		wantCritical bool
+		wantOK       bool
+	}{
+		"reboot exit code": {
+			code:         "1",
+			evidence:     CommandRebootEvidence{ExitCodeMatched: true},
+			rebootCodes:  []int{1},
+			wantEvidence: true,
+		},
+		"successful exit code": {
+			code:        "0",
+			evidence:    CommandRebootEvidence{ExitCodeMatched: true},
+			rebootCodes: []int{1},
+			wantOK:      true,
+		},
+		"unexpected exit code": {
+			code:         "3",
+			evidence:     CommandRebootEvidence{ExitCodeMatched: true},
+			rebootCodes:  []int{1},
+			wantErr:      ErrUnexpectedExitCode,
+			wantCritical: true,
+		},
+		"output matched": {
+			code:         "0",
+			lines:        []string{"Core libraries or services have been updated:", "  kernel -> 6.1.0-21"},
+			evidence:     CommandRebootEvidence{OutputMatched: true},
+			pattern:      `^Core libraries`,
+			wantEvidence: true,
+		},
+		"output not matched": {
+			code:     "0",
+			lines:    []string{"No core libraries or services have been updated."},
+			evidence: CommandRebootEvidence{OutputMatched: true},
+			pattern:  `^Core libraries`,
+			wantOK:   true,
+		},
+		"batch value matched": {
+			code:         "0",
+			lines:        []string{"NEEDRESTART-VER: 3.6", "NEEDRESTART-KSTA: 3", "NEEDRESTART-SVC: ssh.service"},
+			evidence:     CommandRebootEvidence{BatchValueMatched: true},
+			batchKey:     "NEEDRESTART-KSTA",
+			batchValues:  []string{"2", "3"},
+			wantEvidence: true,
+		},
+		"batch value not matched": {
+			code:        "0",
+			lines:       []string{"NEEDRESTART-VER: 3.6", "NEEDRESTART-KSTA: 1"},
+			evidence:    CommandRebootEvidence{BatchValueMatched: true},
+			batchKey:    "NEEDRESTART-KSTA",
+			batchValues: []string{"2", "3"},
+			wantOK:      true,
+		},
+		"batch key listed with any value": {
+			code:         "0",
+			lines:        []string{"NEEDRESTART-VER: 3.6", "NEEDRESTART-SVC: ssh.service"},
+			evidence:     CommandRebootEvidence{BatchValueMatched: true},
+			batchKey:     "NEEDRESTART-SVC",
+			wantEvidence: true,
+		},
+		"timeout": {
+			code:        "sleep",
+			evidence:    CommandRebootEvidence{ExitCodeMatched: true},
+			rebootCodes: []int{1},
+			timeout:     100 * time.Millisecond,
+			wantErr:     ErrCommandTimeout,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := NewCommand(helperArgv(t, tt.code, tt.lines...), tt.timeout, tt.evidence, CommandAssertions{})
+			c.SetExitCodes(tt.rebootCodes, nil)
+			c.SetOutputPattern(tt.pattern)
+			c.SetBatchMatch(tt.batchKey, tt.batchValues)
+
+			if err := c.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate command: %v", err)
+			}
+
+			c.Evaluate()
+
+			if !errors.Is(c.Err(), tt.wantErr) || (tt.wantErr == nil && c.Err() != nil) {
+				t.Errorf("ERROR: got error %v; want %v", c.Err(), tt.wantErr)
+			}
+
+			if got := c.HasEvidence(); got != tt.wantEvidence {
+				t.Errorf("ERROR: got evidence %t; want %t", got, tt.wantEvidence)
+			}
+
+			if got := c.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("ERROR: got critical state %t; want %t", got, tt.wantCritical)
+			}
+
+			if got := c.IsOKState(); got != tt.wantOK {
+				t.Errorf("ERROR: got OK state %t; want %t", got, tt.wantOK)
+			}
+
+			if tt.wantEvidence && len(c.MatchedPaths()) != 1 {
+				t.Errorf("ERROR: got %d matched paths; want 1", len(c.MatchedPaths()))
+			}
+		})
+	}
+}
+
+// TestCommandEvaluateContext asserts that a command is stopped once the
+// given context is done, even if the command timeout has not been reached.
+func TestCommandEvaluateContext(t *testing.T) {
+	c := NewCommand(helperArgv(t, "sleep"), 0, CommandRebootEvidence{ExitCodeMatched: true}, CommandAssertions{})
+	c.SetExitCodes([]int{1}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	c.EvaluateContext(ctx)
+
+	if elapsed := time.Since(start); elapsed >= DefaultTimeout {
+		t.Errorf("ERROR: command stopped after %s; want before timeout %s", elapsed, DefaultTimeout)
+	}
+
+	if !errors.Is(c.Err(), context.DeadlineExceeded) || errors.Is(c.Err(), ErrCommandTimeout) {
+		t.Errorf("ERROR: got error %v; want %v", c.Err(), context.DeadlineExceeded)
+	}
+}
+
+// TestCommandNotFound asserts that a missing command results in a CRITICAL
+// state only if the command is required.
+func TestCommandNotFound(t *testing.T) {
+	t.Parallel()
+
+	argv := []string{filepath.Join(t.TempDir(), "needs-restarting"), "-r"}
+	evidence := CommandRebootEvidence{ExitCodeMatched: true}
+
+	optional := NewCommand(argv, 0, evidence, CommandAssertions{})
+	optional.SetExitCodes([]int{1}, nil)
+	optional.Evaluate()
+
+	if !errors.Is(optional.Err(), restart.ErrMissingOptionalItem) || !optional.IsOKState() {
+		t.Errorf("ERROR: got error %v and OK state %t for optional command; want OK state", optional.Err(), optional.IsOKState())
+	}
+
+	required := NewCommand(argv, 0, evidence, CommandAssertions{CommandRequired: true})
+	required.SetExitCodes([]int{1}, nil)
+	required.Evaluate()
+
+	if !errors.Is(required.Err(), restart.ErrMissingRequiredItem) || !required.IsCriticalState() {
+		t.Errorf("ERROR: got error %v and critical state %t for required command; want CRITICAL state", required.Err(), required.IsCriticalState())
+	}
+}
+
+// TestCommandValidate asserts that invalid command assertions are rejected.
+func TestCommandValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]func() *Command{
+		"missing command": func() *Command {
+			return NewCommand(nil, 0, CommandRebootEvidence{OutputMatched: true}, CommandAssertions{})
+		},
+		"missing evidence": func() *Command {
+			return NewCommand([]string{"needrestart", "-b"}, 0, CommandRebootEvidence{}, CommandAssertions{})
+		},
+		"exit code evidence without exit codes": func() *Command {
+			return NewCommand([]string{"needs-restarting", "-r"}, 0, CommandRebootEvidence{ExitCodeMatched: true}, CommandAssertions{})
+		},
+		"reboot exit code listed as successful": func() *Command {
+			c := NewCommand([]string{"needs-restarting", "-r"}, 0, CommandRebootEvidence{ExitCodeMatched: true}, CommandAssertions{})
+			c.SetExitCodes([]int{0}, nil)
+			return c
+		},
+		"invalid output pattern": func() *Command {
+			c := NewCommand([]string{"needrestart", "-b"}, 0, CommandRebootEvidence{OutputMatched: true}, CommandAssertions{})
+			c.SetOutputPattern("[unclosed")
+			return c
+		},
+		"batch evidence without key": func() *Command {
+			return NewCommand([]string{"needrestart", "-b"}, 0, CommandRebootEvidence{BatchValueMatched: true}, CommandAssertions{})
+		},
+		"negative timeout": func() *Command {
+			c := NewCommand([]string{"needrestart", "-b"}, -time.Second, CommandRebootEvidence{BatchValueMatched: true}, CommandAssertions{})
+			c.SetBatchMatch("NEEDRESTART-KSTA", nil)
+			return c
+		},
+	}
+
+	for name, newCommand := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := newCommand().Validate(); err == nil {
+				t.Errorf("ERROR: expected validation error")
+			}
+		})
+	}
+}
+
+// TestParseBatchOutput asserts that KEY: value batch output is parsed as
+// expected.
+func TestParseBatchOutput(t *testing.T) {
+	t.Parallel()
+
+	const output = `NEEDRESTART-VER: 3.6
+NEEDRESTART-KCUR: 6.1.0-18-amd64
+NEEDRESTART-KEXP: 6.1.0-21-amd64
+NEEDRESTART-KSTA: 3
+NEEDRESTART-SVC: cron.service
+NEEDRESTART-SVC: ssh.service
+not a batch line
+`
+
+	batch := ParseBatchOutput(strings.NewReader(output))
+
+	if got, want := batch["NEEDRESTART-KSTA"], []string{"3"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("ERROR: got %q for NEEDRESTART-KSTA; want %q", got, want)
+	}
+
+	if got := batch["NEEDRESTART-SVC"]; len(got) != 2 || got[1] != "ssh.service" {
+		t.Errorf("ERROR: got %q for NEEDRESTART-SVC; want both services in order", got)
+	}
+
+	if got := len(batch); got != 5 {
+		t.Errorf("ERROR: got %d keys; want 5", got)
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package command provides functionality used to evaluate the exit code or
+// output of external commands (e.g., needs-restarting -r, needrestart -b)
+// which indicate the need for a system reboot.
+package command
@@ -0,0 +1,40 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package command
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// logger is a package logger that can be enabled from client code to allow
+// logging output from this package when desired/needed for troubleshooting
+// nolint:gochecknoglobals
+var logger *log.Logger
+
+func init() {
+	// Disable logging output by default unless client code explicitly
+	// requests it
+	logger = log.New(os.Stderr, "[command] ", 0)
+	logger.SetOutput(io.Discard)
+}
+
+// EnableLogging enables logging output from this package. Output is muted by
+// default unless explicitly requested (by calling this function).
+func EnableLogging() {
+	logger.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	logger.SetOutput(os.Stderr)
+}
+
+// DisableLogging reapplies default package-level logging settings of muting
+// all logging output.
+func DisableLogging() {
+	logger.SetFlags(0)
+	logger.SetOutput(io.Discard)
+}
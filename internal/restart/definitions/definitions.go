@@ -0,0 +1,780 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package definitions
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/command"
+	"github.com/atc0005/check-restart/internal/restart/files"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+)
+
+// ErrInvalidDefinition indicates that an assertion definition is invalid.
+var ErrInvalidDefinition = errors.New("invalid assertion definition")
+
+// SupportedVersion is the definition file format version supported by this
+// package.
+const SupportedVersion int = 1
+
+// Supported assertion types.
+const (
+	TypeKey         string = "Key"
+	TypeKeyInt      string = "KeyInt"
+	TypeKeyString   string = "KeyString"
+	TypeKeyStrings  string = "KeyStrings"
+	TypeKeyBinary   string = "KeyBinary"
+	TypeKeyPair     string = "KeyPair"
+	TypeFile        string = "File"
+	TypeFileContent string = "FileContent"
+	TypeFileGlob    string = "FileGlob"
+	TypeCommand     string = "Command"
+)
+
+// Evidence and requirement marker names.
+const (
+	markerKeyExists              string = "KeyExists"
+	markerSubKeysExist           string = "SubKeysExist"
+	markerValueExists            string = "ValueExists"
+	markerDataOtherThanX         string = "DataOtherThanX"
+	markerValueFound             string = "ValueFound"
+	markerAllValuesFound         string = "AllValuesFound"
+	markerPairedValuesDoNotMatch string = "PairedValuesDoNotMatch"
+	markerKeyRequired            string = "KeyRequired"
+	markerValueRequired          string = "ValueRequired"
+	markerFileExists             string = "FileExists"
+	markerFileEmpty              string = "FileEmpty"
+	markerFileNotEmpty           string = "FileNotEmpty"
+	markerFileExecutable         string = "FileExecutable"
+	markerFileIsSymlink          string = "FileIsSymlink"
+	markerFileModifiedAfterBoot  string = "FileModifiedAfterBoot"
+	markerFileOlderThan          string = "FileOlderThan"
+	markerFileRequired           string = "FileRequired"
+	markerContentMatched         string = "ContentMatched"
+	markerContentOtherThanX      string = "ContentOtherThanX"
+	markerMatchesFound           string = "MatchesFound"
+	markerExitCodeMatched        string = "ExitCodeMatched"
+	markerOutputMatched          string = "OutputMatched"
+	markerBatchValueMatched      string = "BatchValueMatched"
+	markerCommandRequired        string = "CommandRequired"
+)
+
+// definitionFile is the top-level structure of a definition file.
+type definitionFile struct {
+	Version    int               `json:"version"`
+	Assertions []json.RawMessage `json:"assertions"`
+}
+
+// definition is a single assertion definition. Which fields apply depends
+// on the assertion type.
+type definition struct {
+	// Type is the assertion type (e.g., Key, KeyInt, File). This may be
+	// omitted for the keys of a KeyPair.
+	Type string `json:"type"`
+
+	// Description is an optional note describing the assertion. It is not
+	// used for evaluation.
+	Description string `json:"description"`
+
+	// Root is the registry root key (e.g., HKEY_LOCAL_MACHINE or HKLM).
+	Root string `json:"root"`
+
+	// Path is the registry key path (minus the root key) or file path.
+	Path string `json:"path"`
+
+	// Value is the registry key value name.
+	Value string `json:"value"`
+
+	// EnvPrefix is the name of an environment variable whose value is
+	// prepended to a File path.
+	EnvPrefix string `json:"env_prefix"`
+
+	// ReasonsPath is the path to a file listing the items responsible for
+	// the need to reboot.
+	ReasonsPath string `json:"reasons_path"`
+
+	// Evidence is the list of evidence markers which indicate a reboot is
+	// needed.
+	Evidence []string `json:"evidence"`
+
+	// Requirements is the list of requirement markers.
+	Requirements []string `json:"requirements"`
+
+	// Match is the file content match type (regex, key-value or
+	// json-field) for a FileContent assertion.
+	Match string `json:"match"`
+
+	// Pattern is the regular expression, key or JSON field path for a
+	// FileContent assertion or the regular expression matched against the
+	// output of a Command assertion.
+	Pattern string `json:"pattern"`
+
+	// OlderThanDays is the number of days used by the FileOlderThan
+	// evidence marker.
+	OlderThanDays int `json:"older_than_days"`
+
+	// MinCount is the minimum number of entries matching a FileGlob
+	// assertion required to indicate a reboot is needed.
+	MinCount int `json:"min_count"`
+
+	// MaxAge is the maximum age (e.g., 72h) of entries matching a FileGlob
+	// assertion.
+	MaxAge string `json:"max_age"`
+
+	// Command is the command name (or path) and arguments for a Command
+	// assertion.
+	Command []string `json:"command"`
+
+	// Timeout is the maximum amount of time (e.g., 30s) a Command assertion
+	// is allowed to run.
+	Timeout string `json:"timeout"`
+
+	// ExitCodes is the list of exit codes of a Command assertion which
+	// indicate a reboot is needed.
+	ExitCodes []int `json:"exit_codes"`
+
+	// OKExitCodes is the list of exit codes of a Command assertion which
+	// indicate the command ran successfully.
+	OKExitCodes []int `json:"ok_exit_codes"`
+
+	// BatchKey is the key of the batch (KEY: value) output of a Command
+	// assertion evaluated by the BatchValueMatched evidence marker.
+	BatchKey string `json:"batch_key"`
+
+	// Data is the expected data for KeyInt, KeyString, KeyStrings and
+	// KeyBinary assertions. Data is optional for FileContent assertions
+	// (string) and Command assertions (list of batch values).
+	Data json.RawMessage `json:"data"`
+
+	// Keys is the pair of keys for a KeyPair assertion.
+	Keys []json.RawMessage `json:"keys"`
+}
+
+// LoadFiles loads the assertions from each of the given definition files.
+func LoadFiles(filenames ...string) (restart.RebootRequiredAsserters, error) {
+	var assertions restart.RebootRequiredAsserters
+
+	for _, filename := range filenames {
+		loaded, err := LoadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		assertions = append(assertions, loaded...)
+	}
+
+	return assertions, nil
+}
+
+// LoadFile loads the assertions from the given definition file.
+func LoadFile(filename string) (restart.RebootRequiredAsserters, error) {
+	fh, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open definition file: %w", err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", filename, err)
+		}
+	}()
+
+	return Parse(filename, fh)
+}
+
+// Parse loads the assertions from the content of a definition file. The
+// given name is used to identify the source in error messages.
+func Parse(name string, r io.Reader) (restart.RebootRequiredAsserters, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read definitions: %w", name, err)
+	}
+
+	var df definitionFile
+	if err := decodeStrict(content, &df); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf(
+				"%s: line %d: %v: %w",
+				name,
+				lineNumber(content, syntaxErr.Offset),
+				err,
+				ErrInvalidDefinition,
+			)
+		}
+
+		return nil, fmt.Errorf("%s: %v: %w", name, err, ErrInvalidDefinition)
+	}
+
+	if df.Version != SupportedVersion {
+		return nil, fmt.Errorf(
+			"%s: unsupported version %d; expected %d: %w",
+			name,
+			df.Version,
+			SupportedVersion,
+			ErrInvalidDefinition,
+		)
+	}
+
+	assertions := make(restart.RebootRequiredAsserters, 0, len(df.Assertions))
+	for i, raw := range df.Assertions {
+		location := fmt.Sprintf("assertions[%d]", i)
+
+		assertion, err := buildAssertion(location, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		// Environment variables referenced by an assertion may be resolved
+		// using a lookup function applied after loading (e.g., for an
+		// offline Windows system). Those references are validated along
+		// with all other assertions prior to evaluation.
+		if err := assertion.Validate(); err != nil && !errors.Is(err, restart.ErrUnresolvedVariable) {
+			return nil, fmt.Errorf(
+				"%s: %s: %v: %w",
+				name,
+				location,
+				err,
+				ErrInvalidDefinition,
+			)
+		}
+
+		assertions = append(assertions, assertion)
+	}
+
+	logger.Printf("%d assertions loaded from %s", len(assertions), name)
+
+	return assertions, nil
+}
+
+// decodeStrict decodes JSON content into the given value, rejecting unknown
+// fields.
+func decodeStrict(content []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(v)
+}
+
+// lineNumber returns the line number for the given offset within content.
+func lineNumber(content []byte, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+// invalid returns an error wrapping ErrInvalidDefinition which identifies
+// the offending entry.
+func invalid(location string, format string, args ...interface{}) error {
+	return fmt.Errorf(
+		"%s: %s: %w",
+		location,
+		fmt.Sprintf(format, args...),
+		ErrInvalidDefinition,
+	)
+}
+
+// buildAssertion creates the assertion described by the given definition.
+func buildAssertion(location string, raw json.RawMessage) (restart.RebootRequiredAsserter, error) {
+	var def definition
+	if err := decodeStrict(raw, &def); err != nil {
+		return nil, invalid(location, "%v", err)
+	}
+
+	if err := def.checkFields(location); err != nil {
+		return nil, err
+	}
+
+	switch def.Type {
+	case TypeFile:
+		return def.file(location)
+
+	case TypeFileContent:
+		return def.fileContent(location)
+
+	case TypeFileGlob:
+		return def.fileGlob(location)
+
+	case TypeCommand:
+		return def.command(location)
+
+	case TypeKeyPair:
+		return def.keyPair(location)
+
+	case TypeKey, TypeKeyInt, TypeKeyString, TypeKeyStrings, TypeKeyBinary:
+		key, err := def.key(location)
+		if err != nil {
+			return nil, err
+		}
+
+		return def.keyWithData(location, key)
+
+	case "":
+		return nil, invalid(location, "missing type")
+
+	default:
+		return nil, invalid(
+			location,
+			"unsupported type %q; expected one of %v",
+			def.Type,
+			supportedTypes(),
+		)
+	}
+}
+
+// supportedTypes returns the list of supported assertion types.
+func supportedTypes() []string {
+	return []string{
+		TypeKey,
+		TypeKeyInt,
+		TypeKeyString,
+		TypeKeyStrings,
+		TypeKeyBinary,
+		TypeKeyPair,
+		TypeFile,
+		TypeFileContent,
+		TypeFileGlob,
+		TypeCommand,
+	}
+}
+
+// checkFields asserts that only the fields applicable to the assertion type
+// are specified.
+func (def definition) checkFields(location string) error {
+	isFile := def.Type == TypeFile || def.Type == TypeFileContent || def.Type == TypeFileGlob
+	isContent := def.Type == TypeFileContent
+	isGlob := def.Type == TypeFileGlob
+	isPair := def.Type == TypeKeyPair
+	isCommand := def.Type == TypeCommand
+	hasData := def.Type == TypeKeyInt || def.Type == TypeKeyString ||
+		def.Type == TypeKeyStrings || def.Type == TypeKeyBinary
+
+	unsupported := func(field string) error {
+		return invalid(location, "field %q is not supported for type %s", field, def.Type)
+	}
+
+	switch {
+	case def.Root != "" && (isFile || isPair || isCommand):
+		return unsupported("root")
+	case def.Path != "" && (isPair || isCommand):
+		return unsupported("path")
+	case def.Value != "" && (isFile || isPair || isCommand):
+		return unsupported("value")
+	case def.EnvPrefix != "" && !isFile:
+		return unsupported("env_prefix")
+	case def.ReasonsPath != "" && !isFile:
+		return unsupported("reasons_path")
+	case len(def.Requirements) > 0 && isPair:
+		return unsupported("requirements")
+	case len(def.Keys) > 0 && !isPair:
+		return unsupported("keys")
+	case def.OlderThanDays != 0 && (!isFile || isGlob):
+		return unsupported("older_than_days")
+	case def.MinCount != 0 && !isGlob:
+		return unsupported("min_count")
+	case def.MaxAge != "" && !isGlob:
+		return unsupported("max_age")
+	case def.Match != "" && !isContent:
+		return unsupported("match")
+	case def.Pattern != "" && !isContent && !isCommand:
+		return unsupported("pattern")
+	case len(def.Command) > 0 && !isCommand:
+		return unsupported("command")
+	case def.Timeout != "" && !isCommand:
+		return unsupported("timeout")
+	case len(def.ExitCodes) > 0 && !isCommand:
+		return unsupported("exit_codes")
+	case len(def.OKExitCodes) > 0 && !isCommand:
+		return unsupported("ok_exit_codes")
+	case def.BatchKey != "" && !isCommand:
+		return unsupported("batch_key")
+	case def.Data != nil && !hasData && !isContent && !isCommand:
+		return unsupported("data")
+	case def.Data == nil && hasData:
+		return invalid(location, "missing data for type %s", def.Type)
+	}
+
+	return nil
+}
+
+// key creates the registry Key described by the definition.
+func (def definition) key(location string) (*registry.Key, error) {
+	if def.Root == "" {
+		return nil, invalid(location, "missing root")
+	}
+
+	root, err := registry.ParseRootKey(def.Root)
+	if err != nil {
+		return nil, invalid(location, "%v", err)
+	}
+
+	var (
+		evidence     registry.KeyRebootEvidence
+		requirements registry.KeyAssertions
+	)
+
+	for _, marker := range def.Evidence {
+		switch marker {
+		case markerKeyExists:
+			evidence.KeyExists = true
+		case markerSubKeysExist:
+			evidence.SubKeysExist = true
+		case markerValueExists:
+			evidence.ValueExists = true
+		case markerDataOtherThanX:
+			evidence.DataOtherThanX = true
+		case markerValueFound, markerAllValuesFound:
+			if def.Type != TypeKeyStrings {
+				return nil, invalid(location, "evidence %q is only supported for type %s", marker, TypeKeyStrings)
+			}
+		default:
+			return nil, invalid(location, "unsupported evidence %q for type %s", marker, def.Type)
+		}
+	}
+
+	for _, marker := range def.Requirements {
+		switch marker {
+		case markerKeyRequired:
+			requirements.KeyRequired = true
+		case markerValueRequired:
+			requirements.ValueRequired = true
+		default:
+			return nil, invalid(location, "unsupported requirement %q for type %s", marker, def.Type)
+		}
+	}
+
+	return registry.NewKey(root, def.Path, def.Value, evidence, requirements), nil
+}
+
+// keyWithData wraps the given Key using the assertion type (and expected
+// data) described by the definition.
+func (def definition) keyWithData(location string, key *registry.Key) (restart.RebootRequiredAsserter, error) {
+	switch def.Type {
+	case TypeKeyInt:
+		expected, err := parseIntData(def.Data)
+		if err != nil {
+			return nil, invalid(location, "invalid data: %v", err)
+		}
+
+		return registry.NewKeyInt(key, expected), nil
+
+	case TypeKeyString:
+		var expected string
+		if err := json.Unmarshal(def.Data, &expected); err != nil {
+			return nil, invalid(location, "invalid data; expected string: %v", err)
+		}
+
+		return registry.NewKeyString(key, expected), nil
+
+	case TypeKeyStrings:
+		var expected []string
+		if err := json.Unmarshal(def.Data, &expected); err != nil || len(expected) == 0 {
+			return nil, invalid(location, "invalid data; expected non-empty list of strings")
+		}
+
+		var additionalEvidence registry.KeyStringsRebootEvidence
+		for _, marker := range def.Evidence {
+			switch marker {
+			case markerValueFound:
+				additionalEvidence.ValueFound = true
+			case markerAllValuesFound:
+				additionalEvidence.AllValuesFound = true
+			}
+		}
+
+		return registry.NewKeyStrings(key, expected, additionalEvidence), nil
+
+	case TypeKeyBinary:
+		expected, err := parseBinaryData(def.Data)
+		if err != nil {
+			return nil, invalid(location, "invalid data: %v", err)
+		}
+
+		return registry.NewKeyBinary(key, expected), nil
+
+	default:
+		return key, nil
+	}
+}
+
+// keyPair creates the registry KeyPair described by the definition.
+func (def definition) keyPair(location string) (*registry.KeyPair, error) {
+	if len(def.Keys) != 2 {
+		return nil, invalid(location, "expected 2 keys; got %d", len(def.Keys))
+	}
+
+	var additionalEvidence registry.KeyPairRebootEvidence
+	for _, marker := range def.Evidence {
+		switch marker {
+		case markerPairedValuesDoNotMatch:
+			additionalEvidence.PairedValuesDoNotMatch = true
+		default:
+			return nil, invalid(location, "unsupported evidence %q for type %s", marker, def.Type)
+		}
+	}
+
+	keys := make(registry.Keys, 0, len(def.Keys))
+	for i, raw := range def.Keys {
+		keyLocation := fmt.Sprintf("%s.keys[%d]", location, i)
+
+		var keyDef definition
+		if err := decodeStrict(raw, &keyDef); err != nil {
+			return nil, invalid(keyLocation, "%v", err)
+		}
+
+		switch keyDef.Type {
+		case "", TypeKey:
+			keyDef.Type = TypeKey
+		default:
+			return nil, invalid(keyLocation, "unsupported type %q; expected %s", keyDef.Type, TypeKey)
+		}
+
+		if err := keyDef.checkFields(keyLocation); err != nil {
+			return nil, err
+		}
+
+		// Reboot evidence is determined by comparing the paired values, so
+		// (as with the default assertions) each key and value is required.
+		if len(keyDef.Evidence) > 0 {
+			return nil, invalid(keyLocation, "field %q is not supported for keys of type %s", "evidence", TypeKeyPair)
+		}
+
+		if len(keyDef.Requirements) == 0 {
+			keyDef.Requirements = []string{markerKeyRequired, markerValueRequired}
+		}
+
+		key, err := keyDef.key(keyLocation)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return registry.NewKeyPair(keys, additionalEvidence), nil
+}
+
+// file creates the File described by the definition.
+func (def definition) file(location string) (*files.File, error) {
+	var (
+		evidence     files.FileRebootEvidence
+		requirements files.FileAssertions
+	)
+
+	for _, marker := range def.Evidence {
+		switch marker {
+		case markerContentMatched, markerContentOtherThanX:
+			if def.Type != TypeFileContent {
+				return nil, invalid(location, "evidence %q is only supported for type %s", marker, TypeFileContent)
+			}
+		case markerMatchesFound:
+			if def.Type != TypeFileGlob {
+				return nil, invalid(location, "evidence %q is only supported for type %s", marker, TypeFileGlob)
+			}
+		case markerFileExists:
+			evidence.FileExists = true
+		case markerFileEmpty:
+			evidence.FileEmpty = true
+		case markerFileNotEmpty:
+			evidence.FileNotEmpty = true
+		case markerFileExecutable:
+			evidence.FileExecutable = true
+		case markerFileIsSymlink:
+			evidence.FileIsSymlink = true
+		case markerFileModifiedAfterBoot:
+			evidence.FileModifiedAfterBoot = true
+		case markerFileOlderThan:
+			evidence.FileOlderThan = true
+		default:
+			return nil, invalid(location, "unsupported evidence %q for type %s", marker, def.Type)
+		}
+	}
+
+	for _, marker := range def.Requirements {
+		switch marker {
+		case markerFileRequired:
+			requirements.FileRequired = true
+		default:
+			return nil, invalid(location, "unsupported requirement %q for type %s", marker, def.Type)
+		}
+	}
+
+	file := files.NewFile(def.Path, def.EnvPrefix, def.ReasonsPath, evidence, requirements)
+	file.SetOlderThanDays(def.OlderThanDays)
+
+	return file, nil
+}
+
+// fileContent creates the FileContent described by the definition.
+func (def definition) fileContent(location string) (*files.FileContent, error) {
+	file, err := def.file(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.Match == "" {
+		return nil, invalid(location, "missing match")
+	}
+
+	if def.Pattern == "" {
+		return nil, invalid(location, "missing pattern")
+	}
+
+	var expected string
+	if def.Data != nil {
+		if err := json.Unmarshal(def.Data, &expected); err != nil {
+			return nil, invalid(location, "invalid data; expected string: %v", err)
+		}
+	}
+
+	var additionalEvidence files.FileContentRebootEvidence
+	for _, marker := range def.Evidence {
+		switch marker {
+		case markerContentMatched:
+			additionalEvidence.ContentMatched = true
+		case markerContentOtherThanX:
+			additionalEvidence.ContentOtherThanX = true
+		}
+	}
+
+	return files.NewFileContent(
+		file,
+		files.FileContentMatchType(def.Match),
+		def.Pattern,
+		expected,
+		additionalEvidence,
+	), nil
+}
+
+// fileGlob creates the FileGlob described by the definition.
+func (def definition) fileGlob(location string) (*files.FileGlob, error) {
+	for _, marker := range def.Evidence {
+		if marker != markerMatchesFound {
+			return nil, invalid(location, "unsupported evidence %q for type %s", marker, def.Type)
+		}
+	}
+
+	file, err := def.file(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxAge time.Duration
+	if def.MaxAge != "" {
+		maxAge, err = time.ParseDuration(def.MaxAge)
+		if err != nil {
+			return nil, invalid(location, "invalid max_age: %v", err)
+		}
+	}
+
+	return files.NewFileGlob(
+		file,
+		def.MinCount,
+		maxAge,
+		files.FileGlobRebootEvidence{MatchesFound: len(def.Evidence) > 0},
+	), nil
+}
+
+// command creates the Command described by the definition.
+func (def definition) command(location string) (*command.Command, error) {
+	if len(def.Command) == 0 {
+		return nil, invalid(location, "missing command")
+	}
+
+	var (
+		evidence     command.CommandRebootEvidence
+		requirements command.CommandAssertions
+	)
+
+	for _, marker := range def.Evidence {
+		switch marker {
+		case markerExitCodeMatched:
+			evidence.ExitCodeMatched = true
+		case markerOutputMatched:
+			evidence.OutputMatched = true
+		case markerBatchValueMatched:
+			evidence.BatchValueMatched = true
+		default:
+			return nil, invalid(location, "unsupported evidence %q for type %s", marker, def.Type)
+		}
+	}
+
+	for _, marker := range def.Requirements {
+		switch marker {
+		case markerCommandRequired:
+			requirements.CommandRequired = true
+		default:
+			return nil, invalid(location, "unsupported requirement %q for type %s", marker, def.Type)
+		}
+	}
+
+	var timeout time.Duration
+	if def.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(def.Timeout)
+		if err != nil {
+			return nil, invalid(location, "invalid timeout: %v", err)
+		}
+	}
+
+	var batchValues []string
+	if def.Data != nil {
+		if err := json.Unmarshal(def.Data, &batchValues); err != nil {
+			return nil, invalid(location, "invalid data; expected list of strings: %v", err)
+		}
+	}
+
+	c := command.NewCommand(def.Command, timeout, evidence, requirements)
+	c.SetExitCodes(def.ExitCodes, def.OKExitCodes)
+	c.SetOutputPattern(def.Pattern)
+	c.SetBatchMatch(def.BatchKey, batchValues)
+
+	return c, nil
+}
+
+// parseIntData parses integer data specified either as a JSON number or as
+// a string (allowing hex values such as "0x1").
+func parseIntData(data json.RawMessage) (uint64, error) {
+	var number uint64
+	if err := json.Unmarshal(data, &number); err == nil {
+		return number, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return 0, errors.New("expected non-negative integer")
+	}
+
+	return strconv.ParseUint(text, 0, 64)
+}
+
+// parseBinaryData parses binary data specified as a string of hex encoded
+// bytes. Bytes may optionally be separated by commas, spaces or colons.
+func parseBinaryData(data json.RawMessage) ([]byte, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return nil, errors.New("expected string of hex encoded bytes")
+	}
+
+	text = strings.NewReplacer(",", "", " ", "", ":", "").Replace(text)
+
+	return hex.DecodeString(text)
+}
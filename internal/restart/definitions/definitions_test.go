@@ -0,0 +1,233 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package definitions
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/check-restart/internal/restart/files"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+)
+
+// TestParse asserts that each supported assertion type is loaded from a
+// definition file and evaluated as expected.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	flagFile := filepath.Join(dir, "reboot-required")
+	if err := os.WriteFile(flagFile, []byte("pending\n"), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create flag file: %v", err)
+	}
+
+	content := `{
+  "version": 1,
+  "assertions": [
+    {
+      "type": "Key",
+      "description": "Vendor agent reboot flag",
+      "root": "HKLM",
+      "path": "SOFTWARE\\Vendor\\RebootPending",
+      "evidence": ["KeyExists"]
+    },
+    {
+      "type": "KeyInt",
+      "root": "HKEY_LOCAL_MACHINE",
+      "path": "SOFTWARE\\Vendor",
+      "value": "Status",
+      "data": "0x0",
+      "evidence": ["DataOtherThanX"],
+      "requirements": ["KeyRequired", "ValueRequired"]
+    },
+    {
+      "type": "KeyString",
+      "root": "HKLM",
+      "path": "SOFTWARE\\Vendor",
+      "value": "State",
+      "data": "Idle",
+      "evidence": ["DataOtherThanX"]
+    },
+    {
+      "type": "KeyStrings",
+      "root": "HKLM",
+      "path": "SOFTWARE\\Vendor",
+      "value": "Queue",
+      "data": ["restart"],
+      "evidence": ["ValueExists", "ValueFound"]
+    },
+    {
+      "type": "KeyBinary",
+      "root": "HKLM",
+      "path": "SOFTWARE\\Vendor",
+      "value": "Blob",
+      "data": "de,ad,be,ef",
+      "evidence": ["DataOtherThanX"]
+    },
+    {
+      "type": "KeyPair",
+      "evidence": ["PairedValuesDoNotMatch"],
+      "keys": [
+        {"root": "HKLM", "path": "SOFTWARE\\Vendor\\Active", "value": "Name"},
+        {"type": "Key", "root": "HKLM", "path": "SOFTWARE\\Vendor\\Pending", "value": "Name"}
+      ]
+    },
+    {
+      "type": "File",
+      "path": "` + filepath.ToSlash(flagFile) + `",
+      "evidence": ["FileExists"]
+    }
+  ]
+}`
+
+	assertions, err := Parse("test.json", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse definitions: %v", err)
+	}
+
+	if got, want := len(assertions), 7; got != want {
+		t.Fatalf("ERROR: got %d assertions; want %d", got, want)
+	}
+
+	if _, ok := assertions[6].(*files.File); !ok {
+		t.Errorf("ERROR: got %T for File definition; want *files.File", assertions[6])
+	}
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, `SOFTWARE\Vendor\RebootPending`)
+	mb.SetDWordValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor`, "Status", 1)
+	mb.SetStringValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor`, "State", "Idle")
+	mb.SetStringsValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor`, "Queue", []string{"restart", "other"})
+	mb.SetBinaryValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor`, "Blob", []byte{0xde, 0xad, 0xbe, 0xef})
+	mb.SetStringValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor\Active`, "Name", "WEB01")
+	mb.SetStringValue(registry.RootKeyLocalMachine, `SOFTWARE\Vendor\Pending`, "Name", "WEB02")
+
+	registry.UseBackend(assertions, mb)
+
+	assertions.Evaluate(context.Background())
+
+	if assertions.HasErrors(false) {
+		t.Fatalf("ERROR: unexpected evaluation errors: %v", assertions.Errs(false))
+	}
+
+	// Everything except the KeyString and KeyBinary assertions (whose data
+	// matches the expected data) is expected to match.
+	wantMatched := []bool{true, true, false, true, false, true, true}
+	for i, assertion := range assertions {
+		if got := assertion.RebootRequired(); got != wantMatched[i] {
+			t.Errorf("ERROR: assertions[%d] (%s): reboot required = %t; want %t", i, assertion, got, wantMatched[i])
+		}
+	}
+}
+
+// TestParseInvalid asserts that invalid definitions are rejected with the
+// offending entry identified.
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	const validKey = `{"type": "Key", "root": "HKLM", "path": "SOFTWARE", "evidence": ["KeyExists"]}`
+
+	tests := map[string]struct {
+		content      string
+		wantLocation string
+	}{
+		"syntax error": {
+			content:      "{\n  \"version\": 1,\n  \"assertions\": [\n    {,}\n  ]\n}",
+			wantLocation: "line 4",
+		},
+		"unsupported version": {
+			content:      `{"version": 2, "assertions": []}`,
+			wantLocation: "unsupported version 2",
+		},
+		"unknown top-level field": {
+			content:      `{"version": 1, "assertion": []}`,
+			wantLocation: `unknown field "assertion"`,
+		},
+		"unknown type": {
+			content:      `{"version": 1, "assertions": [` + validKey + `, {"type": "Folder", "path": "/tmp"}]}`,
+			wantLocation: "assertions[1]",
+		},
+		"unknown evidence": {
+			content:      `{"version": 1, "assertions": [{"type": "File", "path": "/tmp/x", "evidence": ["KeyExists"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"unknown field": {
+			content:      `{"version": 1, "assertions": [` + validKey + `, ` + validKey + `, {"type": "Key", "root": "HKLM", "path": "SOFTWARE", "evidance": ["KeyExists"]}]}`,
+			wantLocation: "assertions[2]",
+		},
+		"missing data": {
+			content:      `{"version": 1, "assertions": [{"type": "KeyInt", "root": "HKLM", "path": "SOFTWARE", "value": "x", "evidence": ["DataOtherThanX"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"invalid binary data": {
+			content:      `{"version": 1, "assertions": [{"type": "KeyBinary", "root": "HKLM", "path": "SOFTWARE", "value": "x", "data": "zz", "evidence": ["DataOtherThanX"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"unknown root key": {
+			content:      `{"version": 1, "assertions": [{"type": "KeyPair", "keys": [{"root": "HKLM", "path": "A", "value": "x"}, {"root": "HKXX", "path": "B", "value": "x"}]}]}`,
+			wantLocation: "assertions[0].keys[1]",
+		},
+		"single key pair": {
+			content:      `{"version": 1, "assertions": [{"type": "KeyPair", "keys": [{"root": "HKLM", "path": "A", "value": "x"}]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"content evidence for file": {
+			content:      `{"version": 1, "assertions": [{"type": "File", "path": "/tmp/x", "evidence": ["ContentMatched"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"invalid content pattern": {
+			content:      `{"version": 1, "assertions": [{"type": "FileContent", "path": "/tmp/x", "match": "regex", "pattern": "[", "evidence": ["ContentMatched"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"invalid max age": {
+			content:      `{"version": 1, "assertions": [{"type": "FileGlob", "path": "/tmp/*.pending", "max_age": "3 days", "evidence": ["MatchesFound"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"min count for file": {
+			content:      `{"version": 1, "assertions": [{"type": "File", "path": "/tmp/x", "min_count": 2, "evidence": ["FileExists"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"invalid command timeout": {
+			content:      `{"version": 1, "assertions": [{"type": "Command", "command": ["needs-restarting", "-r"], "timeout": "soon", "exit_codes": [1], "evidence": ["ExitCodeMatched"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"command exit code evidence without exit codes": {
+			content:      `{"version": 1, "assertions": [{"type": "Command", "command": ["needs-restarting", "-r"], "evidence": ["ExitCodeMatched"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"command for file": {
+			content:      `{"version": 1, "assertions": [{"type": "File", "path": "/tmp/x", "command": ["true"], "evidence": ["FileExists"]}]}`,
+			wantLocation: "assertions[0]",
+		},
+		"failed validation": {
+			content:      `{"version": 1, "assertions": [` + validKey + `, {"type": "File", "evidence": ["FileExists"]}]}`,
+			wantLocation: "assertions[1]",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse("test.json", strings.NewReader(tt.content))
+
+			switch {
+			case !errors.Is(err, ErrInvalidDefinition):
+				t.Errorf("ERROR: expected ErrInvalidDefinition; got %v", err)
+			case !strings.Contains(err.Error(), tt.wantLocation):
+				t.Errorf("ERROR: error %q does not reference %q", err, tt.wantLocation)
+			case !strings.HasPrefix(err.Error(), "test.json: "):
+				t.Errorf("ERROR: error %q does not reference the definition file", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package definitions loads reboot required assertions from definition files
+// in order to extend (or replace) the default assertions without rebuilding
+// the application.
+//
+// A definition file is a JSON document listing assertions:
+//
+//	{
+//	  "version": 1,
+//	  "assertions": [
+//	    {
+//	      "type": "Key",
+//	      "root": "HKLM",
+//	      "path": "SOFTWARE\\Example\\RebootPending",
+//	      "evidence": ["KeyExists"]
+//	    },
+//	    {
+//	      "type": "KeyInt",
+//	      "root": "HKEY_LOCAL_MACHINE",
+//	      "path": "SOFTWARE\\Example",
+//	      "value": "Status",
+//	      "data": 0,
+//	      "evidence": ["DataOtherThanX"],
+//	      "requirements": ["KeyRequired"]
+//	    },
+//	    {
+//	      "type": "File",
+//	      "path": "/var/run/example-reboot-required",
+//	      "evidence": ["FileExists"]
+//	    }
+//	  ]
+//	}
+//
+// Supported assertion types are Key, KeyInt, KeyString, KeyStrings,
+// KeyBinary, KeyPair, File, FileContent, FileGlob and Command. Evidence and
+// requirement markers use the names of the corresponding fields of the
+// registry.KeyRebootEvidence, registry.KeyStringsRebootEvidence,
+// registry.KeyPairRebootEvidence, registry.KeyAssertions,
+// files.FileRebootEvidence, files.FileContentRebootEvidence,
+// files.FileGlobRebootEvidence, files.FileAssertions,
+// command.CommandRebootEvidence and command.CommandAssertions types.
+//
+// A FileContent assertion specifies the match type (regex, key-value or
+// json-field) in a "match" field, the regular expression, key or field path
+// in a "pattern" field and optionally the expected value in a "data" field.
+//
+// A FileGlob assertion specifies a directory or glob pattern as the path and
+// optionally the minimum number of matching entries in a "min_count" field
+// and the maximum age (e.g., 72h) of matching entries in a "max_age" field.
+//
+// A Command assertion lists the command name (or path) and arguments in a
+// "command" field and optionally the maximum run time (e.g., 30s) in a
+// "timeout" field. The exit codes indicating a reboot is needed are listed
+// in an "exit_codes" field (and those indicating success in an
+// "ok_exit_codes" field), the regular expression matched against the output
+// in a "pattern" field and the batch output key in a "batch_key" field with
+// the values indicating a reboot is needed listed in a "data" field.
+//
+// A KeyPair assertion lists its two registry keys in a "keys" field. Each key
+// and value of the pair is required unless requirement markers are given for
+// the key.
+//
+// Definitions are validated when loaded; errors identify the offending entry
+// by position (e.g., assertions[2].keys[1]).
+package definitions
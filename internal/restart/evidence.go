@@ -0,0 +1,63 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"reflect"
+)
+
+// EvidenceMarkers returns the names of the evidence markers (e.g.,
+// KeyExists) set in the given evidence values. Each evidence value is
+// expected to be a struct of boolean evidence markers (e.g.,
+// registry.KeyRebootEvidence); other fields and values are skipped. Names
+// are returned in field order.
+func EvidenceMarkers(evidence ...any) []string {
+	markers := make([]string, 0)
+
+	for _, e := range evidence {
+		v := reflect.ValueOf(e)
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Type.Kind() != reflect.Bool {
+				continue
+			}
+
+			if v.Field(i).Bool() {
+				markers = append(markers, field.Name)
+			}
+		}
+	}
+
+	return markers
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate that the given assertion requires a reboot. Nil is
+// returned if the assertion does not report evidence markers.
+func ExpectedEvidenceMarkers(rra RebootRequiredAsserter) []string {
+	if v, ok := rra.(EvidenceReporter); ok {
+		return v.ExpectedEvidenceMarkers()
+	}
+
+	return nil
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation of the given assertion. Nil is returned if
+// the assertion does not report evidence markers.
+func DiscoveredEvidenceMarkers(rra RebootRequiredAsserter) []string {
+	if v, ok := rra.(EvidenceReporter); ok {
+		return v.DiscoveredEvidenceMarkers()
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnresolvedVariable indicates that an environment variable referenced
+// by a value could not be resolved.
+var ErrUnresolvedVariable = errors.New("unresolved environment variable")
+
+// EnvLookupSetter represents an item (e.g., file, reg key) which expands
+// environment variables using a configurable lookup function.
+type EnvLookupSetter interface {
+	// SetEnvLookup sets the function used to resolve environment variables
+	// instead of the environment of the current process.
+	SetEnvLookup(lookupEnv func(key string) (string, bool))
+}
+
+// UseEnvLookup sets the function used to resolve environment variables for
+// each assertion in the given collection which supports it. Assertions of
+// other types are skipped.
+func UseEnvLookup(assertions RebootRequiredAsserters, lookupEnv func(key string) (string, bool)) {
+	for _, assertion := range assertions {
+		if v, ok := assertion.(EnvLookupSetter); ok {
+			v.SetEnvLookup(lookupEnv)
+		}
+	}
+}
+
+// ExpandPath expands %VAR%, $VAR and ${VAR} environment variable references
+// within the given value using lookupEnv (or the environment of the current
+// process if not specified). Use %% or $$ for a literal % or $ character. A
+// % character without a closing % is left as-is.
+//
+// Only %VAR% references are expanded within Windows paths as $ is commonly
+// used in Windows file names (e.g., C:\$WINDOWS.~BT, C:\$Recycle.Bin).
+//
+// An error wrapping ErrUnresolvedVariable is returned for any variable that
+// is not set.
+func ExpandPath(value string, lookupEnv func(key string) (string, bool)) (string, error) {
+	return expandVariables(value, lookupEnv, !isWindowsPath(value))
+}
+
+// isWindowsPath indicates whether the given path uses Windows conventions:
+// it begins with a drive letter or %VAR% reference or contains a \ path
+// separator.
+func isWindowsPath(path string) bool {
+	switch {
+	case strings.Contains(path, `\`):
+		return true
+	case strings.HasPrefix(path, "%"):
+		return true
+	case len(path) >= 2 && path[1] == ':' &&
+		((path[0] >= 'A' && path[0] <= 'Z') || (path[0] >= 'a' && path[0] <= 'z')):
+		return true
+	default:
+		return false
+	}
+}
+
+// ExpandWindowsVariables expands %VAR% environment variable references
+// within the given value (e.g., a path relative to a Windows environment
+// variable) using lookupEnv
+// (or the environment of the current process if not specified). Use %% for a
+// literal % character.
+//
+// An error wrapping ErrUnresolvedVariable is returned for any variable that
+// is not set.
+func ExpandWindowsVariables(value string, lookupEnv func(key string) (string, bool)) (string, error) {
+	return expandVariables(value, lookupEnv, false)
+}
+
+// ExpandEnvironmentStrings expands %VAR% environment variable references
+// within the given value (e.g., REG_EXPAND_SZ registry data) in the same way
+// as the ExpandEnvironmentStrings Windows API using lookupEnv (or the
+// environment of the current process if not specified). References which
+// cannot be resolved are left as-is and %% is not treated as an escape.
+func ExpandEnvironmentStrings(value string, lookupEnv func(key string) (string, bool)) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	var expanded strings.Builder
+
+	for {
+		start := strings.IndexByte(value, '%')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(value[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		if name := value[start+1 : end]; name != "" {
+			if resolved, ok := lookupEnv(name); ok {
+				expanded.WriteString(value[:start])
+				expanded.WriteString(resolved)
+				value = value[end+1:]
+
+				continue
+			}
+		}
+
+		// The closing % of an unresolved reference may begin another.
+		expanded.WriteString(value[:end])
+		value = value[end:]
+	}
+
+	expanded.WriteString(value)
+
+	return expanded.String()
+}
+
+// expandVariables expands %VAR% (and optionally $VAR and ${VAR})
+// environment variable references within the given value.
+func expandVariables(value string, lookupEnv func(key string) (string, bool), expandDollar bool) (string, error) {
+	if !strings.ContainsAny(value, "%$") {
+		return value, nil
+	}
+
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	var (
+		expanded   strings.Builder
+		unresolved []string
+	)
+
+	resolve := func(name string) {
+		resolved, ok := lookupEnv(name)
+		if !ok {
+			unresolved = append(unresolved, name)
+		}
+		expanded.WriteString(resolved)
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '%':
+			end := strings.IndexByte(value[i+1:], '%')
+			switch {
+			case end < 0:
+				expanded.WriteByte(c)
+			case end == 0:
+				expanded.WriteByte('%')
+				i++
+			default:
+				resolve(value[i+1 : i+1+end])
+				i += end + 1
+			}
+
+		case c == '$' && expandDollar:
+			rest := value[i+1:]
+			switch {
+			case strings.HasPrefix(rest, "$"):
+				expanded.WriteByte('$')
+				i++
+
+			case strings.HasPrefix(rest, "{"):
+				end := strings.IndexByte(rest, '}')
+				if end < 2 {
+					return "", fmt.Errorf("invalid variable reference in %q: %w", value, ErrUnresolvedVariable)
+				}
+				resolve(rest[1:end])
+				i += end + 1
+
+			default:
+				n := variableNameLength(rest)
+				if n == 0 {
+					expanded.WriteByte(c)
+					continue
+				}
+				resolve(rest[:n])
+				i += n
+			}
+
+		default:
+			expanded.WriteByte(c)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf(
+			"%s in %q: %w",
+			strings.Join(unresolved, ", "),
+			value,
+			ErrUnresolvedVariable,
+		)
+	}
+
+	return expanded.String(), nil
+}
+
+// variableNameLength returns the length of the variable name (letters,
+// digits and underscores, not starting with a digit) at the start of s.
+func variableNameLength(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return i
+		}
+	}
+
+	return len(s)
+}
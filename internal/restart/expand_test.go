@@ -0,0 +1,110 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestExpandPath asserts that environment variable references are expanded
+// and that unresolvable references are reported.
+func TestExpandPath(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"SystemRoot": `C:\Windows`,
+		"STATE_DIR":  "/var/lib/vendor",
+		"EMPTY":      "",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	tests := map[string]struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		"no references":       {input: "/var/run/reboot-required", want: "/var/run/reboot-required"},
+		"percent":             {input: `%SystemRoot%\WinSxS\pending.xml`, want: `C:\Windows\WinSxS\pending.xml`},
+		"dollar":              {input: "$STATE_DIR/reboot", want: "/var/lib/vendor/reboot"},
+		"braces":              {input: "${STATE_DIR}_old/reboot", want: "/var/lib/vendor_old/reboot"},
+		"empty value":         {input: "/tmp/${EMPTY}x", want: "/tmp/x"},
+		"escaped percent":     {input: "/tmp/100%%", want: "/tmp/100%"},
+		"escaped dollar":      {input: "/tmp/$$x", want: "/tmp/$x"},
+		"windows dollar":      {input: `C:\$WINDOWS.~BT\Sources`, want: `C:\$WINDOWS.~BT\Sources`},
+		"windows variable":    {input: `%SystemRoot%\..\$Recycle.Bin`, want: `C:\Windows\..\$Recycle.Bin`},
+		"drive letter dollar": {input: "C:/$Recycle.Bin", want: "C:/$Recycle.Bin"},
+		"unclosed percent":    {input: "/tmp/50%", want: "/tmp/50%"},
+		"trailing dollar":     {input: "/tmp/x$", want: "/tmp/x$"},
+		"unresolved percent":  {input: `%ProgramData%\Vendor`, wantErr: true},
+		"unresolved dollar":   {input: "$HOME/.reboot", wantErr: true},
+		"unclosed braces":     {input: "${STATE_DIR/reboot", wantErr: true},
+		"empty braces":        {input: "${}/reboot", wantErr: true},
+		"case sensitive name": {input: "$state_dir/reboot", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ExpandPath(tt.input, lookupEnv)
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrUnresolvedVariable):
+				t.Errorf("ERROR: expected ErrUnresolvedVariable for %q; got %q, %v", tt.input, got, err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("ERROR: failed to expand %q: %v", tt.input, err)
+			case !tt.wantErr && got != tt.want:
+				t.Errorf("ERROR: got %q for %q; want %q", got, tt.input, tt.want)
+			}
+		})
+	}
+
+	if got, err := ExpandWindowsVariables("$STATE_DIR;%SystemRoot%", lookupEnv); err != nil || got != `$STATE_DIR;C:\Windows` {
+		t.Errorf("ERROR: got %q, %v; want only %%VAR%% references expanded", got, err)
+	}
+}
+
+// TestExpandEnvironmentStrings asserts that %VAR% references are expanded
+// where possible and that unresolved references and %% are left as-is.
+func TestExpandEnvironmentStrings(t *testing.T) {
+	t.Parallel()
+
+	lookupEnv := func(key string) (string, bool) {
+		if key == "SystemRoot" {
+			return `C:\Windows`, true
+		}
+		return "", false
+	}
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"no references":       {input: `C:\Vendor`, want: `C:\Vendor`},
+		"resolved":            {input: `%SystemRoot%\Vendor`, want: `C:\Windows\Vendor`},
+		"unresolved":          {input: `%ProgramData%\Vendor`, want: `%ProgramData%\Vendor`},
+		"mixed":               {input: `%ProgramData%\%SystemRoot%\%Missing%`, want: `%ProgramData%\C:\Windows\%Missing%`},
+		"unresolved adjoins":  {input: `%Missing%SystemRoot%`, want: `%MissingC:\Windows`},
+		"percent not escape":  {input: `100%%\%SystemRoot%`, want: `100%%\C:\Windows`},
+		"unclosed percent":    {input: `%SystemRoot%\50%`, want: `C:\Windows\50%`},
+		"dollar not expanded": {input: `$SystemRoot\%SystemRoot%`, want: `$SystemRoot\C:\Windows`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExpandEnvironmentStrings(tt.input, lookupEnv); got != tt.want {
+				t.Errorf("ERROR: got %q for %q; want %q", got, tt.input, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// DefaultOfflineWindowsRebootRequiredAssertions provides the default
+// collection of file related reboot required assertions for Windows systems.
+// Environment variables used by the assertions (e.g., SystemRoot) are
+// resolved using the given function instead of the environment of the
+// current process. This allows evaluating the assertions against an offline
+// Windows system (e.g., a mounted disk image).
+func DefaultOfflineWindowsRebootRequiredAssertions(lookupEnv func(key string) (string, bool)) restart.RebootRequiredAsserters {
+	return windowsRebootRequiredAssertions(lookupEnv)
+}
+
+// windowsRebootRequiredAssertions provides the default collection of file
+// related reboot required assertions for Windows systems. If specified, the
+// given function is used to resolve environment variables.
+func windowsRebootRequiredAssertions(lookupEnv func(key string) (string, bool)) restart.RebootRequiredAsserters {
+
+	var assertions = restart.RebootRequiredAsserters{
+
+		// Test entry.
+		// &File{
+		// 	// path: `C:\Windows\notepad.exe`,
+		// 	envVarPathPrefix: "SystemRoot",
+		// 	path:             `notepad.exe`,
+		// },
+
+		// Found on Windows desktop and server variants after applying Windows
+		// Updates.
+		&File{
+			// path: `C:\Windows\WinSxS\pending.xml`,
+			envVarPathPrefix: "SystemRoot",
+			path:             `WinSxS\pending.xml`,
+			lookupEnv:        lookupEnv,
+			evidenceExpected: FileRebootEvidence{
+				FileExists: true,
+			},
+		},
+	}
+
+	return assertions
+
+}
@@ -28,7 +28,40 @@ func DefaultRebootRequiredIgnoredPaths() []string {
 // related reboot required assertions.
 func DefaultRebootRequiredAssertions() restart.RebootRequiredAsserters {
 
-	// TODO: Look for paths specific to non-Windows, UNIX-like systems that
-	// indicate a need for a system reboot.
-	return restart.RebootRequiredAsserters{}
+	var assertions = restart.RebootRequiredAsserters{
+
+		// Created by Debian and Ubuntu package maintainer scripts (via the
+		// notify-reboot-required helper) when an installed package update
+		// requires a reboot. Packages requesting the reboot are listed in a
+		// companion file.
+		&File{
+			path:        `/var/run/reboot-required`,
+			reasonsPath: `/var/run/reboot-required.pkgs`,
+			evidenceExpected: FileRebootEvidence{
+				FileExists: true,
+			},
+		},
+
+		// Created by zypper on SUSE/openSUSE systems after installing
+		// packages (or patches) flagged as needing a reboot.
+		&File{
+			path: `/run/reboot-needed`,
+			evidenceExpected: FileRebootEvidence{
+				FileExists: true,
+			},
+		},
+
+		// Sentinel file path used by kured (Kubernetes Reboot Daemon)
+		// deployments where the host's /var/run directory is mounted within
+		// the container at /sentinel. Sysadmins or tooling may also create
+		// this file directly to request a reboot.
+		&File{
+			path: `/sentinel/reboot-required`,
+			evidenceExpected: FileRebootEvidence{
+				FileExists: true,
+			},
+		},
+	}
+
+	return assertions
 }
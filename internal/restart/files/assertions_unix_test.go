@@ -0,0 +1,34 @@
+//go:build !windows
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import "testing"
+
+// TestDefaultRebootRequiredAssertionsReasonsFile asserts that the packages
+// requesting a reboot are read from the companion file of the Debian/Ubuntu
+// sentinel file.
+func TestDefaultRebootRequiredAssertionsReasonsFile(t *testing.T) {
+	t.Parallel()
+
+	for _, assertion := range DefaultRebootRequiredAssertions() {
+		f, ok := assertion.(*File)
+		if !ok || f.path != "/var/run/reboot-required" {
+			continue
+		}
+
+		if got, want := f.ReasonsPath(), "/var/run/reboot-required.pkgs"; got != want {
+			t.Errorf("ERROR: got reasons path %q; want %q", got, want)
+		}
+
+		return
+	}
+
+	t.Error("ERROR: default assertion for /var/run/reboot-required not found")
+}
@@ -27,25 +27,5 @@ func DefaultRebootRequiredIgnoredPaths() []string {
 // DefaultRebootRequiredAssertions provides the default collection of file
 // related reboot required assertions.
 func DefaultRebootRequiredAssertions() restart.RebootRequiredAsserters {
-
-	var assertions = restart.RebootRequiredAsserters{
-
-		// Test entry.
-		// &File{
-		// 	// path: `C:\Windows\notepad.exe`,
-		// 	envVarPathPrefix: "SystemRoot",
-		// 	path:             `notepad.exe`,
-		// },
-
-		// Found on Windows desktop and server variants after applying Windows
-		// Updates.
-		&File{
-			// path: `C:\Windows\WinSxS\pending.xml`,
-			envVarPathPrefix: "SystemRoot",
-			path:             `WinSxS\pending.xml`,
-		},
-	}
-
-	return assertions
-
+	return windowsRebootRequiredAssertions(nil)
 }
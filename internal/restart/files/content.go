@@ -0,0 +1,750 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithDataDisplay implementation isn't
+// correct.
+var _ restart.RebootRequiredAsserterWithDataDisplay = (*FileContent)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.FileRebootRequired implementation isn't correct.
+var _ FileRebootRequired = (*FileContent)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*FileContent)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*FileContent)(nil)
+
+// FileContentMatchType indicates how the content of a file is evaluated.
+type FileContentMatchType string
+
+// Supported file content match types.
+const (
+	// FileContentMatchRegex evaluates each line of a file against a regular
+	// expression.
+	FileContentMatchRegex FileContentMatchType = "regex"
+
+	// FileContentMatchKeyValue evaluates the value for a key specified as
+	// KEY=value on a line of a file. Blank lines and lines beginning with #
+	// are skipped. If a key is listed more than once the last entry is used.
+	FileContentMatchKeyValue FileContentMatchType = "key-value"
+
+	// FileContentMatchJSONField evaluates the value of a field within a JSON
+	// document. Fields are specified using a JSONPath-like syntax (e.g.,
+	// $.status.state or updates[0].state).
+	FileContentMatchJSONField FileContentMatchType = "json-field"
+)
+
+// ErrInvalidJSONFieldPath indicates that an invalid JSON field path was
+// specified.
+var ErrInvalidJSONFieldPath = errors.New("invalid JSON field path")
+
+// FileContentRebootEvidence applies additional evidence "markers" for the
+// FileContent type. If the reboot evidence markers for the File type are not
+// matched, these (also optional) set of evidence markers are then checked to
+// determine if a reboot is required.
+type FileContentRebootEvidence struct {
+
+	// ContentMatched is an evidence "marker" that if satisfied indicates the
+	// need for a reboot. This marker is satisfied if a line matches the
+	// regular expression or if the value for a key or field matches the
+	// expected data. If expected data is not specified for a key or field
+	// the marker is satisfied if the key or field is present.
+	ContentMatched bool
+
+	// ContentOtherThanX is an evidence "marker" that if satisfied indicates
+	// the need for a reboot. This marker is satisfied if a key or field is
+	// present and its value does not match the expected data.
+	ContentOtherThanX bool
+}
+
+// FileContentRuntime is a collection of values that are set during
+// evaluation. Unlike static values that are known ahead of time, these
+// values are not known until execution or runtime.
+type FileContentRuntime struct {
+	// matchedLine is the line (or for a JSON field, a representation of the
+	// field and value) responsible for the match.
+	matchedLine string
+
+	// lineNumber is the line number of matchedLine. This is not set for
+	// JSON fields.
+	lineNumber int
+
+	// data is the value retrieved for a key or field.
+	data string
+
+	// found indicates whether a line matched the regular expression or
+	// whether a value was found for the specified key or field.
+	found bool
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound FileContentRebootEvidence
+}
+
+// FileContent represents a File whose content is evaluated to determine
+// whether a reboot is needed.
+type FileContent struct {
+	File
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime FileContentRuntime
+
+	// matchType indicates how the content of the file is evaluated.
+	matchType FileContentMatchType
+
+	// pattern is the regular expression, key or JSON field path (depending
+	// on matchType) used to evaluate the content of the file.
+	pattern string
+
+	// expectedData represents the data that will be compared against the
+	// value found for a key or JSON field.
+	expectedData string
+
+	// additionalEvidence applies additional evidence "markers" for this type.
+	// If the reboot evidence markers for the enclosed File type are not
+	// matched, this (also optional) set of evidence markers are then checked
+	// to determine if a reboot is required.
+	additionalEvidence FileContentRebootEvidence
+
+	// re is the compiled regular expression for FileContentMatchRegex.
+	re *regexp.Regexp
+
+	// fieldPath is the parsed JSON field path for
+	// FileContentMatchJSONField.
+	fieldPath []interface{}
+}
+
+// NewFileContent creates a FileContent assertion evaluating the content of
+// the given File. The pattern is a regular expression, key or JSON field
+// path depending on the match type. The expected data is compared against
+// the value found for a key or JSON field.
+func NewFileContent(file *File, matchType FileContentMatchType, pattern string, expectedData string, additionalEvidence FileContentRebootEvidence) *FileContent {
+	return &FileContent{
+		File:               *file,
+		matchType:          matchType,
+		pattern:            pattern,
+		expectedData:       expectedData,
+		additionalEvidence: additionalEvidence,
+	}
+}
+
+// SupportedFileContentMatchTypes returns the list of supported file content
+// match types.
+func SupportedFileContentMatchTypes() []FileContentMatchType {
+	return []FileContentMatchType{
+		FileContentMatchRegex,
+		FileContentMatchKeyValue,
+		FileContentMatchJSONField,
+	}
+}
+
+// MatchType returns the specified file content match type.
+func (fc *FileContent) MatchType() FileContentMatchType {
+	return fc.matchType
+}
+
+// Pattern returns the specified regular expression, key or JSON field path.
+func (fc *FileContent) Pattern() string {
+	return fc.pattern
+}
+
+// Data returns the value found for a key or JSON field.
+func (fc *FileContent) Data() string {
+	return fc.runtime.data
+}
+
+// ExpectedData returns the expected data for a key or JSON field.
+func (fc *FileContent) ExpectedData() string {
+	return fc.expectedData
+}
+
+// MatchedLine returns the line responsible for a match. For a JSON field
+// this is a representation of the field and its value.
+func (fc *FileContent) MatchedLine() string {
+	return fc.runtime.matchedLine
+}
+
+// DataDisplay provides a string representation of the matched line for
+// display purposes.
+func (fc *FileContent) DataDisplay() string {
+	switch {
+	case !fc.runtime.found:
+		return fmt.Sprintf("No match for %s in %s", fc.pattern, fc)
+	case fc.runtime.lineNumber > 0:
+		return fmt.Sprintf("Line %d: %s", fc.runtime.lineNumber, fc.runtime.matchedLine)
+	default:
+		return fc.runtime.matchedLine
+	}
+}
+
+// AdditionalEvidence indicates what additional evidence "markers" have been
+// supplied. If the reboot evidence markers for the File type are not
+// matched, these (also optional) set of evidence markers are then checked to
+// determine if a reboot is required.
+func (fc *FileContent) AdditionalEvidence() FileContentRebootEvidence {
+	return fc.additionalEvidence
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (fc *FileContent) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(fc.File.evidenceExpected, fc.additionalEvidence)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (fc *FileContent) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(fc.File.runtime.evidenceFound, fc.runtime.evidenceFound)
+}
+
+// Validate performs basic validation. An error is returned for any
+// validation failures.
+func (fc *FileContent) Validate() error {
+
+	// Reboot evidence markers for the enclosed File are optional; the
+	// content evidence markers are sufficient on their own.
+	switch {
+	case fc.File.evidenceExpected != (FileRebootEvidence{}):
+		if err := fc.File.Validate(); err != nil {
+			return err
+		}
+	default:
+		if err := fc.validatePath(); err != nil {
+			return err
+		}
+	}
+
+	if fc.pattern == "" {
+		return fmt.Errorf(
+			"file content pattern not specified: %w",
+			restart.ErrMissingValue,
+		)
+	}
+
+	switch {
+	case fc.additionalEvidence.ContentMatched && fc.additionalEvidence.ContentOtherThanX:
+		return fmt.Errorf(
+			"ContentMatched and ContentOtherThanX evidence are mutually exclusive: %w",
+			restart.ErrInvalidRebootEvidence,
+		)
+	case !fc.additionalEvidence.ContentMatched && !fc.additionalEvidence.ContentOtherThanX:
+		return fmt.Errorf(
+			"file content evidence not specified: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	switch fc.matchType {
+	case FileContentMatchRegex:
+		if fc.additionalEvidence.ContentOtherThanX || fc.expectedData != "" {
+			return fmt.Errorf(
+				"expected data and ContentOtherThanX evidence are not supported for %s match type: %w",
+				fc.matchType,
+				restart.ErrInvalidRebootEvidence,
+			)
+		}
+
+		re, err := regexp.Compile(fc.pattern)
+		if err != nil {
+			return fmt.Errorf("invalid file content pattern %q: %w", fc.pattern, err)
+		}
+		fc.re = re
+
+	case FileContentMatchKeyValue:
+
+	case FileContentMatchJSONField:
+		fieldPath, err := parseJSONFieldPath(fc.pattern)
+		if err != nil {
+			return err
+		}
+		fc.fieldPath = fieldPath
+
+	default:
+		return fmt.Errorf(
+			"unsupported file content match type %q; expected one of %v: %w",
+			fc.matchType,
+			SupportedFileContentMatchTypes(),
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	return nil
+}
+
+// Evaluate performs evaluation of the embedded File value and then
+// evaluates the content of the file to determine whether a reboot is needed.
+func (fc *FileContent) Evaluate() {
+
+	// Evaluate embedded "base" File first where we check shared requirements
+	// and reboot evidence.
+	fc.File.Evaluate()
+
+	// Go no further if an error occurred evaluating the "base" File.
+	if fc.Err() != nil {
+		return
+	}
+
+	// Validate compiles the pattern; perform that step here if the caller
+	// skipped validation.
+	if (fc.matchType == FileContentMatchRegex && fc.re == nil) ||
+		(fc.matchType == FileContentMatchJSONField && fc.fieldPath == nil) {
+		if err := fc.Validate(); err != nil {
+			fc.File.runtime.err = err
+			return
+		}
+	}
+
+	filePath := filepath.Clean(fc.String())
+
+	content, err := os.ReadFile(filePath)
+	switch {
+	case os.IsNotExist(err):
+		// Requirements were evaluated by the embedded File.
+		logger.Printf("File %s not found, content not evaluated.", filePath)
+		return
+
+	case err != nil:
+		logger.Printf("Failed to read file %q: %v", filePath, err)
+
+		fc.File.runtime.err = fmt.Errorf(
+			"failed to read file %s: %w",
+			filePath,
+			err,
+		)
+
+		return
+	}
+
+	switch fc.matchType {
+	case FileContentMatchRegex:
+		fc.evalRegex(content)
+	case FileContentMatchKeyValue:
+		fc.evalKeyValue(content)
+	case FileContentMatchJSONField:
+		if err := fc.evalJSONField(content); err != nil {
+			logger.Printf("Failed to evaluate JSON field %q of %q: %v", fc.pattern, filePath, err)
+
+			fc.File.runtime.err = fmt.Errorf(
+				"failed to evaluate JSON field %s of file %s: %w",
+				fc.pattern,
+				filePath,
+				err,
+			)
+
+			return
+		}
+	}
+
+	switch {
+	case !fc.runtime.found:
+		logger.Printf("No match for %q found in %q", fc.pattern, filePath)
+		return
+
+	case fc.runtime.lineNumber == 0 && fc.matchType != FileContentMatchJSONField:
+		logger.Print("BUG: line number should have been recorded for a line match")
+	}
+
+	valueMatched := fc.matchType == FileContentMatchRegex ||
+		fc.expectedData == "" ||
+		fc.runtime.data == fc.expectedData
+
+	switch {
+	case fc.additionalEvidence.ContentMatched && valueMatched:
+		logger.Println("Reboot Evidence found!")
+		fc.SetFoundEvidenceContentMatched()
+
+	case fc.additionalEvidence.ContentOtherThanX && !valueMatched:
+		logger.Println("Reboot Evidence found!")
+		fc.SetFoundEvidenceContentOtherThanX()
+
+	default:
+		// If we made it this far then nothing specific to this "super type"
+		// indicated that a reboot was necessary.
+		return
+	}
+
+	logger.Printf("Recording matched path %s", filePath)
+	fc.AddMatchedPath(filePath)
+
+	// Entries from the reasons file are retrieved by the embedded File if
+	// evidence was found for it.
+	if !fc.File.HasEvidence() {
+		fc.evalReasonsFile()
+	}
+}
+
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed File) so that the FileContent may be evaluated again.
+func (fc *FileContent) Reset() {
+	fc.File.Reset()
+	fc.runtime = FileContentRuntime{}
+}
+
+// evalRegex records the first line of the file content matching the regular
+// expression.
+func (fc *FileContent) evalRegex(content []byte) {
+	for i, line := range contentLines(content) {
+		if fc.re.MatchString(line) {
+			logger.Printf("Line %d matches pattern %q", i+1, fc.pattern)
+
+			fc.runtime.found = true
+			fc.runtime.matchedLine = line
+			fc.runtime.lineNumber = i + 1
+
+			return
+		}
+	}
+}
+
+// evalKeyValue records the value for the specified key from the file
+// content. If the key is listed more than once the last entry is used.
+func (fc *FileContent) evalKeyValue(content []byte) {
+	for i, line := range contentLines(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok || strings.TrimSpace(key) != fc.pattern {
+			continue
+		}
+
+		logger.Printf("Line %d sets key %q", i+1, fc.pattern)
+
+		fc.runtime.found = true
+		fc.runtime.data = unquote(strings.TrimSpace(value))
+		fc.runtime.matchedLine = line
+		fc.runtime.lineNumber = i + 1
+	}
+}
+
+// evalJSONField records the value for the specified field from the file
+// content.
+func (fc *FileContent) evalJSONField(content []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("failed to decode JSON content: %w", err)
+	}
+
+	value, ok := lookupJSONField(document, fc.fieldPath)
+	if !ok {
+		return nil
+	}
+
+	data, err := jsonFieldString(value)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("Field %q found with value %q", fc.pattern, data)
+
+	fc.runtime.found = true
+	fc.runtime.data = data
+	fc.runtime.matchedLine = fmt.Sprintf("%s: %s", fc.pattern, data)
+
+	return nil
+}
+
+// SetFoundEvidenceContentMatched records that the ContentMatched reboot
+// evidence was found.
+func (fc *FileContent) SetFoundEvidenceContentMatched() {
+	logger.Printf("Recording that the ContentMatched evidence was found for %q", fc)
+	fc.runtime.evidenceFound.ContentMatched = true
+}
+
+// SetFoundEvidenceContentOtherThanX records that the ContentOtherThanX
+// reboot evidence was found.
+func (fc *FileContent) SetFoundEvidenceContentOtherThanX() {
+	logger.Printf("Recording that the ContentOtherThanX evidence was found for %q", fc)
+	fc.runtime.evidenceFound.ContentOtherThanX = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (fc *FileContent) HasEvidence() bool {
+
+	// Check enclosed File first.
+	if fc.File.HasEvidence() {
+		return true
+	}
+
+	if fc.runtime.evidenceFound.ContentMatched {
+		return true
+	}
+
+	if fc.runtime.evidenceFound.ContentOtherThanX {
+		return true
+	}
+
+	return false
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (fc *FileContent) RebootReasons() []string {
+
+	// Gather existing reasons for a reboot so that we can (potentially)
+	// expand on them with additional reasons.
+	reasons := fc.File.RebootReasons()
+
+	if fc.runtime.evidenceFound.ContentMatched {
+		switch fc.matchType {
+		case FileContentMatchRegex:
+			reasons = append(reasons, fmt.Sprintf(
+				"Line %d of file %s matches %s",
+				fc.runtime.lineNumber,
+				fc,
+				fc.pattern,
+			))
+		default:
+			reasons = append(reasons, fmt.Sprintf(
+				"Found %s with value %q in file %s",
+				fc.pattern,
+				fc.runtime.data,
+				fc,
+			))
+		}
+	}
+
+	if fc.runtime.evidenceFound.ContentOtherThanX {
+		reasons = append(reasons, fmt.Sprintf(
+			"Found %s with value %q in file %s (expected %q)",
+			fc.pattern,
+			fc.runtime.data,
+			fc,
+			fc.expectedData,
+		))
+	}
+
+	return reasons
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the FileContent has been marked as ignored (all recorded
+// matched paths marked as ignored) the need for a reboot is not indicated.
+func (fc *FileContent) RebootRequired() bool {
+	if !fc.Ignored() && fc.HasEvidence() {
+		return true
+	}
+
+	return false
+}
+
+// IsCriticalState indicates whether an evaluation determined that the
+// FileContent is in a CRITICAL state. Whether the FileContent has been
+// marked as Ignored is considered. The caller is responsible for filtering
+// the collection prior to calling this method.
+func (fc *FileContent) IsCriticalState() bool {
+	switch {
+
+	// If we could determine that a reboot is required we consider that to be
+	// a WARNING state.
+	case !fc.Ignored() && fc.RebootRequired():
+		return false
+
+	// If we were unable to determine whether a reboot is required due to
+	// errors we consider that to be a CRITICAL state.
+	case !fc.Ignored() && fc.Err() != nil:
+		if errors.Is(fc.Err(), restart.ErrMissingOptionalItem) {
+			return false
+		}
+		return true
+
+	// No reboot required and no errors, not CRITICAL state.
+	default:
+		return false
+
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the
+// FileContent is in a WARNING state. Whether the FileContent has been marked
+// as Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (fc *FileContent) IsWarningState() bool {
+	return !fc.Ignored() && fc.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the FileContent
+// is in an OK state. Whether the FileContent has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior
+// to calling this method.
+func (fc *FileContent) IsOKState() bool {
+	switch {
+	case fc.Ignored():
+		return true
+	case fc.RebootRequired():
+		return false
+	case fc.Err() != nil:
+		return errors.Is(fc.Err(), restart.ErrMissingOptionalItem)
+	default:
+		return true
+	}
+}
+
+// contentLines splits file content into lines. Line endings (including
+// Windows line endings) are removed.
+func contentLines(content []byte) []string {
+	lines := strings.Split(string(content), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	return lines
+}
+
+// unquote removes a matching pair of single or double quotes surrounding a
+// value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
+
+// parseJSONFieldPath parses a JSONPath-like field path into a list of
+// object member names (string) and array indexes (int). The leading $ is
+// optional. Members are specified as .name or ["name"] and array elements as
+// [index].
+func parseJSONFieldPath(path string) ([]interface{}, error) {
+	invalidPath := func(reason string) error {
+		return fmt.Errorf("%q: %s: %w", path, reason, ErrInvalidJSONFieldPath)
+	}
+
+	remaining := path
+	switch {
+	case strings.HasPrefix(remaining, "$"):
+		remaining = remaining[1:]
+	case remaining != "" && remaining[0] != '.' && remaining[0] != '[':
+		remaining = "." + remaining
+	}
+
+	fieldPath := make([]interface{}, 0, strings.Count(remaining, ".")+strings.Count(remaining, "["))
+
+	for remaining != "" {
+		switch remaining[0] {
+		case '.':
+			remaining = remaining[1:]
+
+			end := strings.IndexAny(remaining, ".[")
+			if end < 0 {
+				end = len(remaining)
+			}
+
+			name := remaining[:end]
+			if name == "" {
+				return nil, invalidPath("empty member name")
+			}
+
+			fieldPath = append(fieldPath, name)
+			remaining = remaining[end:]
+
+		case '[':
+			end := strings.IndexByte(remaining, ']')
+			if end < 0 {
+				return nil, invalidPath("missing closing bracket")
+			}
+
+			element := remaining[1:end]
+			remaining = remaining[end+1:]
+
+			if quoted := unquote(element); len(quoted) == len(element)-2 {
+				fieldPath = append(fieldPath, quoted)
+				continue
+			}
+
+			index, err := strconv.Atoi(element)
+			if err != nil || index < 0 {
+				return nil, invalidPath(fmt.Sprintf("invalid array index %q", element))
+			}
+
+			fieldPath = append(fieldPath, index)
+
+		default:
+			return nil, invalidPath(fmt.Sprintf("unexpected character %q", remaining[0]))
+		}
+	}
+
+	return fieldPath, nil
+}
+
+// lookupJSONField retrieves the value at the given field path from a
+// decoded JSON document and whether the value was found.
+func lookupJSONField(document interface{}, fieldPath []interface{}) (interface{}, bool) {
+	value := document
+
+	for _, element := range fieldPath {
+		switch element := element.(type) {
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			value, ok = object[element]
+			if !ok {
+				return nil, false
+			}
+
+		case int:
+			array, ok := value.([]interface{})
+			if !ok || element >= len(array) {
+				return nil, false
+			}
+
+			value = array[element]
+		}
+	}
+
+	return value, true
+}
+
+// jsonFieldString returns the string representation of a decoded JSON value
+// used for comparison against expected data. Strings are used as-is while
+// other values use their JSON encoding.
+func jsonFieldString(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
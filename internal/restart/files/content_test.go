@@ -0,0 +1,194 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// TestFileContentEvaluate asserts that the content of a file is evaluated
+// using each of the supported match types.
+func TestFileContentEvaluate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	stateFile := filepath.Join(dir, "patch-state")
+	statusFile := filepath.Join(dir, "status.json")
+	missingFile := filepath.Join(dir, "missing")
+
+	const state = "# Written by patch tooling\r\n" +
+		"LAST_RUN=2023-06-01\r\n" +
+		"UPDATE_PENDING=0\r\n" +
+		"UPDATE_PENDING=\"1\"\r\n"
+
+	const status = `{
+  "agent": {"version": "4.2"},
+  "updates": [
+    {"id": "KB5027215", "state": "installed"},
+    {"id": "KB5027231", "state": "pending-reboot"}
+  ],
+  "reboot": {"required": true}
+}`
+
+	if err := os.WriteFile(stateFile, []byte(state), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(statusFile, []byte(status), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	matched := FileContentRebootEvidence{ContentMatched: true}
+	otherThanX := FileContentRebootEvidence{ContentOtherThanX: true}
+
+	tests := map[string]struct {
+		path            string
+		matchType       FileContentMatchType
+		pattern         string
+		expected        string
+		evidence        FileContentRebootEvidence
+		requirements    FileAssertions
+		wantReboot      bool
+		wantErr         error
+		wantDataDisplay string
+	}{
+		"regex matches line": {
+			path: stateFile, matchType: FileContentMatchRegex, pattern: `^UPDATE_PENDING="?1"?$`,
+			evidence: matched, wantReboot: true, wantDataDisplay: `Line 4: UPDATE_PENDING="1"`,
+		},
+		"regex does not match": {
+			path: stateFile, matchType: FileContentMatchRegex, pattern: `^REBOOT=`,
+			evidence: matched,
+		},
+		"last key value is used": {
+			path: stateFile, matchType: FileContentMatchKeyValue, pattern: "UPDATE_PENDING", expected: "1",
+			evidence: matched, wantReboot: true, wantDataDisplay: `Line 4: UPDATE_PENDING="1"`,
+		},
+		"key value other than expected": {
+			path: stateFile, matchType: FileContentMatchKeyValue, pattern: "UPDATE_PENDING", expected: "0",
+			evidence: otherThanX, wantReboot: true,
+		},
+		"key value matches expected": {
+			path: stateFile, matchType: FileContentMatchKeyValue, pattern: "UPDATE_PENDING", expected: "1",
+			evidence: otherThanX,
+		},
+		"missing key": {
+			path: stateFile, matchType: FileContentMatchKeyValue, pattern: "REBOOT",
+			evidence: matched,
+		},
+		"json field in array": {
+			path: statusFile, matchType: FileContentMatchJSONField, pattern: `$.updates[1].state`, expected: "pending-reboot",
+			evidence: matched, wantReboot: true, wantDataDisplay: `$.updates[1].state: pending-reboot`,
+		},
+		"json boolean field": {
+			path: statusFile, matchType: FileContentMatchJSONField, pattern: `reboot["required"]`, expected: "true",
+			evidence: matched, wantReboot: true,
+		},
+		"json field other than expected": {
+			path: statusFile, matchType: FileContentMatchJSONField, pattern: `$.updates[0].state`, expected: "installed",
+			evidence: otherThanX,
+		},
+		"missing json field": {
+			path: statusFile, matchType: FileContentMatchJSONField, pattern: `$.updates[2].state`,
+			evidence: matched,
+		},
+		"invalid json": {
+			path: stateFile, matchType: FileContentMatchJSONField, pattern: `$.reboot`,
+			evidence: matched, wantErr: errors.New("any"),
+		},
+		"missing optional file": {
+			path: missingFile, matchType: FileContentMatchRegex, pattern: `.`,
+			evidence: matched,
+		},
+		"missing required file": {
+			path: missingFile, matchType: FileContentMatchRegex, pattern: `.`,
+			evidence: matched, requirements: FileAssertions{FileRequired: true},
+			wantErr: restart.ErrMissingRequiredItem,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fc := NewFileContent(
+				NewFile(tt.path, "", "", FileRebootEvidence{}, tt.requirements),
+				tt.matchType,
+				tt.pattern,
+				tt.expected,
+				tt.evidence,
+			)
+
+			if err := fc.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate file content assertion: %v", err)
+			}
+
+			fc.Evaluate()
+
+			switch {
+			case tt.wantErr == nil && fc.Err() != nil:
+				t.Errorf("ERROR: unexpected error: %v", fc.Err())
+			case tt.wantErr != nil && fc.Err() == nil:
+				t.Errorf("ERROR: expected error %v; got nil", tt.wantErr)
+			case errors.Is(tt.wantErr, restart.ErrMissingRequiredItem) && !errors.Is(fc.Err(), tt.wantErr):
+				t.Errorf("ERROR: got error %v; want %v", fc.Err(), tt.wantErr)
+			}
+
+			if got := fc.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got RebootRequired() %t; want %t", got, tt.wantReboot)
+			}
+
+			if tt.wantDataDisplay != "" && fc.DataDisplay() != tt.wantDataDisplay {
+				t.Errorf("ERROR: got DataDisplay() %q; want %q", fc.DataDisplay(), tt.wantDataDisplay)
+			}
+
+			if tt.wantReboot {
+				fc.Filter(restart.IgnorePatterns{mustParseIgnorePattern(t, "file:exact:"+tt.path)})
+
+				if fc.RebootRequired() || !fc.IsOKState() {
+					t.Errorf("ERROR: expected ignored match to no longer require a reboot")
+				}
+			}
+		})
+	}
+}
+
+// TestParseJSONFieldPathInvalid asserts that invalid JSON field paths are
+// rejected.
+func TestParseJSONFieldPathInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{
+		"$.",
+		"status..state",
+		"updates[",
+		"updates[-1]",
+		"updates[x]",
+		"$status",
+	} {
+		if _, err := parseJSONFieldPath(path); !errors.Is(err, ErrInvalidJSONFieldPath) {
+			t.Errorf("ERROR: expected ErrInvalidJSONFieldPath for %q; got %v", path, err)
+		}
+	}
+}
+
+func mustParseIgnorePattern(t *testing.T, pattern string) restart.IgnorePattern {
+	t.Helper()
+
+	ip, err := restart.ParseIgnorePattern(pattern)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore pattern %q: %v", pattern, err)
+	}
+
+	return ip
+}
@@ -0,0 +1,1118 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserter implementation isn't correct.
+var _ restart.RebootRequiredAsserter = (*File)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.FileRebootRequired implementation isn't correct.
+var _ FileRebootRequired = (*File)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var _ restart.EvaluationTimer = (*File)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var _ restart.PendingTracker = (*File)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*File)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*File)(nil)
+
+// Add "implements assertions" to fail the build if the restart.MatchedPath
+// implementation isn't correct.
+var _ restart.MatchedPath = (*MatchedPath)(nil)
+
+// FileRebootRequired represents the behavior of a file that can be evaluated
+// to indicate whether a reboot is required.
+//
+// TODO: This is not needed at present, but would be useful later if/when
+// adding support for optional files or when evaluating a file's metadata or
+// contents (e.g., a specific value for a specific line in a file).
+type FileRebootRequired interface {
+	Validate() error
+	Path() string
+	Requirements() FileAssertions
+	String() string
+	// Err() error // TODO: Should this be an interface method?
+}
+
+// FileRebootEvidence indicates what file evidence is required in order to
+// determine that a reboot is needed.
+type FileRebootEvidence struct {
+	FileExists     bool
+	FileEmpty      bool
+	FileNotEmpty   bool
+	FileExecutable bool
+	FileIsSymlink  bool
+
+	// FileModifiedAfterBoot is an evidence "marker" that if satisfied
+	// indicates the need for a reboot. This marker is satisfied if the file
+	// was last modified after the system was last booted.
+	FileModifiedAfterBoot bool
+
+	// FileOlderThan is an evidence "marker" that if satisfied indicates the
+	// need for a reboot. This marker is satisfied if the file was last
+	// modified more than the specified number of days ago.
+	FileOlderThan bool
+}
+
+// FileAssertions indicates what requirements must be met. If not met, this
+// indicates than an error has occurred. If a specific file is required, but
+// not present on a system then client code can not reliably determine whether
+// a reboot is necessary. IN that scenario client code should assume that all
+// results are invalid.
+//
+// TODO: This is not needed at present, but would be useful later if/when
+// adding support for evaluating a file's metadata or contents (e.g., a
+// specific value for a specific line in a file).
+type FileAssertions struct {
+	FileRequired bool
+}
+
+// FileRuntime is a collection of values for a File that are set during File
+// evaluation. Unlike the static values set for a File (e.g., path, any
+// requirements or assertions), these values are not known until execution or
+// runtime.
+type FileRuntime struct {
+	// err records any error that occurs while performing an evaluation.
+	err error
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound FileRebootEvidence
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a reboot is needed.
+	// ignored bool
+
+	// pathsMatched is a collection of file path values that were matched
+	// during evaluation of specified reboot required assertions.
+	pathsMatched MatchedPathIndex
+
+	// reasonsFound is the collection of entries retrieved from the
+	// (optional) reasons file associated with a File.
+	reasonsFound []string
+
+	// modTime is the last modification time of the file.
+	modTime time.Time
+
+	// bootTime is the time the system was last booted. This is only
+	// retrieved if the FileModifiedAfterBoot evidence is expected.
+	bootTime time.Time
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
+}
+
+// MatchedPathIndex is a collection of path values that were matched during
+// evaluation of specified reboot required assertions.
+type MatchedPathIndex map[string]MatchedPath
+
+// File represents a file that if found (and requirements met) indicates a
+// reboot is needed.
+type File struct {
+	// path is either the fully-qualified path to a file or, if
+	// envVarPathPrefix is set is a partial path to be joined to
+	// envVarPathPrefix to form a fully-qualified path to a file.
+	path string
+
+	// envVarPathPrefix if set, will be prepended to path to form the
+	// fully-qualified path to a file.
+	envVarPathPrefix string
+
+	// lookupEnv if set, is used instead of the environment of the current
+	// process to resolve envVarPathPrefix. This allows evaluating assertions
+	// against an offline system.
+	lookupEnv func(key string) (string, bool)
+
+	// reasonsPath is an optional fully-qualified path to a file listing (one
+	// entry per line) the items responsible for the need to reboot. If
+	// present when evidence of a needed reboot is found, each entry is
+	// included as a reboot reason.
+	//
+	// For example, Debian-based systems list packages requesting a reboot in
+	// a file alongside the /var/run/reboot-required sentinel file.
+	reasonsPath string
+
+	// olderThanDays is the number of days after which a file last modified
+	// before then satisfies the FileOlderThan evidence marker.
+	olderThanDays int
+
+	// bootTime if set, is used instead of boot.Time to determine when the
+	// system was last booted.
+	bootTime boot.Provider
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime FileRuntime
+
+	// evidenceExpected indicates what evidence is used to determine that a
+	// reboot is needed.
+	evidenceExpected FileRebootEvidence
+
+	// requirements indicates what requirements must be met. If not met, this
+	// indicates that an error has occurred.
+	requirements FileAssertions
+}
+
+// NewFile creates a File assertion for the file at the given path. If
+// envVarPathPrefix is specified the value of that environment variable is
+// prepended to the path. If specified, entries from the file at reasonsPath
+// are included as reboot reasons.
+func NewFile(path string, envVarPathPrefix string, reasonsPath string, evidence FileRebootEvidence, requirements FileAssertions) *File {
+	return &File{
+		path:             path,
+		envVarPathPrefix: envVarPathPrefix,
+		reasonsPath:      reasonsPath,
+		evidenceExpected: evidence,
+		requirements:     requirements,
+	}
+}
+
+// SetEnvLookup sets the function used to resolve environment variables
+// referenced by the path (or used as a path prefix) instead of the
+// environment of the current process.
+func (f *File) SetEnvLookup(lookupEnv func(key string) (string, bool)) {
+	f.lookupEnv = lookupEnv
+}
+
+// SetOlderThanDays sets the number of days after which a file satisfies the
+// FileOlderThan evidence marker.
+func (f *File) SetOlderThanDays(days int) {
+	f.olderThanDays = days
+}
+
+// OlderThanDays returns the number of days after which a file satisfies the
+// FileOlderThan evidence marker.
+func (f *File) OlderThanDays() int {
+	return f.olderThanDays
+}
+
+// SetBootTimeProvider sets the function used to determine when the system
+// was last booted instead of boot.Time.
+func (f *File) SetBootTimeProvider(bootTime boot.Provider) {
+	f.bootTime = bootTime
+}
+
+// Err exposes the underlying error (if any) as-is.
+func (f *File) Err() error {
+	return f.runtime.err
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (f *File) EvaluationDuration() time.Duration {
+	return f.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (f *File) SetEvaluationDuration(duration time.Duration) {
+	f.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (f *File) PendingSince() time.Time {
+	return f.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (f *File) Escalated() bool {
+	return f.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (f *File) SetPending(since time.Time, escalated bool) {
+	f.runtime.pendingSince = since
+	f.runtime.escalated = escalated
+}
+
+// MatchedPath represents a path that was matched when performing an
+// evaluation of a "reboot required" assertion.
+type MatchedPath struct {
+	// root is the left-most element of a matched path. This is the beginning
+	// of a qualified path.
+	root string
+
+	// relative is the unqualified path (without the root element). The base
+	// element of the path is usually included in this element.
+	relative string
+
+	// base is the last element or the right-most "leaf" value of a matched
+	// path.
+	base string
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a reboot is needed.
+	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
+}
+
+// Validate performs basic validation. An error is returned for any validation
+// failures.
+func (f *File) Validate() error {
+
+	if err := f.validatePath(); err != nil {
+		return err
+	}
+
+	// Validate reboot evidence values.
+	switch {
+	case f.evidenceExpected.FileEmpty && f.evidenceExpected.FileNotEmpty:
+		return fmt.Errorf(
+			"FileEmpty and FileNotEmpty evidence are mutually exclusive: %w",
+			restart.ErrInvalidRebootEvidence,
+		)
+	case f.evidenceExpected.FileOlderThan && f.olderThanDays <= 0:
+		return fmt.Errorf(
+			"FileOlderThan evidence requires a positive number of days; got %d: %w",
+			f.olderThanDays,
+			restart.ErrInvalidRebootEvidence,
+		)
+	case f.evidenceExpected.FileExists:
+	case f.evidenceExpected.FileEmpty:
+	case f.evidenceExpected.FileNotEmpty:
+	case f.evidenceExpected.FileExecutable:
+	case f.evidenceExpected.FileIsSymlink:
+	case f.evidenceExpected.FileModifiedAfterBoot:
+	case f.evidenceExpected.FileOlderThan:
+	default:
+		return fmt.Errorf(
+			"file evidence not specified: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	return nil
+
+}
+
+// Path returns the specified (potentially unqualified) path to the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Requirements returns the specified requirements or file assertions. If one
+// of these requirements is not met than an error condition has been
+// encountered. Requirements does not indicate whether a reboot is needed,
+// only how potential "not found" conditions should be treated.
+func (f *File) Requirements() FileAssertions {
+	return f.requirements
+}
+
+// String provides the fully qualified path for a File. Environment variable
+// references (%VAR%, or $VAR and ${VAR} outside of Windows paths) within the
+// path are expanded and if specified, the value of the path prefix
+// environment variable is prepended to the path. If an environment variable
+// is not specified, the given path value is expected to be fully qualified.
+//
+// If an environment variable cannot be resolved the path is returned with
+// the references intact; Validate reports this as an error.
+func (f *File) String() string {
+	path, err := f.expandedPath()
+	if err != nil {
+		if f.envVarPathPrefix != "" {
+			return "%" + f.envVarPathPrefix + "%" + string(filepath.Separator) + f.path
+		}
+
+		return f.path
+	}
+
+	return path
+}
+
+// expandedPath returns the fully qualified path for a File with environment
+// variable references expanded. An error is returned if an environment
+// variable cannot be resolved.
+func (f *File) expandedPath() (string, error) {
+	lookupEnv := f.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	var pathPrefix string
+	if f.envVarPathPrefix != "" {
+		prefix, ok := lookupEnv(f.envVarPathPrefix)
+		if !ok || prefix == "" {
+			return "", fmt.Errorf(
+				"%s (path prefix): %w",
+				f.envVarPathPrefix,
+				restart.ErrUnresolvedVariable,
+			)
+		}
+		pathPrefix = prefix
+	}
+
+	expand := restart.ExpandPath
+	if pathPrefix != "" {
+		// Paths relative to a Windows environment variable are Windows
+		// paths even without a path separator (e.g., $Recycle.Bin).
+		expand = restart.ExpandWindowsVariables
+	}
+
+	path, err := expand(f.path, lookupEnv)
+	if err != nil {
+		return "", err
+	}
+
+	// Partial paths and paths using Windows environment variables use
+	// Windows path separators; convert them so that paths for an offline
+	// Windows system resolve on other platforms.
+	if pathPrefix != "" || strings.Contains(f.path, "%") {
+		path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+	}
+
+	if pathPrefix != "" {
+		return filepath.Join(pathPrefix, path), nil
+	}
+
+	return path, nil
+}
+
+// validatePath asserts that a path is specified and that any environment
+// variables referenced by the path can be resolved.
+func (f *File) validatePath() error {
+	if f.path == "" {
+		return fmt.Errorf(
+			"invalid file path: %w",
+			restart.ErrMissingValue,
+		)
+	}
+
+	if _, err := f.expandedPath(); err != nil {
+		return fmt.Errorf("invalid file path %s: %w", f.path, err)
+	}
+
+	return nil
+}
+
+// AddMatchedPath records given paths as successful assertion matches.
+// Duplicate entries are ignored.
+func (f *File) AddMatchedPath(paths ...string) {
+
+	// f.path may be unqualified. Unlike registry keys, these values are not
+	// intentionally split out into "root" keys and path values.
+	var rootPath string
+	qualifiedPath, err := filepath.Abs(f.String())
+	switch {
+	case err != nil:
+		rootPath = filepath.Dir(f.path)
+	default:
+		rootPath = filepath.Dir(qualifiedPath)
+	}
+
+	f.addMatchedPath(rootPath, paths...)
+}
+
+// addMatchedPath records given paths as successful assertion matches
+// relative to the given root path. Duplicate entries are ignored.
+func (f *File) addMatchedPath(rootPath string, paths ...string) {
+
+	if f.runtime.pathsMatched == nil {
+		f.runtime.pathsMatched = make(MatchedPathIndex)
+	}
+
+	for _, path := range paths {
+		// Record MatchedPath if it does not already exist; we do not want to
+		// overwrite an existing entry in case any non-default metadata is set
+		// for the entry.
+		if _, ok := f.runtime.pathsMatched[path]; !ok {
+
+			relPath, err := filepath.Rel(rootPath, path)
+			switch {
+			case err != nil:
+				logger.Printf("Failed to obtain relative path for %q using %q as the base", path, rootPath)
+				logger.Printf("Falling back to using %q as relative path", path)
+				relPath = path
+			default:
+				logger.Printf(
+					"Successfully resolved %q as relative path of %q using %q as root path",
+					relPath,
+					path,
+					rootPath,
+				)
+			}
+
+			matchedPath := MatchedPath{
+				root:     rootPath,
+				relative: relPath,
+				base:     filepath.Base(path),
+			}
+
+			f.runtime.pathsMatched[path] = matchedPath
+		}
+	}
+
+}
+
+// MatchedPaths returns all recorded paths from successful assertion matches.
+//
+//	func (f *File) MatchedPaths() []string {
+//		paths := make([]string, 0, len(f.runtime.pathsMatched))
+//		for path := range f.runtime.pathsMatched {
+//			paths = append(paths, path)
+//		}
+//		return paths
+//	}
+func (f *File) MatchedPaths() restart.MatchedPaths {
+
+	pathStrings := make([]string, 0, len(f.runtime.pathsMatched))
+	matchedPaths := make(restart.MatchedPaths, 0, len(f.runtime.pathsMatched))
+
+	// Pull all of the keys.
+	for k := range f.runtime.pathsMatched {
+		pathStrings = append(pathStrings, k)
+	}
+
+	// Sort them.
+	sort.Strings(sort.StringSlice(pathStrings))
+
+	// Use them to pull out the MatchedPath entries in order.
+	for _, path := range pathStrings {
+		logger.Printf("File.runtime.pathsMatched entry: %q", path)
+		matchedPaths = append(matchedPaths, f.runtime.pathsMatched[path])
+	}
+
+	return matchedPaths
+}
+
+// Evaluate applies the specified assertion to determine if a reboot is
+// necessary.
+func (f *File) Evaluate() {
+	logger.Printf("Given file: %s", f)
+
+	filePath := filepath.Clean(f.String())
+	logger.Printf("File after sanitizing path: %s", filePath)
+
+	// Lstat is used so that a symbolic link is evaluated as a link instead
+	// of as the file it refers to.
+	linkInfo, err := os.Lstat(filePath)
+	switch {
+	case os.IsNotExist(err):
+		if f.requirements.FileRequired {
+			logger.Printf("File %s not found, but marked as required.", filePath)
+
+			f.runtime.err = fmt.Errorf(
+				"file %s not found, but marked as required: %w",
+				filePath,
+				restart.ErrMissingRequiredItem,
+			)
+
+			return
+		}
+
+		logger.Printf("File %s not found, reboot not required due to this file.", filePath)
+
+		return
+
+	case err != nil:
+		f.runtime.err = err
+
+		return
+	}
+
+	logger.Printf("File %q found!", filePath)
+
+	// Evaluate the properties of the file a symbolic link refers to. If the
+	// link is broken, only the properties of the link itself are evaluated.
+	info := linkInfo
+	isSymlink := linkInfo.Mode()&os.ModeSymlink != 0
+	if isSymlink {
+		targetInfo, err := os.Stat(filePath)
+		switch {
+		case err != nil:
+			logger.Printf("Failed to evaluate target of symbolic link %q: %v", filePath, err)
+			info = nil
+		default:
+			info = targetInfo
+		}
+	}
+
+	isRegular := info != nil && info.Mode().IsRegular()
+
+	if f.evidenceExpected.FileExists {
+		f.SetFoundEvidenceFileExists()
+	}
+
+	if f.evidenceExpected.FileIsSymlink && isSymlink {
+		f.SetFoundEvidenceFileIsSymlink()
+	}
+
+	if f.evidenceExpected.FileEmpty && isRegular && info.Size() == 0 {
+		f.SetFoundEvidenceFileEmpty()
+	}
+
+	if f.evidenceExpected.FileNotEmpty && isRegular && info.Size() > 0 {
+		f.SetFoundEvidenceFileNotEmpty()
+	}
+
+	if f.evidenceExpected.FileExecutable && isRegular && isExecutable(filePath, info) {
+		f.SetFoundEvidenceFileExecutable()
+	}
+
+	if info != nil {
+		f.runtime.modTime = info.ModTime()
+	}
+
+	if f.evidenceExpected.FileOlderThan && info != nil {
+		cutoff := time.Now().AddDate(0, 0, -f.olderThanDays)
+		if f.runtime.modTime.Before(cutoff) {
+			f.SetFoundEvidenceFileOlderThan()
+		}
+	}
+
+	if f.evidenceExpected.FileModifiedAfterBoot && info != nil {
+		bootTime, err := f.getBootTime()
+		if err != nil {
+			logger.Printf("Failed to determine boot time: %v", err)
+
+			f.runtime.err = fmt.Errorf(
+				"failed to determine boot time for evaluating file %s: %w",
+				filePath,
+				err,
+			)
+
+			return
+		}
+
+		f.runtime.bootTime = bootTime
+		if f.runtime.modTime.After(bootTime) {
+			f.SetFoundEvidenceFileModifiedAfterBoot()
+		}
+	}
+
+	if !f.HasEvidence() {
+		logger.Printf("No expected evidence found for %q, reboot not required due to this file.", filePath)
+
+		return
+	}
+
+	logger.Println("Reboot Required!")
+
+	f.AddMatchedPath(filePath)
+
+	f.evalReasonsFile()
+}
+
+// Reset discards the results of an earlier evaluation so that the File may
+// be evaluated again.
+func (f *File) Reset() {
+	f.runtime = FileRuntime{}
+}
+
+// getBootTime returns the time the system was last booted using the
+// configured boot time provider.
+func (f *File) getBootTime() (time.Time, error) {
+	if f.bootTime != nil {
+		return f.bootTime()
+	}
+
+	return boot.Time()
+}
+
+// evalReasonsFile retrieves entries from the (optional) reasons file
+// associated with the File. A missing reasons file is not considered an
+// error; the entries are supplemental to the evidence already found.
+func (f *File) evalReasonsFile() {
+	if f.reasonsPath == "" {
+		return
+	}
+
+	reasonsPath := filepath.Clean(f.reasonsPath)
+	logger.Printf("Reading reasons file %q for %q", reasonsPath, f)
+
+	fh, err := os.Open(reasonsPath)
+	switch {
+	case os.IsNotExist(err):
+		logger.Printf("Reasons file %q not found, skipping", reasonsPath)
+		return
+
+	case err != nil:
+		logger.Printf("Failed to open reasons file %q: %v", reasonsPath, err)
+		return
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("Failed to close reasons file %q: %v", reasonsPath, err)
+		}
+	}()
+
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" {
+			continue
+		}
+
+		// Package tooling may list the same entry more than once.
+		if _, ok := seen[entry]; ok {
+			continue
+		}
+		seen[entry] = struct{}{}
+
+		f.runtime.reasonsFound = append(f.runtime.reasonsFound, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Printf("Error reading reasons file %q: %v", reasonsPath, err)
+	}
+
+	logger.Printf("%d entries retrieved from reasons file %q", len(f.runtime.reasonsFound), reasonsPath)
+}
+
+// ReasonsPath returns the specified path to the (optional) file listing the
+// items responsible for the need to reboot.
+func (f *File) ReasonsPath() string {
+	return f.reasonsPath
+}
+
+// Filter uses the list of specified ignore patterns to mark each matched path
+// for the File as ignored *IF* a match is found.
+//
+// While matched path and ignored pattern entries are normalized before
+// comparison, we record path entries using the original non-normalized form.
+//
+// If no matched paths are recorded Filter makes no changes. Filter should be
+// called before performing final state evaluation.
+func (f *File) Filter(ignorePatterns restart.IgnorePatterns) {
+
+	numIgnorePatterns := len(ignorePatterns)
+	var numIgnorePatternsApplied int
+
+	if numIgnorePatterns == 0 {
+		logger.Printf("0 ignore patterns specified for %q; skipping Filter", f)
+		return
+	}
+
+	logger.Printf(
+		"%d ignore patterns specified for %q; applying Filter",
+		numIgnorePatterns,
+		f,
+	)
+
+	for originalPathString, matchedPath := range f.runtime.pathsMatched {
+		logger.Printf("Searching matched path %q for ignore pattern matches", originalPathString)
+
+		ignorePattern, ok := ignorePatterns.Match(restart.IgnoreTargetFile, originalPathString, f.String())
+		if ok {
+			logger.Printf("matchedPath %q matches ignorePattern %q", originalPathString, ignorePattern)
+			logger.Printf("marking matched path %q as ignored", originalPathString)
+
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			f.runtime.pathsMatched[originalPathString] = matchedPath
+			numIgnorePatternsApplied++
+		}
+	}
+
+	logger.Printf("%d ignore patterns applied for %q", numIgnorePatternsApplied, f)
+}
+
+// ExpectedEvidence returns the specified evidence that (if found) indicates a
+// reboot is needed.
+func (f *File) ExpectedEvidence() FileRebootEvidence {
+	return f.evidenceExpected
+}
+
+// DiscoveredEvidence returns the discovered evidence from an earlier
+// evaluation.
+func (f *File) DiscoveredEvidence() FileRebootEvidence {
+	return f.runtime.evidenceFound
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (f *File) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(f.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (f *File) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(f.runtime.evidenceFound)
+}
+
+// SetFoundEvidenceFileExists records that the FileExists reboot evidence was
+// found.
+func (f *File) SetFoundEvidenceFileExists() {
+	logger.Printf("Recording that the FileExists evidence was found for %q", f)
+	f.runtime.evidenceFound.FileExists = true
+}
+
+// SetFoundEvidenceFileEmpty records that the FileEmpty reboot evidence was
+// found.
+func (f *File) SetFoundEvidenceFileEmpty() {
+	logger.Printf("Recording that the FileEmpty evidence was found for %q", f)
+	f.runtime.evidenceFound.FileEmpty = true
+}
+
+// SetFoundEvidenceFileNotEmpty records that the FileNotEmpty reboot evidence
+// was found.
+func (f *File) SetFoundEvidenceFileNotEmpty() {
+	logger.Printf("Recording that the FileNotEmpty evidence was found for %q", f)
+	f.runtime.evidenceFound.FileNotEmpty = true
+}
+
+// SetFoundEvidenceFileExecutable records that the FileExecutable reboot evidence
+// was found.
+func (f *File) SetFoundEvidenceFileExecutable() {
+	logger.Printf("Recording that the FileExecutable evidence was found for %q", f)
+	f.runtime.evidenceFound.FileExecutable = true
+}
+
+// SetFoundEvidenceFileIsSymlink records that the FileIsSymlink reboot evidence
+// was found.
+func (f *File) SetFoundEvidenceFileIsSymlink() {
+	logger.Printf("Recording that the FileIsSymlink evidence was found for %q", f)
+	f.runtime.evidenceFound.FileIsSymlink = true
+}
+
+// SetFoundEvidenceFileModifiedAfterBoot records that the
+// FileModifiedAfterBoot reboot evidence was found.
+func (f *File) SetFoundEvidenceFileModifiedAfterBoot() {
+	logger.Printf("Recording that the FileModifiedAfterBoot evidence was found for %q", f)
+	f.runtime.evidenceFound.FileModifiedAfterBoot = true
+}
+
+// SetFoundEvidenceFileOlderThan records that the FileOlderThan reboot
+// evidence was found.
+func (f *File) SetFoundEvidenceFileOlderThan() {
+	logger.Printf("Recording that the FileOlderThan evidence was found for %q", f)
+	f.runtime.evidenceFound.FileOlderThan = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (f *File) HasEvidence() bool {
+	if f.runtime.evidenceFound.FileExists {
+		return true
+	}
+	if f.runtime.evidenceFound.FileEmpty {
+		return true
+	}
+	if f.runtime.evidenceFound.FileNotEmpty {
+		return true
+	}
+	if f.runtime.evidenceFound.FileExecutable {
+		return true
+	}
+	if f.runtime.evidenceFound.FileIsSymlink {
+		return true
+	}
+	if f.runtime.evidenceFound.FileModifiedAfterBoot {
+		return true
+	}
+	if f.runtime.evidenceFound.FileOlderThan {
+		return true
+	}
+
+	return false
+}
+
+// Ignored indicates whether the File has been marked as ignored.
+func (f *File) Ignored() bool {
+
+	numMatchedPaths := len(f.runtime.pathsMatched)
+
+	// logger.Printf("%d pathsMatched entries for %q", numMatchedPaths, k)
+
+	// An empty collection of entries can occur if an error occurred or if no
+	// assertions were matched.
+	if numMatchedPaths == 0 {
+		// logger.Printf("%d pathsMatched entries for %q", numMatchedPaths, f)
+		return false
+	}
+
+	for _, v := range f.runtime.pathsMatched {
+		if !v.ignored {
+			return false
+		}
+
+		logger.Printf("%s is marked as ignored\n", v)
+	}
+
+	// The entire File is ignored *only* if all recorded match path entries
+	// are marked as ignored.
+	return true
+}
+
+// HasIgnored indicates whether any matched path for the File have been marked
+// as ignored.
+func (f *File) HasIgnored() bool {
+	for _, v := range f.runtime.pathsMatched {
+		if v.ignored {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the File has been marked as ignored (all recorded matched paths
+// marked as ignored) the need for a reboot is not indicated.
+func (f *File) RebootRequired() bool {
+	if !f.Ignored() && f.HasEvidence() {
+		return true
+	}
+
+	return false
+}
+
+// IsCriticalState indicates whether an evaluation determined that the File is
+// in a CRITICAL state. Whether the File has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (f *File) IsCriticalState() bool {
+	switch {
+
+	// If we could determine that a reboot is required we consider that to be
+	// a WARNING state.
+	case !f.Ignored() && f.RebootRequired():
+		return false
+
+	// If we were unable to determine whether a reboot is required due to
+	// errors we consider that to be a CRITICAL state.
+	case !f.Ignored() && f.Err() != nil:
+		if errors.Is(f.Err(), restart.ErrMissingOptionalItem) {
+			return false
+		}
+		return true
+
+	// No reboot required and no errors, not CRITICAL state.
+	default:
+		return false
+
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the File is
+// in a WARNING state. Whether the File has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (f *File) IsWarningState() bool {
+	return !f.Ignored() && f.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the File is in an
+// OK state. Whether the File has been marked as Ignored is considered. The
+// caller is responsible for filtering the collection prior to calling this
+// method.
+//
+// TODO: Cleanup the logic.
+func (f *File) IsOKState() bool {
+	switch {
+	case f.Ignored():
+		return true
+	case !f.Ignored() && f.RebootRequired():
+		return false
+	case !f.Ignored() && f.Err() != nil:
+		if errors.Is(f.Err(), restart.ErrMissingOptionalItem) {
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (f *File) RebootReasons() []string {
+	// The usual scenario is one reason per evidence match.
+	reasons := make([]string, 0, 1)
+
+	if f.runtime.evidenceFound.FileExists {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s found", f,
+		))
+	}
+
+	if f.runtime.evidenceFound.FileEmpty {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s empty (but should not be)", f,
+		))
+	}
+
+	if f.runtime.evidenceFound.FileNotEmpty {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s not empty (but expected to be)", f,
+		))
+	}
+
+	if f.runtime.evidenceFound.FileExecutable {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s executable (but should not be)", f,
+		))
+	}
+
+	if f.runtime.evidenceFound.FileIsSymlink {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s is a symbolic link (but should not be)", f,
+		))
+	}
+
+	if f.runtime.evidenceFound.FileModifiedAfterBoot {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s modified %s, after last boot %s",
+			f,
+			f.runtime.modTime.Format(time.RFC3339),
+			f.runtime.bootTime.Format(time.RFC3339),
+		))
+	}
+
+	if f.runtime.evidenceFound.FileOlderThan {
+		reasons = append(reasons, fmt.Sprintf(
+			"File %s modified %s, more than %d days before %s",
+			f,
+			f.runtime.modTime.Format(time.RFC3339),
+			f.olderThanDays,
+			time.Now().Format(time.RFC3339),
+		))
+	}
+
+	for _, entry := range f.runtime.reasonsFound {
+		reasons = append(reasons, fmt.Sprintf(
+			"Reboot requested by %s (listed in %s)", entry, f.reasonsPath,
+		))
+	}
+
+	return reasons
+}
+
+// Root returns the left-most element of a matched path. This returned value
+// is the beginning of a qualified path.
+func (mp MatchedPath) Root() string {
+	return mp.root
+}
+
+// Rel returns the relative (unqualified) element of a matched path. The base
+// element of the path is usually included in this element.
+func (mp MatchedPath) Rel() string {
+	return mp.relative
+}
+
+// Base returns the last or right-most "leaf" element of a matched path.
+func (mp MatchedPath) Base() string {
+	return mp.base
+}
+
+// Full returns the qualified matched path value.
+func (mp MatchedPath) Full() string {
+	return filepath.Join(mp.root, mp.relative)
+}
+
+// String provides a human readable version of the matched path value.
+func (mp MatchedPath) String() string {
+	return mp.Full()
+}
+
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
+
+// func matchedPathsFromPathStrings(rootPath string, pathStrings []string) restart.MatchedPaths {
+//
+// 	matchedPaths := make(restart.MatchedPaths, 0, len(pathStrings))
+//
+// 	for _, path := range pathStrings {
+// 		relPath, err := filepath.Rel(rootPath, path)
+// 		switch {
+// 		case err != nil:
+// 			logger.Printf("Failed to obtain relative path for %q using %q as the base", path, rootPath)
+// 			logger.Printf("Falling back to using %q as relative path", path)
+// 			relPath = path
+// 		default:
+// 			logger.Printf(
+// 				"Successfully resolved %q as relative path of %q using %q as root path",
+// 				relPath,
+// 				path,
+// 				rootPath,
+// 			)
+// 		}
+//
+// 		matchedPath := MatchedPath{
+// 			root:     rootPath,
+// 			relative: relPath,
+// 			base:     filepath.Base(path),
+// 		}
+//
+// 		matchedPaths = append(matchedPaths, matchedPath)
+// 	}
+//
+// 	return matchedPaths
+//
+// }
+//
+// func matchedPathFromPathString(rootPath string, pathString string) restart.MatchedPath {
+//
+// 	relPath, err := filepath.Rel(rootPath, pathString)
+// 	switch {
+// 	case err != nil:
+// 		logger.Printf("Failed to obtain relative path for %q using %q as the base", pathString, rootPath)
+// 		logger.Printf("Falling back to using %q as relative path", pathString)
+// 		relPath = pathString
+// 	default:
+// 		logger.Printf(
+// 			"Successfully resolved %q as relative path of %q using %q as root path",
+// 			relPath,
+// 			pathString,
+// 			rootPath,
+// 		)
+// 	}
+//
+// 	matchedPath := MatchedPath{
+// 		root:     rootPath,
+// 		relative: relPath,
+// 		base:     filepath.Base(pathString),
+// 	}
+//
+// 	return matchedPath
+// }
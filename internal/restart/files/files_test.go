@@ -0,0 +1,409 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// TestFileEvaluate asserts that each expected evidence marker is evaluated
+// against the file properties.
+func TestFileEvaluate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	emptyFile := filepath.Join(dir, "reboot-required")
+	notEmptyFile := filepath.Join(dir, "reboot-required.pkgs")
+	// The extension allows the file to be considered executable on Windows.
+	executableFile := filepath.Join(dir, "reboot-hook.exe")
+	symlink := filepath.Join(dir, "reboot-required.link")
+	missingFile := filepath.Join(dir, "missing")
+
+	if err := os.WriteFile(emptyFile, nil, 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(notEmptyFile, []byte("linux-base\n"), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(executableFile, []byte("#!/bin/sh\n"), 0o700); err != nil { // nolint:gosec
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	symlinkSupported := os.Symlink(notEmptyFile, symlink) == nil
+
+	tests := map[string]struct {
+		path           string
+		evidence       FileRebootEvidence
+		requirements   FileAssertions
+		needsSymlink   bool
+		wantReboot     bool
+		wantErr        error
+		wantMatchCount int
+	}{
+		"exists": {
+			path:           emptyFile,
+			evidence:       FileRebootEvidence{FileExists: true},
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"missing optional file": {
+			path:     missingFile,
+			evidence: FileRebootEvidence{FileExists: true},
+		},
+		"missing required file": {
+			path:         missingFile,
+			evidence:     FileRebootEvidence{FileExists: true},
+			requirements: FileAssertions{FileRequired: true},
+			wantErr:      restart.ErrMissingRequiredItem,
+		},
+		"empty file is empty": {
+			path:           emptyFile,
+			evidence:       FileRebootEvidence{FileEmpty: true},
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"non-empty file is not empty": {
+			path:     notEmptyFile,
+			evidence: FileRebootEvidence{FileEmpty: true},
+		},
+		"non-empty file has content": {
+			path:           notEmptyFile,
+			evidence:       FileRebootEvidence{FileNotEmpty: true},
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"empty file has no content": {
+			path:     emptyFile,
+			evidence: FileRebootEvidence{FileNotEmpty: true},
+		},
+		"executable file": {
+			path:           executableFile,
+			evidence:       FileRebootEvidence{FileExecutable: true},
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"regular file is not executable": {
+			path:     notEmptyFile,
+			evidence: FileRebootEvidence{FileExecutable: true},
+		},
+		"symlink": {
+			path:           symlink,
+			evidence:       FileRebootEvidence{FileIsSymlink: true},
+			needsSymlink:   true,
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"symlink target content": {
+			path:           symlink,
+			evidence:       FileRebootEvidence{FileNotEmpty: true},
+			needsSymlink:   true,
+			wantReboot:     true,
+			wantMatchCount: 1,
+		},
+		"regular file is not a symlink": {
+			path:     notEmptyFile,
+			evidence: FileRebootEvidence{FileIsSymlink: true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.needsSymlink && !symlinkSupported {
+				t.Skip("creating symbolic links is not supported")
+			}
+
+			f := NewFile(tt.path, "", "", tt.evidence, tt.requirements)
+			if err := f.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate file assertion: %v", err)
+			}
+
+			f.Evaluate()
+
+			if !errors.Is(f.Err(), tt.wantErr) {
+				t.Errorf("ERROR: got error %v; want %v", f.Err(), tt.wantErr)
+			}
+
+			if got := f.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got RebootRequired() %t; want %t", got, tt.wantReboot)
+			}
+
+			if got := len(f.MatchedPaths()); got != tt.wantMatchCount {
+				t.Errorf("ERROR: got %d matched paths; want %d", got, tt.wantMatchCount)
+			}
+		})
+	}
+}
+
+// TestFileReasonsFile asserts that the entries listed in the reasons file
+// (e.g., /var/run/reboot-required.pkgs) are included once each in the reboot
+// reasons and that a missing reasons file is not an error.
+func TestFileReasonsFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	sentinel := filepath.Join(dir, "reboot-required")
+	if err := os.WriteFile(sentinel, []byte("*** System restart required ***\n"), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	pkgs := filepath.Join(dir, "reboot-required.pkgs")
+	content := "linux-image-6.1.0-21-amd64\n\n  libc6  \nlinux-image-6.1.0-21-amd64\n"
+	if err := os.WriteFile(pkgs, []byte(content), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	tests := map[string]struct {
+		reasonsPath string
+		want        []string
+	}{
+		"packages listed": {
+			reasonsPath: pkgs,
+			want: []string{
+				"File " + sentinel + " found",
+				"Reboot requested by linux-image-6.1.0-21-amd64 (listed in " + pkgs + ")",
+				"Reboot requested by libc6 (listed in " + pkgs + ")",
+			},
+		},
+		"missing reasons file": {
+			reasonsPath: filepath.Join(dir, "missing.pkgs"),
+			want:        []string{"File " + sentinel + " found"},
+		},
+		"no reasons file": {
+			want: []string{"File " + sentinel + " found"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f := NewFile(sentinel, "", tt.reasonsPath, FileRebootEvidence{FileExists: true}, FileAssertions{})
+			f.Evaluate()
+
+			if f.Err() != nil {
+				t.Fatalf("ERROR: unexpected error: %v", f.Err())
+			}
+
+			if !f.RebootRequired() {
+				t.Fatal("ERROR: expected reboot to be required")
+			}
+
+			if got := f.RebootReasons(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ERROR: got reasons %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileValidate asserts that file assertions without evidence or with
+// conflicting evidence are rejected.
+func TestFileValidate(t *testing.T) {
+	t.Parallel()
+
+	f := NewFile("/var/run/reboot-required", "", "", FileRebootEvidence{}, FileAssertions{})
+	if err := f.Validate(); !errors.Is(err, restart.ErrUnknownRebootEvidence) {
+		t.Errorf("ERROR: expected ErrUnknownRebootEvidence; got %v", err)
+	}
+
+	f = NewFile(
+		"/var/run/reboot-required",
+		"",
+		"",
+		FileRebootEvidence{FileEmpty: true, FileNotEmpty: true},
+		FileAssertions{},
+	)
+	if err := f.Validate(); !errors.Is(err, restart.ErrInvalidRebootEvidence) {
+		t.Errorf("ERROR: expected ErrInvalidRebootEvidence; got %v", err)
+	}
+}
+
+// TestFileEvaluateTimestamps asserts that the modification time of a file is
+// evaluated against the boot time and the specified number of days.
+func TestFileEvaluateTimestamps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	now := time.Now()
+	bootTime := now.Add(-48 * time.Hour)
+
+	recentFile := filepath.Join(dir, "initrd.img")
+	oldFile := filepath.Join(dir, "patch-marker")
+
+	for path, modTime := range map[string]time.Time{
+		recentFile: now.Add(-time.Hour),
+		oldFile:    now.AddDate(0, 0, -10),
+	} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("ERROR: failed to set modification time: %v", err)
+		}
+	}
+
+	tests := map[string]struct {
+		path          string
+		evidence      FileRebootEvidence
+		olderThanDays int
+		bootTimeErr   error
+		wantReboot    bool
+		wantErr       bool
+		wantReason    string
+	}{
+		"modified after boot": {
+			path:       recentFile,
+			evidence:   FileRebootEvidence{FileModifiedAfterBoot: true},
+			wantReboot: true,
+			wantReason: "after last boot " + bootTime.Format(time.RFC3339),
+		},
+		"modified before boot": {
+			path:     oldFile,
+			evidence: FileRebootEvidence{FileModifiedAfterBoot: true},
+		},
+		"boot time unavailable": {
+			path:        recentFile,
+			evidence:    FileRebootEvidence{FileModifiedAfterBoot: true},
+			bootTimeErr: errors.New("boot time unavailable"),
+			wantErr:     true,
+		},
+		"older than days": {
+			path:          oldFile,
+			evidence:      FileRebootEvidence{FileOlderThan: true},
+			olderThanDays: 7,
+			wantReboot:    true,
+			wantReason:    "more than 7 days before",
+		},
+		"not older than days": {
+			path:          recentFile,
+			evidence:      FileRebootEvidence{FileOlderThan: true},
+			olderThanDays: 7,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f := NewFile(tt.path, "", "", tt.evidence, FileAssertions{})
+			f.SetOlderThanDays(tt.olderThanDays)
+			f.SetBootTimeProvider(func() (time.Time, error) {
+				return bootTime, tt.bootTimeErr
+			})
+
+			if err := f.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate file assertion: %v", err)
+			}
+
+			f.Evaluate()
+
+			if got := f.Err() != nil; got != tt.wantErr {
+				t.Errorf("ERROR: got error %v; want error %t", f.Err(), tt.wantErr)
+			}
+
+			if got := f.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got RebootRequired() %t; want %t", got, tt.wantReboot)
+			}
+
+			if tt.wantReason == "" {
+				return
+			}
+
+			reasons := f.RebootReasons()
+			modTime := f.runtime.modTime.Format(time.RFC3339)
+			if len(reasons) != 1 || !strings.Contains(reasons[0], tt.wantReason) || !strings.Contains(reasons[0], modTime) {
+				t.Errorf("ERROR: got reasons %q; want reason with %q and %q", reasons, modTime, tt.wantReason)
+			}
+		})
+	}
+
+	f := NewFile(oldFile, "", "", FileRebootEvidence{FileOlderThan: true}, FileAssertions{})
+	if err := f.Validate(); !errors.Is(err, restart.ErrInvalidRebootEvidence) {
+		t.Errorf("ERROR: expected ErrInvalidRebootEvidence without number of days; got %v", err)
+	}
+}
+
+// TestFileExpandedPath asserts that environment variable references within
+// a path are expanded and that unresolvable references fail validation.
+func TestFileExpandedPath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "reboot-required"), nil, 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	lookupEnv := func(key string) (string, bool) {
+		if key == "STATE_DIR" {
+			return dir, true
+		}
+		return "", false
+	}
+
+	for _, path := range []string{
+		"$STATE_DIR/reboot-required",
+		"${STATE_DIR}/reboot-required",
+		`%STATE_DIR%\reboot-required`,
+	} {
+		f := NewFile(path, "", "", FileRebootEvidence{FileExists: true}, FileAssertions{})
+		f.SetEnvLookup(lookupEnv)
+
+		if err := f.Validate(); err != nil {
+			t.Errorf("ERROR: failed to validate %q: %v", path, err)
+			continue
+		}
+
+		if got, want := f.String(), filepath.Join(dir, "reboot-required"); got != want {
+			t.Errorf("ERROR: got %q for %q; want %q", got, path, want)
+		}
+
+		f.Evaluate()
+		if !f.RebootRequired() {
+			t.Errorf("ERROR: expected reboot to be required for %q", path)
+		}
+	}
+
+	// A literal $ within a path relative to a Windows environment variable
+	// is not a variable reference.
+	if err := os.WriteFile(filepath.Join(dir, "$Recycle.Bin"), nil, 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create test file: %v", err)
+	}
+
+	f := NewFile("$Recycle.Bin", "STATE_DIR", "", FileRebootEvidence{FileExists: true}, FileAssertions{})
+	f.SetEnvLookup(lookupEnv)
+
+	if err := f.Validate(); err != nil {
+		t.Errorf("ERROR: failed to validate %q: %v", f, err)
+	}
+
+	if got, want := f.String(), filepath.Join(dir, "$Recycle.Bin"); got != want {
+		t.Errorf("ERROR: got %q; want %q", got, want)
+	}
+
+	for _, f := range []*File{
+		NewFile("$MISSING_DIR/reboot-required", "", "", FileRebootEvidence{FileExists: true}, FileAssertions{}),
+		NewFile(`WinSxS\pending.xml`, "MISSING_ROOT", "", FileRebootEvidence{FileExists: true}, FileAssertions{}),
+	} {
+		f.SetEnvLookup(lookupEnv)
+		if err := f.Validate(); !errors.Is(err, restart.ErrUnresolvedVariable) {
+			t.Errorf("ERROR: expected ErrUnresolvedVariable for %q; got %v", f, err)
+		}
+	}
+}
@@ -8,3 +8,13 @@
 // full license information.
 
 package files
+
+import (
+	"os"
+)
+
+// isExecutable indicates whether the file is executable by anyone as
+// indicated by the permission bits of the file.
+func isExecutable(_ string, info os.FileInfo) bool {
+	return info.Mode().Perm()&0o111 != 0
+}
@@ -0,0 +1,436 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithSubPaths implementation isn't correct.
+var _ restart.RebootRequiredAsserterWithSubPaths = (*FileGlob)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*FileGlob)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*FileGlob)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.FileRebootRequired implementation isn't correct.
+var _ FileRebootRequired = (*FileGlob)(nil)
+
+// FileGlobRebootEvidence indicates what evidence is required for a FileGlob
+// in order to determine that a reboot is needed.
+type FileGlobRebootEvidence struct {
+
+	// MatchesFound is an evidence "marker" that if satisfied indicates the
+	// need for a reboot. This marker is satisfied if at least the minimum
+	// number of entries match the directory or glob pattern.
+	MatchesFound bool
+}
+
+// FileGlobRuntime is a collection of values that are set during evaluation.
+// Unlike static values that are known ahead of time, these values are not
+// known until execution or runtime.
+type FileGlobRuntime struct {
+	// numMatches is the number of entries matching the directory or glob
+	// pattern (and maximum age if specified).
+	numMatches int
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound FileGlobRebootEvidence
+}
+
+// FileGlob represents a directory or glob pattern whose matching entries (if
+// found and requirements met) indicate a reboot is needed. Each matching
+// entry is recorded as a matched path.
+//
+// If the path is a directory each entry within the directory is evaluated.
+// Otherwise the path is evaluated as a glob pattern (e.g.,
+// /var/lib/update-notifier/*.pending). If required, the directory (or the
+// directory containing the glob pattern) must exist.
+type FileGlob struct {
+	File
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime FileGlobRuntime
+
+	// minCount is the minimum number of matching entries required to
+	// indicate a reboot is needed. If not specified, a single matching entry
+	// is sufficient.
+	minCount int
+
+	// maxAge if specified is the maximum age of a matching entry. Entries
+	// last modified before this are not considered.
+	maxAge time.Duration
+
+	// additionalEvidence indicates what evidence is used to determine that a
+	// reboot is needed.
+	additionalEvidence FileGlobRebootEvidence
+}
+
+// NewFileGlob creates a FileGlob assertion for the directory or glob pattern
+// specified as the path of the given File. If minCount is specified at least
+// that many matching entries are required. If maxAge is specified entries
+// last modified before that are not considered.
+func NewFileGlob(file *File, minCount int, maxAge time.Duration, additionalEvidence FileGlobRebootEvidence) *FileGlob {
+	return &FileGlob{
+		File:               *file,
+		minCount:           minCount,
+		maxAge:             maxAge,
+		additionalEvidence: additionalEvidence,
+	}
+}
+
+// MinCount returns the minimum number of matching entries required to
+// indicate a reboot is needed.
+func (fg *FileGlob) MinCount() int {
+	if fg.minCount == 0 {
+		return 1
+	}
+
+	return fg.minCount
+}
+
+// MaxAge returns the maximum age of a matching entry. Zero indicates that
+// the age of an entry is not considered.
+func (fg *FileGlob) MaxAge() time.Duration {
+	return fg.maxAge
+}
+
+// NumMatches returns the number of matching entries found during
+// evaluation.
+func (fg *FileGlob) NumMatches() int {
+	return fg.runtime.numMatches
+}
+
+// AdditionalEvidence indicates what evidence "markers" have been supplied.
+func (fg *FileGlob) AdditionalEvidence() FileGlobRebootEvidence {
+	return fg.additionalEvidence
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (fg *FileGlob) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(fg.File.evidenceExpected, fg.additionalEvidence)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (fg *FileGlob) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(fg.File.runtime.evidenceFound, fg.runtime.evidenceFound)
+}
+
+// Validate performs basic validation. An error is returned for any
+// validation failures.
+func (fg *FileGlob) Validate() error {
+
+	if err := fg.validatePath(); err != nil {
+		return err
+	}
+
+	if _, err := filepath.Match(fg.path, ""); err != nil {
+		return fmt.Errorf("invalid glob pattern %q: %w", fg.path, err)
+	}
+
+	if !fg.additionalEvidence.MatchesFound {
+		return fmt.Errorf(
+			"file glob evidence not specified: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	if fg.minCount < 0 {
+		return fmt.Errorf(
+			"invalid minimum count %d: %w",
+			fg.minCount,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	if fg.maxAge < 0 {
+		return fmt.Errorf(
+			"invalid maximum age %s: %w",
+			fg.maxAge,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	return nil
+}
+
+// Evaluate applies the specified assertion to determine if a reboot is
+// necessary.
+func (fg *FileGlob) Evaluate() {
+	logger.Printf("Given file glob: %s", fg)
+
+	pattern := filepath.Clean(fg.String())
+	isGlob := hasGlobMeta(pattern)
+
+	// The directory (or the directory containing the glob pattern) is
+	// evaluated to determine whether requirements are met.
+	baseDir := fg.baseDir()
+
+	info, err := os.Stat(baseDir)
+	switch {
+	case os.IsNotExist(err):
+		if fg.requirements.FileRequired {
+			logger.Printf("Directory %s not found, but marked as required.", baseDir)
+
+			fg.File.runtime.err = fmt.Errorf(
+				"directory %s not found, but marked as required: %w",
+				baseDir,
+				restart.ErrMissingRequiredItem,
+			)
+
+			return
+		}
+
+		logger.Printf("Directory %s not found, reboot not required due to this file glob.", baseDir)
+
+		return
+
+	case err != nil:
+		fg.File.runtime.err = err
+
+		return
+	}
+
+	var candidates []string
+	switch {
+	case !isGlob && info.IsDir():
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			fg.File.runtime.err = fmt.Errorf(
+				"failed to read directory %s: %w",
+				pattern,
+				err,
+			)
+
+			return
+		}
+
+		candidates = make([]string, 0, len(entries))
+		for _, entry := range entries {
+			candidates = append(candidates, filepath.Join(pattern, entry.Name()))
+		}
+
+	default:
+		candidates, err = filepath.Glob(pattern)
+		if err != nil {
+			fg.File.runtime.err = fmt.Errorf(
+				"invalid glob pattern %s: %w",
+				pattern,
+				err,
+			)
+
+			return
+		}
+	}
+
+	logger.Printf("%d candidate entries found for %q", len(candidates), pattern)
+
+	now := time.Now()
+	matches := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateInfo, err := os.Lstat(candidate)
+		if err != nil {
+			// The entry may have been removed since it was listed.
+			logger.Printf("Failed to evaluate %q: %v", candidate, err)
+			continue
+		}
+
+		if fg.maxAge > 0 && now.Sub(candidateInfo.ModTime()) > fg.maxAge {
+			logger.Printf(
+				"Skipping %q; last modified %s exceeds maximum age of %s",
+				candidate,
+				candidateInfo.ModTime().Format(time.RFC3339),
+				fg.maxAge,
+			)
+			continue
+		}
+
+		matches = append(matches, candidate)
+	}
+
+	fg.runtime.numMatches = len(matches)
+
+	if len(matches) < fg.MinCount() {
+		logger.Printf(
+			"%d matching entries found for %q; %d required, reboot not required due to this file glob.",
+			len(matches),
+			pattern,
+			fg.MinCount(),
+		)
+
+		return
+	}
+
+	logger.Println("Reboot Required!")
+	fg.SetFoundEvidenceMatchesFound()
+
+	logger.Printf("Recording %d matched paths for %s", len(matches), pattern)
+	fg.AddMatchedPath(matches...)
+
+	fg.evalReasonsFile()
+}
+
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed File) so that the FileGlob may be evaluated again.
+func (fg *FileGlob) Reset() {
+	fg.File.Reset()
+	fg.runtime = FileGlobRuntime{}
+}
+
+// baseDir returns the directory or, for a glob pattern, the directory
+// containing the glob pattern.
+func (fg *FileGlob) baseDir() string {
+	baseDir := filepath.Clean(fg.String())
+	for hasGlobMeta(baseDir) {
+		baseDir = filepath.Dir(baseDir)
+	}
+
+	return baseDir
+}
+
+// AddMatchedPath records given paths as successful assertion matches
+// relative to the directory (or the directory containing the glob pattern).
+// Duplicate entries are ignored.
+func (fg *FileGlob) AddMatchedPath(paths ...string) {
+	rootPath := fg.baseDir()
+	if qualifiedPath, err := filepath.Abs(rootPath); err == nil {
+		rootPath = qualifiedPath
+	}
+
+	fg.addMatchedPath(rootPath, paths...)
+}
+
+// SetFoundEvidenceMatchesFound records that the MatchesFound reboot evidence
+// was found.
+func (fg *FileGlob) SetFoundEvidenceMatchesFound() {
+	logger.Printf("Recording that the MatchesFound evidence was found for %q", fg)
+	fg.runtime.evidenceFound.MatchesFound = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (fg *FileGlob) HasEvidence() bool {
+	return fg.runtime.evidenceFound.MatchesFound
+}
+
+// HasSubPathMatches indicates whether the FileGlob has evidence of matching
+// entries.
+func (fg *FileGlob) HasSubPathMatches() bool {
+	return fg.runtime.evidenceFound.MatchesFound
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (fg *FileGlob) RebootReasons() []string {
+	reasons := make([]string, 0, 1)
+
+	if fg.runtime.evidenceFound.MatchesFound {
+		reasons = append(reasons, fmt.Sprintf(
+			"Found %d entries matching %s",
+			fg.runtime.numMatches,
+			fg,
+		))
+	}
+
+	// Include any entries retrieved from the reasons file.
+	reasons = append(reasons, fg.File.RebootReasons()...)
+
+	return reasons
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the FileGlob has been marked as ignored (all recorded matched
+// paths marked as ignored) the need for a reboot is not indicated.
+func (fg *FileGlob) RebootRequired() bool {
+	if !fg.Ignored() && fg.HasEvidence() {
+		return true
+	}
+
+	return false
+}
+
+// IsCriticalState indicates whether an evaluation determined that the
+// FileGlob is in a CRITICAL state. Whether the FileGlob has been marked as
+// Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (fg *FileGlob) IsCriticalState() bool {
+	switch {
+
+	// If we could determine that a reboot is required we consider that to be
+	// a WARNING state.
+	case !fg.Ignored() && fg.RebootRequired():
+		return false
+
+	// If we were unable to determine whether a reboot is required due to
+	// errors we consider that to be a CRITICAL state.
+	case !fg.Ignored() && fg.Err() != nil:
+		if errors.Is(fg.Err(), restart.ErrMissingOptionalItem) {
+			return false
+		}
+		return true
+
+	// No reboot required and no errors, not CRITICAL state.
+	default:
+		return false
+
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the
+// FileGlob is in a WARNING state. Whether the FileGlob has been marked as
+// Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (fg *FileGlob) IsWarningState() bool {
+	return !fg.Ignored() && fg.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the FileGlob is
+// in an OK state. Whether the FileGlob has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior
+// to calling this method.
+func (fg *FileGlob) IsOKState() bool {
+	switch {
+	case fg.Ignored():
+		return true
+	case fg.RebootRequired():
+		return false
+	case fg.Err() != nil:
+		return errors.Is(fg.Err(), restart.ErrMissingOptionalItem)
+	default:
+		return true
+	}
+}
+
+// hasGlobMeta indicates whether the given path contains any of the special
+// characters recognized by filepath.Match.
+func hasGlobMeta(path string) bool {
+	magicChars := `*?[`
+	if filepath.Separator != '\\' {
+		magicChars = `*?[\`
+	}
+
+	return strings.ContainsAny(path, magicChars)
+}
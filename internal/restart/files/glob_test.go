@@ -0,0 +1,204 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package files
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// TestFileGlobEvaluate asserts that entries matching a directory or glob
+// pattern are recorded as matched paths subject to the minimum count and
+// maximum age conditions.
+func TestFileGlobEvaluate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	pendingDir := filepath.Join(dir, "update-notifier")
+	if err := os.Mkdir(pendingDir, 0o700); err != nil {
+		t.Fatalf("ERROR: failed to create test directory: %v", err)
+	}
+
+	old := time.Now().Add(-72 * time.Hour)
+	for name, modTime := range map[string]time.Time{
+		"kernel.pending":  time.Now(),
+		"libc6.pending":   time.Now(),
+		"openssl.pending": old,
+		"notes.txt":       time.Now(),
+	} {
+		path := filepath.Join(pendingDir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("ERROR: failed to set modification time: %v", err)
+		}
+	}
+
+	glob := filepath.Join(pendingDir, "*.pending")
+	missingGlob := filepath.Join(dir, "missing", "*.pending")
+
+	tests := map[string]struct {
+		path         string
+		minCount     int
+		maxAge       time.Duration
+		requirements FileAssertions
+		wantReboot   bool
+		wantMatches  int
+		wantErr      error
+	}{
+		"glob": {
+			path: glob, wantReboot: true, wantMatches: 3,
+		},
+		"directory": {
+			path: pendingDir, wantReboot: true, wantMatches: 4,
+		},
+		"minimum count met": {
+			path: glob, minCount: 3, wantReboot: true, wantMatches: 3,
+		},
+		"minimum count not met": {
+			path: glob, minCount: 4, wantMatches: 0,
+		},
+		"maximum age": {
+			path: glob, maxAge: 24 * time.Hour, wantReboot: true, wantMatches: 2,
+		},
+		"maximum age and minimum count": {
+			path: glob, maxAge: 24 * time.Hour, minCount: 3, wantMatches: 0,
+		},
+		"no matches": {
+			path: filepath.Join(pendingDir, "*.reboot"), wantMatches: 0,
+		},
+		"missing optional directory": {
+			path: missingGlob, wantMatches: 0,
+		},
+		"missing required directory": {
+			path: missingGlob, requirements: FileAssertions{FileRequired: true},
+			wantErr: restart.ErrMissingRequiredItem,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fg := NewFileGlob(
+				NewFile(tt.path, "", "", FileRebootEvidence{}, tt.requirements),
+				tt.minCount,
+				tt.maxAge,
+				FileGlobRebootEvidence{MatchesFound: true},
+			)
+
+			if err := fg.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate file glob assertion: %v", err)
+			}
+
+			fg.Evaluate()
+
+			if !errors.Is(fg.Err(), tt.wantErr) {
+				t.Errorf("ERROR: got error %v; want %v", fg.Err(), tt.wantErr)
+			}
+
+			if got := fg.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got RebootRequired() %t; want %t", got, tt.wantReboot)
+			}
+
+			if got := fg.HasSubPathMatches(); got != tt.wantReboot {
+				t.Errorf("ERROR: got HasSubPathMatches() %t; want %t", got, tt.wantReboot)
+			}
+
+			matchedPaths := fg.MatchedPaths()
+			if got := len(matchedPaths); got != tt.wantMatches {
+				t.Errorf("ERROR: got %d matched paths; want %d", got, tt.wantMatches)
+			}
+
+			for _, matchedPath := range matchedPaths {
+				if matchedPath.Root() != pendingDir || matchedPath.Rel() != matchedPath.Base() {
+					t.Errorf(
+						"ERROR: got root %q and relative path %q for %q; want root %q",
+						matchedPath.Root(), matchedPath.Rel(), matchedPath, pendingDir,
+					)
+				}
+			}
+		})
+	}
+}
+
+// TestFileGlobFilter asserts that a FileGlob is only ignored if all matched
+// entries are ignored.
+func TestFileGlobFilter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"kernel.pending", "libc6.pending"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+	}
+
+	fg := NewFileGlob(
+		NewFile(filepath.Join(dir, "*.pending"), "", "", FileRebootEvidence{}, FileAssertions{}),
+		0,
+		0,
+		FileGlobRebootEvidence{MatchesFound: true},
+	)
+	fg.Evaluate()
+
+	fg.Filter(restart.IgnorePatterns{mustParseIgnorePattern(t, "file:glob:**/kernel.pending")})
+	if !fg.RebootRequired() || !fg.HasIgnored() {
+		t.Errorf("ERROR: expected reboot to be required with one of two entries ignored")
+	}
+
+	fg.Filter(restart.IgnorePatterns{mustParseIgnorePattern(t, "file:libc6")})
+	if fg.RebootRequired() || !fg.IsOKState() {
+		t.Errorf("ERROR: expected reboot to not be required with all entries ignored")
+	}
+}
+
+// TestFileGlobReset asserts that matched paths (and whether they have been
+// marked as ignored) are discarded when a FileGlob is reset so that matched
+// paths do not accumulate across evaluations.
+func TestFileGlobReset(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"kernel.pending", "libc6.pending"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+	}
+
+	fg := NewFileGlob(
+		NewFile(filepath.Join(dir, "*.pending"), "", "", FileRebootEvidence{}, FileAssertions{}),
+		0,
+		0,
+		FileGlobRebootEvidence{MatchesFound: true},
+	)
+	fg.Evaluate()
+	fg.Filter(restart.IgnorePatterns{mustParseIgnorePattern(t, "file:libc6")})
+
+	if err := os.Remove(filepath.Join(dir, "kernel.pending")); err != nil {
+		t.Fatalf("ERROR: failed to remove test file: %v", err)
+	}
+
+	fg.Reset()
+	fg.Evaluate()
+
+	if got := len(fg.MatchedPaths()); got != 1 {
+		t.Errorf("ERROR: got %d matched paths after reset; want 1", got)
+	}
+
+	if !fg.RebootRequired() || fg.HasIgnored() {
+		t.Errorf("ERROR: expected reboot to be required with no entries ignored after reset")
+	}
+}
@@ -0,0 +1,475 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/textutils"
+)
+
+// ErrInvalidIgnorePattern indicates that an invalid ignore pattern was
+// specified.
+var ErrInvalidIgnorePattern = errors.New("invalid ignore pattern")
+
+// IgnoreKind indicates how an ignore pattern is compared against matched
+// paths.
+type IgnoreKind string
+
+// Supported ignore pattern kinds.
+const (
+	// IgnoreKindSubstring indicates that a matched path is ignored if it
+	// contains the pattern. This is the default.
+	IgnoreKindSubstring IgnoreKind = "substring"
+
+	// IgnoreKindExact indicates that a matched path is ignored if it is
+	// equal to the pattern.
+	IgnoreKindExact IgnoreKind = "exact"
+
+	// IgnoreKindGlob indicates that a matched path is ignored if the whole
+	// path matches the glob pattern. A '*' matches any sequence of
+	// characters other than a path separator, '**' matches any sequence of
+	// characters and '?' matches any single character other than a path
+	// separator.
+	IgnoreKindGlob IgnoreKind = "glob"
+
+	// IgnoreKindRegex indicates that a matched path is ignored if the whole
+	// path matches the regular expression.
+	IgnoreKindRegex IgnoreKind = "regex"
+)
+
+// IgnoreTarget indicates what matched paths an ignore pattern applies to.
+type IgnoreTarget string
+
+// Supported ignore pattern targets.
+const (
+	// IgnoreTargetAll indicates that an ignore pattern applies to the
+	// matched paths of all assertions. This is the default.
+	IgnoreTargetAll IgnoreTarget = ""
+
+	// IgnoreTargetRegistry indicates that an ignore pattern applies only to
+	// the matched paths of registry assertions.
+	IgnoreTargetRegistry IgnoreTarget = "registry"
+
+	// IgnoreTargetFile indicates that an ignore pattern applies only to the
+	// matched paths of file assertions.
+	IgnoreTargetFile IgnoreTarget = "file"
+
+	// IgnoreTargetKernel indicates that an ignore pattern applies only to
+	// the matched paths of kernel assertions.
+	IgnoreTargetKernel IgnoreTarget = "kernel"
+
+	// IgnoreTargetProcess indicates that an ignore pattern applies only to
+	// the matched paths of process assertions.
+	IgnoreTargetProcess IgnoreTarget = "process"
+
+	// IgnoreTargetCommand indicates that an ignore pattern applies only to
+	// the matched paths of command assertions.
+	IgnoreTargetCommand IgnoreTarget = "command"
+
+	// IgnoreTargetUptime indicates that an ignore pattern applies only to
+	// the matched paths of uptime assertions.
+	IgnoreTargetUptime IgnoreTarget = "uptime"
+
+	// IgnoreTargetAssertion indicates that an ignore pattern applies only to
+	// the matched paths of a specific assertion.
+	IgnoreTargetAssertion IgnoreTarget = "assertion"
+)
+
+// ignoreTargetAssertionPrefix is the prefix used to specify an assertion
+// target in the string form of an ignore pattern. The assertion is
+// terminated by ignoreTargetAssertionSeparator.
+const (
+	ignoreTargetAssertionPrefix    string = "assertion="
+	ignoreTargetAssertionSeparator string = "|"
+)
+
+// IgnorePattern is a pattern used to mark matched paths as ignored.
+type IgnorePattern struct {
+	// Pattern is the value compared against matched paths.
+	Pattern string
+
+	// Kind indicates how the pattern is compared against matched paths.
+	Kind IgnoreKind
+
+	// Target indicates what matched paths the pattern applies to.
+	Target IgnoreTarget
+
+	// Assertion identifies the assertion (e.g.,
+	// HKEY_LOCAL_MACHINE\SOFTWARE\Example) the pattern applies to if Target
+	// is IgnoreTargetAssertion.
+	Assertion string
+
+	// Owner optionally identifies who is responsible for the ignore pattern.
+	Owner string
+
+	// Comment optionally records why the ignore pattern was added.
+	Comment string
+
+	// Expires is the (optional) time after which the ignore pattern no
+	// longer applies.
+	Expires time.Time
+
+	// re is the compiled form of glob and regex patterns.
+	re *regexp.Regexp
+}
+
+// IgnorePatterns is a collection of IgnorePattern values.
+type IgnorePatterns []IgnorePattern
+
+// NewIgnorePattern creates a validated IgnorePattern of the given kind and
+// target. An empty kind is treated as IgnoreKindSubstring.
+func NewIgnorePattern(pattern string, kind IgnoreKind, target IgnoreTarget, assertion string) (IgnorePattern, error) {
+	ip := IgnorePattern{
+		Pattern:   pattern,
+		Kind:      kind,
+		Target:    target,
+		Assertion: assertion,
+	}
+
+	if ip.Kind == "" {
+		ip.Kind = IgnoreKindSubstring
+	}
+
+	if pattern == "" {
+		return IgnorePattern{}, fmt.Errorf("empty pattern: %w", ErrInvalidIgnorePattern)
+	}
+
+	switch ip.Target {
+	case IgnoreTargetAssertion:
+		if assertion == "" {
+			return IgnorePattern{}, fmt.Errorf("missing assertion for target %s: %w", ip.Target, ErrInvalidIgnorePattern)
+		}
+
+	case IgnoreTargetAll, IgnoreTargetRegistry, IgnoreTargetFile,
+		IgnoreTargetKernel, IgnoreTargetProcess, IgnoreTargetCommand,
+		IgnoreTargetUptime:
+		if assertion != "" {
+			return IgnorePattern{}, fmt.Errorf("assertion specified for target %q: %w", ip.Target, ErrInvalidIgnorePattern)
+		}
+
+	default:
+		return IgnorePattern{}, fmt.Errorf(
+			"unsupported target %q; expected one of %v: %w",
+			ip.Target,
+			SupportedIgnoreTargets(),
+			ErrInvalidIgnorePattern,
+		)
+	}
+
+	switch ip.Kind {
+	case IgnoreKindSubstring, IgnoreKindExact:
+
+	case IgnoreKindGlob:
+		ip.re = regexp.MustCompile(globToRegex(pattern))
+
+	case IgnoreKindRegex:
+		re, err := regexp.Compile(`(?i)^(?:` + pattern + `)$`)
+		if err != nil {
+			return IgnorePattern{}, fmt.Errorf("%v: %w", err, ErrInvalidIgnorePattern)
+		}
+		ip.re = re
+
+	default:
+		return IgnorePattern{}, fmt.Errorf(
+			"unsupported kind %q; expected one of %v: %w",
+			ip.Kind,
+			SupportedIgnoreKinds(),
+			ErrInvalidIgnorePattern,
+		)
+	}
+
+	return ip, nil
+}
+
+// ParseIgnorePattern parses the string form of an ignore pattern:
+//
+//	[TARGET:][KIND:]PATTERN
+//
+// where TARGET is one of registry, file, kernel, process, command, uptime or
+// assertion=ASSERTION| and KIND is one of substring, exact, glob or regex.
+// If not specified the pattern applies to all assertions and is compared as
+// a substring of matched paths.
+func ParseIgnorePattern(s string) (IgnorePattern, error) {
+	var (
+		kind      IgnoreKind
+		target    IgnoreTarget
+		assertion string
+	)
+
+	rest := s
+
+	if strings.HasPrefix(rest, ignoreTargetAssertionPrefix) {
+		var found bool
+		assertion, rest, found = strings.Cut(
+			strings.TrimPrefix(rest, ignoreTargetAssertionPrefix),
+			ignoreTargetAssertionSeparator,
+		)
+		if !found {
+			return IgnorePattern{}, fmt.Errorf(
+				"%q: assertion target not terminated by %q: %w",
+				s,
+				ignoreTargetAssertionSeparator,
+				ErrInvalidIgnorePattern,
+			)
+		}
+		target = IgnoreTargetAssertion
+	}
+
+	if target == IgnoreTargetAll {
+		if prefix, remaining, found := strings.Cut(rest, ":"); found {
+			switch IgnoreTarget(prefix) {
+			case IgnoreTargetRegistry, IgnoreTargetFile, IgnoreTargetKernel,
+				IgnoreTargetProcess, IgnoreTargetCommand, IgnoreTargetUptime:
+				target = IgnoreTarget(prefix)
+				rest = remaining
+			}
+		}
+	}
+
+	if prefix, remaining, found := strings.Cut(rest, ":"); found {
+		switch IgnoreKind(prefix) {
+		case IgnoreKindSubstring, IgnoreKindExact, IgnoreKindGlob, IgnoreKindRegex:
+			kind = IgnoreKind(prefix)
+			rest = remaining
+		}
+	}
+
+	ip, err := NewIgnorePattern(rest, kind, target, assertion)
+	if err != nil {
+		return IgnorePattern{}, fmt.Errorf("%q: %w", s, err)
+	}
+
+	return ip, nil
+}
+
+// SubstringIgnorePatterns converts the given list of substring patterns
+// (e.g., the default ignored paths) to an IgnorePatterns collection which
+// applies to all assertions.
+func SubstringIgnorePatterns(patterns []string) IgnorePatterns {
+	ignorePatterns := make(IgnorePatterns, 0, len(patterns))
+	for _, pattern := range patterns {
+		ignorePatterns = append(ignorePatterns, IgnorePattern{
+			Pattern: pattern,
+			Kind:    IgnoreKindSubstring,
+		})
+	}
+
+	return ignorePatterns
+}
+
+// SupportedIgnoreKinds returns the list of supported ignore pattern kinds.
+func SupportedIgnoreKinds() []string {
+	return []string{
+		string(IgnoreKindSubstring),
+		string(IgnoreKindExact),
+		string(IgnoreKindGlob),
+		string(IgnoreKindRegex),
+	}
+}
+
+// SupportedIgnoreTargets returns the list of supported (non-default) ignore
+// pattern targets.
+func SupportedIgnoreTargets() []string {
+	return []string{
+		string(IgnoreTargetRegistry),
+		string(IgnoreTargetFile),
+		string(IgnoreTargetKernel),
+		string(IgnoreTargetProcess),
+		string(IgnoreTargetCommand),
+		string(IgnoreTargetUptime),
+		string(IgnoreTargetAssertion),
+	}
+}
+
+// String provides the string form of the ignore pattern as accepted by
+// ParseIgnorePattern.
+func (ip IgnorePattern) String() string {
+	var prefix string
+	switch ip.Target {
+	case IgnoreTargetAll:
+	case IgnoreTargetAssertion:
+		prefix = ignoreTargetAssertionPrefix + ip.Assertion + ignoreTargetAssertionSeparator
+	default:
+		prefix = string(ip.Target) + ":"
+	}
+
+	kind := ip.Kind
+	if kind == "" {
+		kind = IgnoreKindSubstring
+	}
+
+	return prefix + string(kind) + ":" + ip.Pattern
+}
+
+// Expired indicates whether the ignore pattern has an expiration time which
+// has passed as of the given time.
+func (ip IgnorePattern) Expired(now time.Time) bool {
+	return !ip.Expires.IsZero() && !now.Before(ip.Expires)
+}
+
+// Details provides the comment, owner and expiration time (if any) recorded
+// for the ignore pattern for display purposes.
+func (ip IgnorePattern) Details() string {
+	details := make([]string, 0, 3)
+
+	if ip.Comment != "" {
+		details = append(details, ip.Comment)
+	}
+
+	if ip.Owner != "" {
+		details = append(details, "owner: "+ip.Owner)
+	}
+
+	if !ip.Expires.IsZero() {
+		details = append(details, "expires: "+ip.Expires.Format(time.RFC3339))
+	}
+
+	return strings.Join(details, "; ")
+}
+
+// AppliesTo indicates whether the ignore pattern applies to matched paths
+// for an assertion of the given target type. An assertion may be identified
+// by more than one name (e.g., a registry key which is part of a key pair).
+func (ip IgnorePattern) AppliesTo(target IgnoreTarget, assertions ...string) bool {
+	switch ip.Target {
+	case IgnoreTargetAll:
+		return true
+
+	case IgnoreTargetAssertion:
+		for _, assertion := range assertions {
+			if normalizeIgnorePath(assertion) == normalizeIgnorePath(ip.Assertion) {
+				return true
+			}
+		}
+
+		return false
+
+	default:
+		return ip.Target == target
+	}
+}
+
+// MatchPath indicates whether the given matched path is matched by the
+// ignore pattern. All comparisons are case-insensitive.
+func (ip IgnorePattern) MatchPath(path string) bool {
+	switch ip.Kind {
+	case IgnoreKindExact:
+		return normalizeIgnorePath(path) == normalizeIgnorePath(ip.Pattern)
+
+	case IgnoreKindGlob:
+		if ip.re == nil {
+			return false
+		}
+
+		return ip.re.MatchString(normalizeIgnorePath(path))
+
+	case IgnoreKindRegex:
+		if ip.re == nil {
+			return false
+		}
+
+		return ip.re.MatchString(path)
+
+	default:
+		return strings.Contains(
+			textutils.NormalizePath(path),
+			textutils.NormalizePath(ip.Pattern),
+		)
+	}
+}
+
+// Match returns the first ignore pattern in the collection which applies to
+// the given target type and assertion and matches the given matched path.
+func (ips IgnorePatterns) Match(target IgnoreTarget, path string, assertions ...string) (IgnorePattern, bool) {
+	for _, ip := range ips {
+		if ip.AppliesTo(target, assertions...) && ip.MatchPath(path) {
+			return ip, true
+		}
+	}
+
+	return IgnorePattern{}, false
+}
+
+// MatchAny returns the first ignore pattern in the collection which applies to
+// the given target type and assertion and matches any of the given values
+// for a matched path (e.g., the full matched path along with the elements it
+// is composed of). Ignore patterns are considered in order so that the same
+// ignore pattern is returned regardless of the order of the given values.
+func (ips IgnorePatterns) MatchAny(target IgnoreTarget, paths []string, assertions ...string) (IgnorePattern, bool) {
+	for _, ip := range ips {
+		if !ip.AppliesTo(target, assertions...) {
+			continue
+		}
+
+		for _, path := range paths {
+			if ip.MatchPath(path) {
+				return ip, true
+			}
+		}
+	}
+
+	return IgnorePattern{}, false
+}
+
+// Partition splits the collection into the ignore patterns which apply as of
+// the given time and those which have expired.
+func (ips IgnorePatterns) Partition(now time.Time) (active IgnorePatterns, expired IgnorePatterns) {
+	for _, ip := range ips {
+		switch {
+		case ip.Expired(now):
+			expired = append(expired, ip)
+		default:
+			active = append(active, ip)
+		}
+	}
+
+	return active, expired
+}
+
+// normalizeIgnorePath normalizes a given path string by folding character
+// case and converting both Windows and Unix path separators to forward
+// slashes. This allows registry and file paths to be compared using the same
+// glob patterns on all platforms.
+func normalizeIgnorePath(path string) string {
+	return strings.ReplaceAll(strings.ToLower(path), `\`, "/")
+}
+
+// globToRegex converts a glob pattern to an anchored, case-insensitive
+// regular expression matching normalized paths.
+func globToRegex(pattern string) string {
+	pattern = normalizeIgnorePath(pattern)
+
+	var b strings.Builder
+	b.WriteString(`(?i)^`)
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				b.WriteString(`.*`)
+				i++
+				continue
+			}
+			b.WriteString(`[^/]*`)
+		case '?':
+			b.WriteString(`[^/]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString(`$`)
+
+	return b.String()
+}
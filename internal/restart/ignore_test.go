@@ -0,0 +1,278 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseIgnorePattern asserts that the target and kind prefixes of an
+// ignore pattern are parsed as expected.
+func TestParseIgnorePattern(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input         string
+		wantPattern   string
+		wantKind      IgnoreKind
+		wantTarget    IgnoreTarget
+		wantAssertion string
+	}{
+		"plain substring": {
+			input:       `SOFTWARE\Example`,
+			wantPattern: `SOFTWARE\Example`,
+			wantKind:    IgnoreKindSubstring,
+			wantTarget:  IgnoreTargetAll,
+		},
+		"drive letter is not a prefix": {
+			input:       `C:\Windows\WinSxS\pending.xml`,
+			wantPattern: `C:\Windows\WinSxS\pending.xml`,
+			wantKind:    IgnoreKindSubstring,
+			wantTarget:  IgnoreTargetAll,
+		},
+		"kind only": {
+			input:       `exact:/var/run/reboot-required`,
+			wantPattern: `/var/run/reboot-required`,
+			wantKind:    IgnoreKindExact,
+			wantTarget:  IgnoreTargetAll,
+		},
+		"target and kind": {
+			input:       `registry:glob:HKEY_LOCAL_MACHINE\SOFTWARE\*\Pending\*`,
+			wantPattern: `HKEY_LOCAL_MACHINE\SOFTWARE\*\Pending\*`,
+			wantKind:    IgnoreKindGlob,
+			wantTarget:  IgnoreTargetRegistry,
+		},
+		"assertion target": {
+			input:         `assertion=HKEY_LOCAL_MACHINE\SOFTWARE\Example|regex:.*\\[0-9a-f-]{36}`,
+			wantPattern:   `.*\\[0-9a-f-]{36}`,
+			wantKind:      IgnoreKindRegex,
+			wantTarget:    IgnoreTargetAssertion,
+			wantAssertion: `HKEY_LOCAL_MACHINE\SOFTWARE\Example`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ip, err := ParseIgnorePattern(tt.input)
+			if err != nil {
+				t.Fatalf("ERROR: failed to parse %q: %v", tt.input, err)
+			}
+
+			if ip.Pattern != tt.wantPattern || ip.Kind != tt.wantKind ||
+				ip.Target != tt.wantTarget || ip.Assertion != tt.wantAssertion {
+				t.Errorf(
+					"ERROR: got pattern %q, kind %q, target %q, assertion %q; want %q, %q, %q, %q",
+					ip.Pattern, ip.Kind, ip.Target, ip.Assertion,
+					tt.wantPattern, tt.wantKind, tt.wantTarget, tt.wantAssertion,
+				)
+			}
+
+			reparsed, err := ParseIgnorePattern(ip.String())
+			if err != nil || reparsed.Pattern != ip.Pattern || reparsed.Kind != ip.Kind {
+				t.Errorf("ERROR: failed to round trip %q via %q: %v", tt.input, ip.String(), err)
+			}
+		})
+	}
+}
+
+// TestParseIgnorePatternInvalid asserts that invalid ignore patterns are
+// rejected.
+func TestParseIgnorePatternInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{
+		"",
+		"glob:",
+		"regex:[unclosed",
+		"assertion=HKEY_LOCAL_MACHINE\\SOFTWARE\\Example",
+		"assertion=|exact:x",
+	} {
+		if _, err := ParseIgnorePattern(input); !errors.Is(err, ErrInvalidIgnorePattern) {
+			t.Errorf("ERROR: expected ErrInvalidIgnorePattern for %q; got %v", input, err)
+		}
+	}
+}
+
+// TestIgnorePatternsMatch asserts that each ignore pattern kind and target
+// matches the expected paths.
+func TestIgnorePatternsMatch(t *testing.T) {
+	t.Parallel()
+
+	const (
+		pendingKey   = `HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Services\Pending`
+		guidSubKey   = pendingKey + `\117cab2d-82b1-4b5a-a08c-4d62dbee7782`
+		namedSubKey  = pendingKey + `\117cab2d-legacy`
+		rebootFile   = `/var/run/reboot-required`
+		rebootPkgs   = `/var/run/reboot-required.pkgs`
+		otherKeyPath = `HKEY_LOCAL_MACHINE\SOFTWARE\Other`
+	)
+
+	tests := map[string]struct {
+		pattern    string
+		target     IgnoreTarget
+		assertion  string
+		path       string
+		wantIgnore bool
+	}{
+		"substring": {
+			pattern: "117cab2d", target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: namedSubKey, wantIgnore: true,
+		},
+		"exact matches case-insensitively": {
+			pattern: "exact:" + strings.ToLower(guidSubKey), target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: true,
+		},
+		"exact does not match prefix": {
+			pattern: "exact:" + rebootFile, target: IgnoreTargetFile, assertion: rebootFile,
+			path: rebootPkgs, wantIgnore: false,
+		},
+		"glob matches within path element": {
+			pattern: `glob:HKEY_LOCAL_MACHINE\SOFTWARE\*\Windows\CurrentVersion\WindowsUpdate\Services\Pending\117cab2d-*`,
+			target:  IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: true,
+		},
+		"glob star does not cross separators": {
+			pattern: `glob:HKEY_LOCAL_MACHINE\SOFTWARE\*\Pending\*`, target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: false,
+		},
+		"glob double star crosses separators": {
+			pattern: `glob:HKEY_LOCAL_MACHINE\SOFTWARE\**\Pending\*`, target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: true,
+		},
+		"regex is anchored": {
+			pattern: `regex:.*\\[0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12}`, target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: true,
+		},
+		"regex does not match partial path": {
+			pattern: `regex:.*\\[0-9a-f]{8}(-[0-9a-f]{4}){3}-[0-9a-f]{12}`, target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: namedSubKey, wantIgnore: false,
+		},
+		"registry target does not apply to files": {
+			pattern: "registry:reboot-required", target: IgnoreTargetFile, assertion: rebootFile,
+			path: rebootFile, wantIgnore: false,
+		},
+		"file target applies to files": {
+			pattern: "file:exact:" + rebootFile, target: IgnoreTargetFile, assertion: rebootFile,
+			path: rebootFile, wantIgnore: true,
+		},
+		"assertion target applies to named assertion": {
+			pattern: "assertion=" + strings.ToLower(pendingKey) + "|117cab2d", target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: true,
+		},
+		"assertion target does not apply to other assertions": {
+			pattern: "assertion=" + otherKeyPath + "|117cab2d", target: IgnoreTargetRegistry, assertion: pendingKey,
+			path: guidSubKey, wantIgnore: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ip, err := ParseIgnorePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("ERROR: failed to parse %q: %v", tt.pattern, err)
+			}
+
+			_, got := IgnorePatterns{ip}.Match(tt.target, tt.path, tt.assertion)
+			if got != tt.wantIgnore {
+				t.Errorf("ERROR: %q matching %q = %t; want %t", tt.pattern, tt.path, got, tt.wantIgnore)
+			}
+		})
+	}
+}
+
+// TestParseIgnoreFile asserts that ignore files are loaded and that invalid
+// entries are identified.
+func TestParseIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	const content = `{
+  "version": 1,
+  "ignore": [
+    {"pattern": "Services\\Pending"},
+    {"pattern": "/var/run/reboot-required", "kind": "exact", "target": "file"},
+    {"pattern": "*", "kind": "glob", "target": "assertion", "assertion": "HKEY_LOCAL_MACHINE\\SOFTWARE\\Example"}
+  ]
+}`
+
+	ignorePatterns, err := ParseIgnoreFile("ignore.json", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore file: %v", err)
+	}
+
+	if got, want := len(ignorePatterns), 3; got != want {
+		t.Fatalf("ERROR: got %d ignore patterns; want %d", got, want)
+	}
+
+	if got, want := ignorePatterns[0].Kind, IgnoreKindSubstring; got != want {
+		t.Errorf("ERROR: got kind %q for entry without kind; want %q", got, want)
+	}
+
+	const invalid = `{"version": 1, "ignore": [{"pattern": "x"}, {"pattern": "x", "target": "network"}]}`
+
+	_, err = ParseIgnoreFile("ignore.json", strings.NewReader(invalid))
+	switch {
+	case !errors.Is(err, ErrInvalidIgnorePattern):
+		t.Errorf("ERROR: expected ErrInvalidIgnorePattern; got %v", err)
+	case !strings.Contains(err.Error(), "ignore.json: ignore[1]"):
+		t.Errorf("ERROR: error %q does not reference the offending entry", err)
+	}
+}
+
+// TestIgnorePatternsPartition asserts that ignore file entries record their
+// owner and comment and that expired entries are separated from those which
+// still apply.
+func TestIgnorePatternsPartition(t *testing.T) {
+	t.Parallel()
+
+	const content = `{
+  "version": 1,
+  "ignore": [
+    {"pattern": "Pending", "owner": "ops", "comment": "agent leaves stale GUIDs (TICKET-123)", "expires": "2023-06-30"},
+    {"pattern": "RebootRequired", "expires": "2023-07-01T12:00:00Z"},
+    {"pattern": "reboot-required.pkgs"}
+  ]
+}`
+
+	ignorePatterns, err := ParseIgnoreFile("ignore.json", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore file: %v", err)
+	}
+
+	if got, want := ignorePatterns[0].Details(), "agent leaves stale GUIDs (TICKET-123); owner: ops"; !strings.HasPrefix(got, want) {
+		t.Errorf("ERROR: got details %q; want prefix %q", got, want)
+	}
+
+	// A date is applied through the end of that day.
+	lastDay := time.Date(2023, time.June, 30, 23, 0, 0, 0, time.Local)
+	if ignorePatterns[0].Expired(lastDay) {
+		t.Errorf("ERROR: entry expiring %s unexpectedly expired as of %s", ignorePatterns[0].Expires, lastDay)
+	}
+
+	now := time.Date(2023, time.July, 2, 0, 0, 0, 0, time.UTC)
+	active, expired := ignorePatterns.Partition(now)
+
+	if len(active) != 1 || active[0].Pattern != "reboot-required.pkgs" {
+		t.Errorf("ERROR: got active patterns %v; want only the entry without expiration", active)
+	}
+
+	if len(expired) != 2 {
+		t.Errorf("ERROR: got %d expired patterns; want 2", len(expired))
+	}
+
+	const invalid = `{"version": 1, "ignore": [{"pattern": "x", "expires": "next week"}]}`
+	if _, err := ParseIgnoreFile("ignore.json", strings.NewReader(invalid)); !errors.Is(err, ErrInvalidIgnorePattern) {
+		t.Errorf("ERROR: expected ErrInvalidIgnorePattern for invalid expiration; got %v", err)
+	}
+}
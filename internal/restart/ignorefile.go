@@ -0,0 +1,180 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// IgnoreFileVersion is the ignore file format version supported by this
+// package.
+const IgnoreFileVersion int = 1
+
+// ignoreFile is the top-level structure of an ignore file.
+type ignoreFile struct {
+	Version int           `json:"version"`
+	Ignore  []ignoreEntry `json:"ignore"`
+}
+
+// ignoreEntry is a single entry in an ignore file.
+type ignoreEntry struct {
+	// Pattern is the value compared against matched paths.
+	Pattern string `json:"pattern"`
+
+	// Kind is one of substring (the default), exact, glob or regex.
+	Kind string `json:"kind"`
+
+	// Target is one of registry, file, kernel, process, command, uptime or
+	// assertion. If not specified the entry applies to all assertions.
+	Target string `json:"target"`
+
+	// Assertion identifies the assertion the entry applies to if Target is
+	// assertion.
+	Assertion string `json:"assertion"`
+
+	// Owner optionally identifies who is responsible for the entry.
+	Owner string `json:"owner"`
+
+	// Comment optionally records why the entry was added.
+	Comment string `json:"comment"`
+
+	// Expires is the (optional) date (YYYY-MM-DD) or time (RFC 3339) after
+	// which the entry no longer applies. An entry with a date is applied
+	// through the end of that day (local time).
+	Expires string `json:"expires"`
+}
+
+// ignoreExpiresDateLayout is the layout for expiration dates in ignore
+// files.
+const ignoreExpiresDateLayout string = "2006-01-02"
+
+// parseIgnoreExpires parses the expiration date or time of an ignore file
+// entry.
+func parseIgnoreExpires(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.ParseInLocation(ignoreExpiresDateLayout, value, time.Local); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"invalid expires value %q; expected YYYY-MM-DD or RFC 3339 time: %w",
+			value,
+			ErrInvalidIgnorePattern,
+		)
+	}
+
+	return expires, nil
+}
+
+// LoadIgnoreFiles loads the ignore patterns from each of the given ignore
+// files.
+func LoadIgnoreFiles(filenames ...string) (IgnorePatterns, error) {
+	var ignorePatterns IgnorePatterns
+
+	for _, filename := range filenames {
+		loaded, err := LoadIgnoreFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		ignorePatterns = append(ignorePatterns, loaded...)
+	}
+
+	return ignorePatterns, nil
+}
+
+// LoadIgnoreFile loads the ignore patterns from the given ignore file.
+func LoadIgnoreFile(filename string) (IgnorePatterns, error) {
+	fh, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", filename, err)
+		}
+	}()
+
+	return ParseIgnoreFile(filename, fh)
+}
+
+// ParseIgnoreFile loads the ignore patterns from the content of an ignore
+// file. The given name is used to identify the source in error messages.
+//
+// An ignore file is a JSON document listing ignore entries:
+//
+//	{
+//	  "version": 1,
+//	  "ignore": [
+//	    {
+//	      "pattern": "HKEY_LOCAL_MACHINE\\SOFTWARE\\Example\\{*}",
+//	      "kind": "glob",
+//	      "target": "registry",
+//	      "owner": "ops",
+//	      "comment": "Stale update GUID left behind by agent (TICKET-123)",
+//	      "expires": "2023-06-30"
+//	    }
+//	  ]
+//	}
+func ParseIgnoreFile(name string, r io.Reader) (IgnorePatterns, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var content ignoreFile
+	if err := decoder.Decode(&content); err != nil {
+		return nil, fmt.Errorf("%s: %v: %w", name, err, ErrInvalidIgnorePattern)
+	}
+
+	if content.Version != IgnoreFileVersion {
+		return nil, fmt.Errorf(
+			"%s: unsupported version %d; expected %d: %w",
+			name,
+			content.Version,
+			IgnoreFileVersion,
+			ErrInvalidIgnorePattern,
+		)
+	}
+
+	ignorePatterns := make(IgnorePatterns, 0, len(content.Ignore))
+	for i, entry := range content.Ignore {
+		ip, err := NewIgnorePattern(
+			entry.Pattern,
+			IgnoreKind(entry.Kind),
+			IgnoreTarget(entry.Target),
+			entry.Assertion,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: ignore[%d]: %w", name, i, err)
+		}
+
+		ip.Owner = entry.Owner
+		ip.Comment = entry.Comment
+
+		ip.Expires, err = parseIgnoreExpires(entry.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("%s: ignore[%d]: %w", name, i, err)
+		}
+
+		ignorePatterns = append(ignorePatterns, ip)
+	}
+
+	logger.Printf("%d ignore patterns loaded from %s", len(ignorePatterns), name)
+
+	return ignorePatterns, nil
+}
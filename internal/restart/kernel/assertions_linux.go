@@ -0,0 +1,34 @@
+//go:build linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package kernel
+
+import (
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// DefaultRebootRequiredAssertions provides the default collection of kernel
+// related reboot required assertions.
+func DefaultRebootRequiredAssertions() restart.RebootRequiredAsserters {
+
+	var assertions = restart.RebootRequiredAsserters{
+
+		// Compare the running kernel against the newest kernel installed
+		// via the system package manager. A newer installed kernel is only
+		// used after a reboot.
+		&Kernel{
+			root: "/",
+			evidenceExpected: KernelRebootEvidence{
+				NewerKernelInstalled: true,
+			},
+		},
+	}
+
+	return assertions
+}
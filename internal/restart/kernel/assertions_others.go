@@ -0,0 +1,22 @@
+//go:build !linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package kernel
+
+import (
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// DefaultRebootRequiredAssertions provides the default collection of kernel
+// related reboot required assertions.
+func DefaultRebootRequiredAssertions() restart.RebootRequiredAsserters {
+	logger.Println("WARNING: Kernel mismatch assertions are only supported on Linux systems")
+
+	return restart.RebootRequiredAsserters{}
+}
@@ -0,0 +1,11 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package kernel provides functionality used to evaluate whether the running
+// Linux kernel differs from the newest installed kernel, indicating the need
+// for a system reboot.
+package kernel
@@ -0,0 +1,629 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package kernel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserter implementation isn't correct.
+var _ restart.RebootRequiredAsserter = (*Kernel)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithDataDisplay implementation isn't correct.
+var _ restart.RebootRequiredAsserterWithDataDisplay = (*Kernel)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var _ restart.EvaluationTimer = (*Kernel)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var _ restart.PendingTracker = (*Kernel)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*Kernel)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*Kernel)(nil)
+
+// Add "implements assertions" to fail the build if the restart.MatchedPath
+// implementation isn't correct.
+var _ restart.MatchedPath = (*MatchedPath)(nil)
+
+// Paths (relative to the filesystem root) used to determine the running and
+// installed kernel releases.
+const (
+	osReleasePath  string = "proc/sys/kernel/osrelease"
+	bootPath       string = "boot"
+	modulesPath    string = "lib/modules"
+	vmlinuzPrefix  string = "vmlinuz-"
+	modulesDepFile string = "modules.dep"
+	vmlinuzFile    string = "vmlinuz"
+)
+
+// rescueImageMarker is found in the filename of rescue kernel images
+// installed on RHEL and derivative systems (e.g.,
+// vmlinuz-0-rescue-<machine-id>). These images are never booted by default
+// and are excluded from evaluation.
+const rescueImageMarker string = "-rescue-"
+
+// ErrMissingRunningKernel indicates that the running kernel release could not
+// be determined.
+var ErrMissingRunningKernel = errors.New("unable to determine running kernel release")
+
+// KernelRebootEvidence indicates what kernel evidence is required in order to
+// determine that a reboot is needed.
+//
+//nolint:revive
+type KernelRebootEvidence struct {
+	// NewerKernelInstalled indicates that an installed kernel newer than the
+	// running kernel is sufficient evidence for a reboot.
+	NewerKernelInstalled bool
+}
+
+// KernelRuntime is a collection of values for a Kernel that are set during
+// evaluation. Unlike the static values set for a Kernel (e.g., filesystem
+// root, expected evidence), these values are not known until execution or
+// runtime.
+//
+//nolint:revive
+type KernelRuntime struct {
+	// err records any error that occurs while performing an evaluation.
+	err error
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound KernelRebootEvidence
+
+	// running is the release of the running kernel.
+	running string
+
+	// newest is the release of the newest installed kernel.
+	newest string
+
+	// pathsMatched is a collection of path values that were matched during
+	// evaluation of specified reboot required assertions.
+	pathsMatched MatchedPathIndex
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
+}
+
+// MatchedPathIndex is a collection of path values that were matched during
+// evaluation of specified reboot required assertions.
+type MatchedPathIndex map[string]MatchedPath
+
+// MatchedPath represents a path that was matched when performing an
+// evaluation of a "reboot required" assertion.
+type MatchedPath struct {
+	// root is the left-most element of a matched path. This is the beginning
+	// of a qualified path.
+	root string
+
+	// relative is the unqualified path (without the root element). The base
+	// element of the path is usually included in this element.
+	relative string
+
+	// base is the last element or the right-most "leaf" value of a matched
+	// path.
+	base string
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a reboot is needed.
+	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
+}
+
+// Kernel represents a comparison between the running kernel and the newest
+// installed kernel. If the newest installed kernel is newer than the running
+// kernel a reboot is needed.
+type Kernel struct {
+	// root is the filesystem root used to locate the running and installed
+	// kernel details. This is usually "/", but may be set to an alternate
+	// location for testing purposes.
+	root string
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime KernelRuntime
+
+	// evidenceExpected indicates what evidence is used to determine that a
+	// reboot is needed.
+	evidenceExpected KernelRebootEvidence
+}
+
+// installedKernel represents a kernel release found installed on the
+// filesystem along with the path used to detect it.
+type installedKernel struct {
+	release string
+	path    string
+}
+
+// Err exposes the underlying error (if any) as-is.
+func (k *Kernel) Err() error {
+	return k.runtime.err
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (k *Kernel) EvaluationDuration() time.Duration {
+	return k.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (k *Kernel) SetEvaluationDuration(duration time.Duration) {
+	k.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (k *Kernel) PendingSince() time.Time {
+	return k.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (k *Kernel) Escalated() bool {
+	return k.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (k *Kernel) SetPending(since time.Time, escalated bool) {
+	k.runtime.pendingSince = since
+	k.runtime.escalated = escalated
+}
+
+// Validate performs basic validation. An error is returned for any validation
+// failures.
+func (k *Kernel) Validate() error {
+	if k.root == "" {
+		return fmt.Errorf(
+			"invalid filesystem root: %w",
+			restart.ErrMissingValue,
+		)
+	}
+
+	if !k.evidenceExpected.NewerKernelInstalled {
+		return fmt.Errorf(
+			"value unexpected: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	return nil
+}
+
+// String provides the fully qualified path to the running kernel release
+// details.
+func (k *Kernel) String() string {
+	return filepath.Join(k.root, osReleasePath)
+}
+
+// Running returns the release of the running kernel recorded during an
+// earlier evaluation.
+func (k *Kernel) Running() string {
+	return k.runtime.running
+}
+
+// Newest returns the release of the newest installed kernel recorded during
+// an earlier evaluation.
+func (k *Kernel) Newest() string {
+	return k.runtime.newest
+}
+
+// DataDisplay provides a string representation of the running and newest
+// installed kernel releases for display purposes.
+func (k *Kernel) DataDisplay() string {
+	return fmt.Sprintf("running %s, installed %s", k.Running(), k.Newest())
+}
+
+// Evaluate applies the specified assertion to determine if a reboot is
+// necessary.
+func (k *Kernel) Evaluate() {
+	logger.Printf("Evaluating kernel releases using root %q", k.root)
+
+	running, err := k.runningRelease()
+	if err != nil {
+		k.runtime.err = err
+		return
+	}
+	k.runtime.running = running
+
+	logger.Printf("Running kernel release: %q", running)
+
+	installed, err := k.installedKernels()
+	if err != nil {
+		k.runtime.err = err
+		return
+	}
+
+	// Only consider installed kernels of the same flavor (e.g., amd64 vs
+	// cloud-amd64) as the running kernel to prevent comparing unrelated
+	// kernel packages.
+	runningFlavor := flavor(running)
+
+	var newest *installedKernel
+	for i := range installed {
+		if flavor(installed[i].release) != runningFlavor {
+			logger.Printf(
+				"Skipping installed kernel %q; flavor does not match running kernel flavor %q",
+				installed[i].release,
+				runningFlavor,
+			)
+			continue
+		}
+
+		if newest == nil || CompareVersions(installed[i].release, newest.release) > 0 {
+			newest = &installed[i]
+		}
+	}
+
+	if newest == nil {
+		logger.Printf("No installed kernels found under %q; unable to compare", k.root)
+		return
+	}
+
+	k.runtime.newest = newest.release
+
+	logger.Printf("Newest installed kernel release: %q (%s)", newest.release, newest.path)
+
+	if CompareVersions(newest.release, running) > 0 &&
+		k.evidenceExpected.NewerKernelInstalled {
+
+		logger.Println("Reboot Required!")
+
+		k.SetFoundEvidenceNewerKernelInstalled()
+		k.AddMatchedPath(newest.path)
+	}
+}
+
+// Reset discards the results of an earlier evaluation so that the Kernel may
+// be evaluated again.
+func (k *Kernel) Reset() {
+	k.runtime = KernelRuntime{}
+}
+
+// runningRelease retrieves the release of the running kernel.
+func (k *Kernel) runningRelease() (string, error) {
+	path := filepath.Join(k.root, osReleasePath)
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf(
+			"%w: failed to read %s: %w",
+			ErrMissingRunningKernel,
+			path,
+			err,
+		)
+	}
+
+	release := strings.TrimSpace(string(data))
+	if release == "" {
+		return "", fmt.Errorf(
+			"%w: %s is empty",
+			ErrMissingRunningKernel,
+			path,
+		)
+	}
+
+	return release, nil
+}
+
+// installedKernels retrieves the releases of all kernels installed under the
+// boot and modules paths. Entries found in both locations are listed once
+// using the boot image path. Missing boot or modules paths are not treated
+// as errors as some environments (e.g., containers) do not provide them.
+func (k *Kernel) installedKernels() ([]installedKernel, error) {
+	index := make(map[string]string)
+
+	bootDir := filepath.Join(k.root, bootPath)
+	bootImages, err := filepath.Glob(filepath.Join(bootDir, vmlinuzPrefix+"*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kernel images in %s: %w", bootDir, err)
+	}
+
+	for _, image := range bootImages {
+		release := strings.TrimPrefix(filepath.Base(image), vmlinuzPrefix)
+		if release == "" || strings.Contains(image, rescueImageMarker) {
+			logger.Printf("Skipping kernel image %q", image)
+			continue
+		}
+
+		index[release] = image
+	}
+
+	modulesDir := filepath.Join(k.root, modulesPath)
+	entries, err := os.ReadDir(modulesDir)
+	switch {
+	case os.IsNotExist(err):
+		logger.Printf("Modules path %q not found", modulesDir)
+
+	case err != nil:
+		return nil, fmt.Errorf("failed to list kernel modules in %s: %w", modulesDir, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		release := entry.Name()
+		if _, ok := index[release]; ok {
+			continue
+		}
+
+		// Directories left behind after removing a kernel package often
+		// contain only externally built modules. Only consider a modules
+		// directory to represent an installed kernel if it has the module
+		// dependencies file generated at install time or a kernel image.
+		releaseDir := filepath.Join(modulesDir, release)
+		if !exists(filepath.Join(releaseDir, modulesDepFile)) &&
+			!exists(filepath.Join(releaseDir, vmlinuzFile)) {
+			logger.Printf("Skipping incomplete modules directory %q", releaseDir)
+			continue
+		}
+
+		index[release] = releaseDir
+	}
+
+	installed := make([]installedKernel, 0, len(index))
+	for release, path := range index {
+		installed = append(installed, installedKernel{release: release, path: path})
+	}
+
+	sort.Slice(installed, func(i, j int) bool {
+		return CompareVersions(installed[i].release, installed[j].release) < 0
+	})
+
+	logger.Printf("%d installed kernels found", len(installed))
+
+	return installed, nil
+}
+
+// exists indicates whether the specified path exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// AddMatchedPath records given paths as successful assertion matches.
+// Duplicate entries are ignored.
+func (k *Kernel) AddMatchedPath(paths ...string) {
+	if k.runtime.pathsMatched == nil {
+		k.runtime.pathsMatched = make(MatchedPathIndex)
+	}
+
+	for _, path := range paths {
+		if _, ok := k.runtime.pathsMatched[path]; !ok {
+			rootPath := filepath.Dir(path)
+
+			k.runtime.pathsMatched[path] = MatchedPath{
+				root:     rootPath,
+				relative: filepath.Base(path),
+				base:     filepath.Base(path),
+			}
+		}
+	}
+}
+
+// MatchedPaths returns all recorded paths from successful assertion matches.
+func (k *Kernel) MatchedPaths() restart.MatchedPaths {
+	pathStrings := make([]string, 0, len(k.runtime.pathsMatched))
+	matchedPaths := make(restart.MatchedPaths, 0, len(k.runtime.pathsMatched))
+
+	for path := range k.runtime.pathsMatched {
+		pathStrings = append(pathStrings, path)
+	}
+
+	sort.Strings(pathStrings)
+
+	for _, path := range pathStrings {
+		matchedPaths = append(matchedPaths, k.runtime.pathsMatched[path])
+	}
+
+	return matchedPaths
+}
+
+// Filter uses the list of specified ignore patterns to mark each matched path
+// for the Kernel as ignored *IF* a match is found.
+//
+// If no matched paths are recorded Filter makes no changes. Filter should be
+// called before performing final state evaluation.
+func (k *Kernel) Filter(ignorePatterns restart.IgnorePatterns) {
+	if len(ignorePatterns) == 0 {
+		logger.Printf("0 ignore patterns specified for %q; skipping Filter", k)
+		return
+	}
+
+	for originalPathString, matchedPath := range k.runtime.pathsMatched {
+		if ignorePattern, ok := ignorePatterns.Match(restart.IgnoreTargetKernel, originalPathString, k.String()); ok {
+			logger.Printf("marking matched path %q as ignored", originalPathString)
+
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			k.runtime.pathsMatched[originalPathString] = matchedPath
+		}
+	}
+}
+
+// ExpectedEvidence returns the specified evidence that (if found) indicates a
+// reboot is needed.
+func (k *Kernel) ExpectedEvidence() KernelRebootEvidence {
+	return k.evidenceExpected
+}
+
+// DiscoveredEvidence returns the discovered evidence from an earlier
+// evaluation.
+func (k *Kernel) DiscoveredEvidence() KernelRebootEvidence {
+	return k.runtime.evidenceFound
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (k *Kernel) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(k.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (k *Kernel) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(k.runtime.evidenceFound)
+}
+
+// SetFoundEvidenceNewerKernelInstalled records that the NewerKernelInstalled
+// reboot evidence was found.
+func (k *Kernel) SetFoundEvidenceNewerKernelInstalled() {
+	logger.Printf("Recording that the NewerKernelInstalled evidence was found for %q", k)
+	k.runtime.evidenceFound.NewerKernelInstalled = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (k *Kernel) HasEvidence() bool {
+	return k.runtime.evidenceFound.NewerKernelInstalled
+}
+
+// Ignored indicates whether the Kernel has been marked as ignored.
+func (k *Kernel) Ignored() bool {
+	if len(k.runtime.pathsMatched) == 0 {
+		return false
+	}
+
+	for _, v := range k.runtime.pathsMatched {
+		if !v.ignored {
+			return false
+		}
+	}
+
+	// The Kernel is ignored *only* if all recorded match path entries are
+	// marked as ignored.
+	return true
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the Kernel has been marked as ignored (all recorded matched
+// paths marked as ignored) the need for a reboot is not indicated.
+func (k *Kernel) RebootRequired() bool {
+	return !k.Ignored() && k.HasEvidence()
+}
+
+// IsCriticalState indicates whether an evaluation determined that the Kernel
+// is in a CRITICAL state. Whether the Kernel has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (k *Kernel) IsCriticalState() bool {
+	switch {
+	case !k.Ignored() && k.RebootRequired():
+		return false
+	case !k.Ignored() && k.Err() != nil:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the Kernel
+// is in a WARNING state. Whether the Kernel has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (k *Kernel) IsWarningState() bool {
+	return !k.Ignored() && k.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the Kernel is in
+// an OK state. Whether the Kernel has been marked as Ignored is considered.
+// The caller is responsible for filtering the collection prior to calling
+// this method.
+func (k *Kernel) IsOKState() bool {
+	switch {
+	case k.Ignored():
+		return true
+	case k.RebootRequired():
+		return false
+	case k.Err() != nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (k *Kernel) RebootReasons() []string {
+	reasons := make([]string, 0, 1)
+
+	if k.runtime.evidenceFound.NewerKernelInstalled {
+		reasons = append(reasons, fmt.Sprintf(
+			"Running kernel %s is older than installed kernel %s",
+			k.Running(),
+			k.Newest(),
+		))
+	}
+
+	return reasons
+}
+
+// Root returns the left-most element of a matched path. This returned value
+// is the beginning of a qualified path.
+func (mp MatchedPath) Root() string {
+	return mp.root
+}
+
+// Rel returns the relative (unqualified) element of a matched path. The base
+// element of the path is usually included in this element.
+func (mp MatchedPath) Rel() string {
+	return mp.relative
+}
+
+// Base returns the last or right-most "leaf" element of a matched path.
+func (mp MatchedPath) Base() string {
+	return mp.base
+}
+
+// Full returns the qualified matched path value.
+func (mp MatchedPath) Full() string {
+	return filepath.Join(mp.root, mp.relative)
+}
+
+// String provides a human readable version of the matched path value.
+func (mp MatchedPath) String() string {
+	return mp.Full()
+}
+
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
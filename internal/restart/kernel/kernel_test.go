@@ -0,0 +1,210 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package kernel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompareVersions asserts that kernel release values are ordered using
+// numeric (not lexical) comparison of version segments.
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "6.1.0-21-amd64", b: "6.1.0-21-amd64", want: 0},
+		{a: "6.1.0-21-amd64", b: "6.1.0-9-amd64", want: 1},
+		{a: "6.1.0-9-amd64", b: "6.1.0-21-amd64", want: -1},
+		{a: "5.15.0-105-generic", b: "6.2.0-39-generic", want: -1},
+		{a: "4.18.0-513.11.1.el8_9.x86_64", b: "4.18.0-513.9.1.el8_9.x86_64", want: 1},
+		{a: "6.1.0-010", b: "6.1.0-9", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf(
+				"ERROR: CompareVersions(%q, %q) = %d; want %d",
+				tt.a, tt.b, got, tt.want,
+			)
+		}
+	}
+}
+
+// TestFlavor asserts that the expected flavor is determined for kernel
+// release values.
+func TestFlavor(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"6.1.0-21-amd64":                    "amd64",
+		"6.1.0-21-cloud-amd64":              "cloud-amd64",
+		"5.15.0-105-generic":                "generic",
+		"5.14.0-362.8.1.el9_3.x86_64+debug": "debug",
+		"5.14.0-362.8.1.el9_3.x86_64":       "",
+	}
+
+	for release, want := range tests {
+		if got := flavor(release); got != want {
+			t.Errorf("ERROR: flavor(%q) = %q; want %q", release, got, want)
+		}
+	}
+}
+
+// writeFixture creates the given file (and any parent directories) under the
+// specified root.
+func writeFixture(t *testing.T, root string, path string, content string) {
+	t.Helper()
+
+	fullPath := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o750); err != nil {
+		t.Fatalf("ERROR: failed to create fixture directory: %v", err)
+	}
+
+	if err := os.WriteFile(fullPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create fixture file: %v", err)
+	}
+}
+
+// TestKernelEvaluate asserts that a reboot is indicated only when a newer
+// kernel of the same flavor as the running kernel is installed.
+func TestKernelEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		running        string
+		files          []string
+		wantReboot     bool
+		wantNewest     string
+		wantMatchedDir string
+	}{
+		{
+			name:    "newer kernel image installed",
+			running: "6.1.0-9-amd64",
+			files: []string{
+				"boot/vmlinuz-6.1.0-9-amd64",
+				"boot/vmlinuz-6.1.0-21-amd64",
+			},
+			wantReboot:     true,
+			wantNewest:     "6.1.0-21-amd64",
+			wantMatchedDir: "boot",
+		},
+		{
+			name:    "running newest kernel",
+			running: "6.1.0-21-amd64",
+			files: []string{
+				"boot/vmlinuz-6.1.0-9-amd64",
+				"boot/vmlinuz-6.1.0-21-amd64",
+			},
+			wantReboot: false,
+			wantNewest: "6.1.0-21-amd64",
+		},
+		{
+			name:    "newer kernel of different flavor ignored",
+			running: "6.1.0-21-amd64",
+			files: []string{
+				"boot/vmlinuz-6.1.0-21-amd64",
+				"boot/vmlinuz-6.1.0-25-cloud-amd64",
+				"boot/vmlinuz-0-rescue-0123456789abcdef",
+			},
+			wantReboot: false,
+			wantNewest: "6.1.0-21-amd64",
+		},
+		{
+			name:    "newer kernel found via modules directory",
+			running: "5.14.0-362.8.1.el9_3.x86_64",
+			files: []string{
+				"lib/modules/5.14.0-362.8.1.el9_3.x86_64/modules.dep",
+				"lib/modules/5.14.0-362.13.1.el9_3.x86_64/vmlinuz",
+				"lib/modules/5.14.0-400.1.1.el9_3.x86_64/extra/leftover.ko",
+			},
+			wantReboot:     true,
+			wantNewest:     "5.14.0-362.13.1.el9_3.x86_64",
+			wantMatchedDir: "lib/modules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			writeFixture(t, root, osReleasePath, tt.running+"\n")
+			for _, file := range tt.files {
+				writeFixture(t, root, file, "")
+			}
+
+			k := &Kernel{
+				root: root,
+				evidenceExpected: KernelRebootEvidence{
+					NewerKernelInstalled: true,
+				},
+			}
+
+			if err := k.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate kernel assertion: %v", err)
+			}
+
+			k.Evaluate()
+
+			if err := k.Err(); err != nil {
+				t.Fatalf("ERROR: unexpected evaluation error: %v", err)
+			}
+
+			if got := k.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: RebootRequired() = %t; want %t", got, tt.wantReboot)
+			}
+
+			if got := k.Newest(); got != tt.wantNewest {
+				t.Errorf("ERROR: Newest() = %q; want %q", got, tt.wantNewest)
+			}
+
+			matchedPaths := k.MatchedPaths()
+			switch {
+			case !tt.wantReboot && len(matchedPaths) != 0:
+				t.Errorf("ERROR: unexpected matched paths: %v", matchedPaths)
+			case tt.wantReboot && len(matchedPaths) != 1:
+				t.Errorf("ERROR: got %d matched paths; want 1", len(matchedPaths))
+			case tt.wantReboot:
+				wantDir := filepath.Join(root, tt.wantMatchedDir)
+				if got := matchedPaths[0].Root(); got != wantDir {
+					t.Errorf("ERROR: matched path root %q; want %q", got, wantDir)
+				}
+			}
+		})
+	}
+}
+
+// TestKernelEvaluateMissingRelease asserts that failure to determine the
+// running kernel release is recorded as an error.
+func TestKernelEvaluateMissingRelease(t *testing.T) {
+	t.Parallel()
+
+	k := &Kernel{
+		root: t.TempDir(),
+		evidenceExpected: KernelRebootEvidence{
+			NewerKernelInstalled: true,
+		},
+	}
+
+	k.Evaluate()
+
+	if k.Err() == nil {
+		t.Fatal("ERROR: expected error for missing running kernel release")
+	}
+
+	if !k.IsCriticalState() {
+		t.Error("ERROR: expected CRITICAL state for evaluation error")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package kernel
+
+import (
+	"regexp"
+	"strings"
+)
+
+// flavorRegex matches Debian/Ubuntu style kernel release values (e.g.,
+// 6.1.0-21-amd64, 6.1.0-21-cloud-amd64, 5.15.0-91-generic) and captures the
+// "flavor" suffix.
+var flavorRegex = regexp.MustCompile(`^\d+(?:\.\d+)*-\d+(?:\.\d+)*-([a-z][a-z0-9-]*)$`)
+
+// CompareVersions performs a version-aware comparison of two kernel release
+// values. The result is 0 if a == b, -1 if a < b and +1 if a > b.
+//
+// Each value is split into alternating runs of digits and letters; all other
+// characters act as separators. Numeric segments are compared numerically,
+// alphabetic segments lexically and a numeric segment is considered newer
+// than an alphabetic one. If all shared segments are equal, the value with
+// more segments is considered newer. This mirrors the approach used by
+// package managers (e.g., rpmvercmp) so that 6.1.0-21 sorts after 6.1.0-9.
+func CompareVersions(a string, b string) int {
+	if a == b {
+		return 0
+	}
+
+	segmentsA := versionSegments(a)
+	segmentsB := versionSegments(b)
+
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		segA, segB := segmentsA[i], segmentsB[i]
+
+		isNumA, isNumB := isDigit(segA[0]), isDigit(segB[0])
+
+		switch {
+		case isNumA && !isNumB:
+			return 1
+		case !isNumA && isNumB:
+			return -1
+		case isNumA && isNumB:
+			if result := compareNumeric(segA, segB); result != 0 {
+				return result
+			}
+		default:
+			if result := strings.Compare(segA, segB); result != 0 {
+				return result
+			}
+		}
+	}
+
+	switch {
+	case len(segmentsA) > len(segmentsB):
+		return 1
+	case len(segmentsA) < len(segmentsB):
+		return -1
+	default:
+		return 0
+	}
+}
+
+// flavor returns the "flavor" (e.g., amd64, cloud-amd64, generic, debug) for
+// a given kernel release value. An empty string is returned if a flavor
+// could not be determined.
+func flavor(release string) string {
+	// RHEL/Fedora debug kernels use a "+debug" suffix.
+	if i := strings.LastIndex(release, "+"); i != -1 {
+		return release[i+1:]
+	}
+
+	if matches := flavorRegex.FindStringSubmatch(release); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// versionSegments splits a given version string into alternating runs of
+// digits and letters, discarding any other characters.
+func versionSegments(version string) []string {
+	segments := make([]string, 0, len(version)/2)
+
+	for i := 0; i < len(version); {
+		switch {
+		case isDigit(version[i]):
+			start := i
+			for i < len(version) && isDigit(version[i]) {
+				i++
+			}
+			segments = append(segments, version[start:i])
+
+		case isLetter(version[i]):
+			start := i
+			for i < len(version) && isLetter(version[i]) {
+				i++
+			}
+			segments = append(segments, version[start:i])
+
+		default:
+			i++
+		}
+	}
+
+	return segments
+}
+
+// compareNumeric compares two strings of digits by numeric value without
+// risk of integer overflow.
+func compareNumeric(a string, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+
+	switch {
+	case len(a) > len(b):
+		return 1
+	case len(a) < len(b):
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -1,5 +1,3 @@
-//go:build !windows
-
 // Copyright 2022 Adam Chalkley
 //
 // https://github.com/atc0005/check-restart
@@ -7,6 +5,6 @@
 // Licensed under the MIT License. See LICENSE file in the project root for
 // full license information.
 
-package registry
-
-// NOTE: This package is not intended for non-Windows systems.
+// Package maintenance provides types and functionality used to adjust the
+// severity of a needed reboot based on scheduled maintenance windows.
+package maintenance
@@ -0,0 +1,416 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the time zone database so that named time zones may be used on
+	// systems without one (e.g., Windows).
+	_ "time/tzdata"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/go-nagios"
+)
+
+// ErrInvalidWindow indicates that a maintenance window specification could
+// not be parsed.
+var ErrInvalidWindow = errors.New("invalid maintenance window")
+
+// ErrInvalidTimezone indicates that a maintenance window time zone could not
+// be loaded.
+var ErrInvalidTimezone = errors.New("invalid maintenance window time zone")
+
+// minutesPerDay is the number of minutes in a (non-DST transition) day.
+const minutesPerDay int = 24 * 60
+
+// searchDays is the number of days before and after a given time searched
+// for occurrences of a maintenance window. Windows recur weekly so a week
+// (plus a day for windows crossing midnight) is sufficient.
+const searchDays int = 8
+
+// weekdays maps the supported (case-insensitive) day name abbreviations to
+// their weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a recurring weekly period of time during which a reboot is
+// expected to be performed.
+type Window struct {
+	// spec is the specification the window was parsed from.
+	spec string
+
+	// days indicates which days of the week the window starts on.
+	days [7]bool
+
+	// start is the start of the window in minutes since midnight.
+	start int
+
+	// end is the end of the window in minutes since midnight. An end before
+	// (or equal to) the start indicates that the window ends the next day.
+	end int
+
+	// location is the time zone the window is specified in.
+	location *time.Location
+}
+
+// Windows is a collection of maintenance windows.
+type Windows []Window
+
+// Decision is the result of applying maintenance windows to the service
+// state of a collection of evaluated assertions.
+type Decision struct {
+	// Applied indicates whether maintenance windows were applied. If false,
+	// the service state of the collection is used as-is.
+	Applied bool
+
+	// State is the service state after applying maintenance windows.
+	State nagios.ServiceState
+
+	// Reason is a brief explanation of how the service state was determined.
+	Reason string
+}
+
+// LoadLocation returns the time zone with the given IANA name (e.g.,
+// "Europe/Berlin"). The local time zone is returned if no name is given.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %v: %w", name, err, ErrInvalidTimezone)
+	}
+
+	return location, nil
+}
+
+// ParseWindow parses the given maintenance window specification in the
+// given time zone. The specification is a list of days followed by a time
+// range:
+//
+//	DAYS HH:MM-HH:MM
+//
+// DAYS is a comma separated list of day names (Mon, Tue, ...) or day ranges
+// (Mon-Fri), or "*" for every day. The window starts on each listed day. An
+// end time before the start time indicates that the window crosses midnight
+// and ends the next day (e.g., "Sat 22:00-02:00"). An end time of 24:00
+// indicates midnight at the end of the day.
+func ParseWindow(spec string, location *time.Location) (Window, error) {
+	if location == nil {
+		location = time.Local
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return Window{}, fmt.Errorf(
+			"%q: expected days and time range (e.g., \"Sat 02:00-06:00\"): %w",
+			spec,
+			ErrInvalidWindow,
+		)
+	}
+
+	days, err := parseDays(fields[0])
+	if err != nil {
+		return Window{}, fmt.Errorf("%q: %v: %w", spec, err, ErrInvalidWindow)
+	}
+
+	startSpec, endSpec, found := strings.Cut(fields[1], "-")
+	if !found {
+		return Window{}, fmt.Errorf(
+			"%q: expected time range as HH:MM-HH:MM: %w",
+			spec,
+			ErrInvalidWindow,
+		)
+	}
+
+	start, err := parseTimeOfDay(startSpec, false)
+	if err != nil {
+		return Window{}, fmt.Errorf("%q: %v: %w", spec, err, ErrInvalidWindow)
+	}
+
+	end, err := parseTimeOfDay(endSpec, true)
+	if err != nil {
+		return Window{}, fmt.Errorf("%q: %v: %w", spec, err, ErrInvalidWindow)
+	}
+
+	if start == end {
+		return Window{}, fmt.Errorf(
+			"%q: start and end time must differ: %w",
+			spec,
+			ErrInvalidWindow,
+		)
+	}
+
+	return Window{
+		spec:     spec,
+		days:     days,
+		start:    start,
+		end:      end,
+		location: location,
+	}, nil
+}
+
+// ParseWindows parses each of the given maintenance window specifications
+// in the given time zone. See ParseWindow for the supported format.
+func ParseWindows(specs []string, location *time.Location) (Windows, error) {
+	windows := make(Windows, 0, len(specs))
+	for _, spec := range specs {
+		window, err := ParseWindow(spec, location)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// parseDays parses a comma separated list of day names and day ranges.
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+
+	if spec == "*" {
+		for i := range days {
+			days[i] = true
+		}
+
+		return days, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		if !isRange {
+			last = first
+		}
+
+		from, ok := weekdays[strings.ToLower(first)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", first)
+		}
+
+		to, ok := weekdays[strings.ToLower(last)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", last)
+		}
+
+		// Ranges may wrap around the end of the week (e.g., Fri-Mon).
+		for day := from; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseTimeOfDay parses a HH:MM time of day into minutes since midnight. If
+// specified, 24:00 is accepted as the end of the day.
+func parseTimeOfDay(spec string, allowEndOfDay bool) (int, error) {
+	hourSpec, minuteSpec, found := strings.Cut(spec, ":")
+	if !found || len(hourSpec) == 0 || len(hourSpec) > 2 || len(minuteSpec) != 2 {
+		return 0, fmt.Errorf("invalid time %q; expected HH:MM", spec)
+	}
+
+	hour, hourErr := strconv.Atoi(hourSpec)
+	minute, minuteErr := strconv.Atoi(minuteSpec)
+
+	switch {
+	case hourErr != nil || minuteErr != nil:
+		return 0, fmt.Errorf("invalid time %q; expected HH:MM", spec)
+
+	case allowEndOfDay && hour == 24 && minute == 0:
+		return minutesPerDay, nil
+
+	case hour < 0 || hour > 23 || minute < 0 || minute > 59:
+		return 0, fmt.Errorf("invalid time %q; out of range", spec)
+	}
+
+	return hour*60 + minute, nil
+}
+
+// String provides the maintenance window specification and time zone.
+func (w Window) String() string {
+	return fmt.Sprintf("%s (%s)", w.spec, w.location)
+}
+
+// occurrence returns the start and end of the window if it starts on the
+// day offset by the given number of days from the given time.
+func (w Window) occurrence(t time.Time, offset int) (time.Time, time.Time, bool) {
+	t = t.In(w.location)
+	year, month, day := t.Date()
+
+	date := time.Date(year, month, day+offset, 0, 0, 0, 0, w.location)
+	if !w.days[date.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+
+	endDay := day + offset
+	if w.end <= w.start {
+		endDay++
+	}
+
+	start := time.Date(year, month, day+offset, 0, w.start, 0, 0, w.location)
+	end := time.Date(year, month, endDay, 0, w.end, 0, 0, w.location)
+
+	return start, end, true
+}
+
+// Active indicates whether the given time falls within any window in the
+// collection. If so, the end of the current window is also returned.
+func (ws Windows) Active(now time.Time) (time.Time, bool) {
+	var until time.Time
+	for _, window := range ws {
+		for offset := -searchDays; offset <= searchDays; offset++ {
+			start, end, ok := window.occurrence(now, offset)
+			if !ok || now.Before(start) || !now.Before(end) {
+				continue
+			}
+
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+
+	return until, !until.IsZero()
+}
+
+// NextStart returns the start of the next window in the collection after
+// the given time. The zero value is returned if the collection is empty.
+func (ws Windows) NextStart(now time.Time) time.Time {
+	var next time.Time
+	for _, window := range ws {
+		for offset := -searchDays; offset <= searchDays; offset++ {
+			start, _, ok := window.occurrence(now, offset)
+			if !ok || !start.After(now) {
+				continue
+			}
+
+			if next.IsZero() || start.Before(next) {
+				next = start
+			}
+		}
+	}
+
+	return next
+}
+
+// PreviousEnd returns the end of the most recent window in the collection
+// which ended at or before the given time. The zero value is returned if the
+// collection is empty.
+func (ws Windows) PreviousEnd(now time.Time) time.Time {
+	var previous time.Time
+	for _, window := range ws {
+		for offset := -searchDays; offset <= searchDays; offset++ {
+			_, end, ok := window.occurrence(now, offset)
+			if !ok || end.After(now) {
+				continue
+			}
+
+			if end.After(previous) {
+				previous = end
+			}
+		}
+	}
+
+	return previous
+}
+
+// Apply adjusts the service state of the given evaluated assertions based on
+// the maintenance windows in the collection. The caller is responsible for
+// filtering the collection of assertions prior to calling this method.
+//
+// Maintenance windows are only applied if the assertions indicate that a
+// reboot is needed and no errors occurred during evaluation:
+//
+//   - within a maintenance window the service state is unchanged
+//   - outside of a maintenance window the service state is downgraded to OK
+//   - if a maintenance window has ended since the need for a reboot was
+//     first recorded (see restart.PendingState) the service state is
+//     escalated to CRITICAL
+//
+// Assertions escalated after the pending grace period (see
+// restart.PendingTracker) are not downgraded outside of a maintenance window.
+func (ws Windows) Apply(assertions restart.RebootRequiredAsserters, now time.Time) Decision {
+	if len(ws) == 0 || !assertions.RebootRequired() || assertions.HasErrors(false) {
+		return Decision{}
+	}
+
+	if until, active := ws.Active(now); active {
+		logger.Printf("Maintenance window in progress until %s", until.Format(time.RFC3339))
+
+		return Decision{
+			Applied: true,
+			State:   assertions.ServiceState(),
+			Reason:  fmt.Sprintf("maintenance window in progress until %s", until.Format(time.RFC3339)),
+		}
+	}
+
+	since := assertions.OldestPending()
+	previousEnd := ws.PreviousEnd(now)
+
+	if !since.IsZero() && previousEnd.After(since) {
+		logger.Printf(
+			"Reboot pending since %s; maintenance window ended %s",
+			since.Format(time.RFC3339),
+			previousEnd.Format(time.RFC3339),
+		)
+
+		return Decision{
+			Applied: true,
+			State: nagios.ServiceState{
+				Label:    nagios.StateCRITICALLabel,
+				ExitCode: nagios.StateCRITICALExitCode,
+			},
+			Reason: fmt.Sprintf(
+				"still pending after maintenance window ended %s",
+				previousEnd.Format(time.RFC3339),
+			),
+		}
+	}
+
+	// Assertions escalated after the pending grace period are not
+	// downgraded.
+	if assertions.HasEscalated() {
+		logger.Print("Outside maintenance window; not downgrading escalated assertions")
+
+		return Decision{}
+	}
+
+	next := ws.NextStart(now)
+
+	logger.Printf("Outside maintenance window; next window starts %s", next.Format(time.RFC3339))
+
+	return Decision{
+		Applied: true,
+		State: nagios.ServiceState{
+			Label:    nagios.StateOKLabel,
+			ExitCode: nagios.StateOKExitCode,
+		},
+		Reason: fmt.Sprintf(
+			"outside maintenance window; next window starts %s",
+			next.Format(time.RFC3339),
+		),
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package maintenance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// pendingStub is a RebootRequiredAsserter in a WARNING state if a reboot is
+// required which records pending state. Methods other than those used to
+// apply maintenance windows are not implemented.
+type pendingStub struct {
+	restart.RebootRequiredAsserter
+
+	rebootRequired bool
+	err            error
+	since          time.Time
+}
+
+func (ps *pendingStub) Err() error              { return ps.err }
+func (ps *pendingStub) Ignored() bool           { return false }
+func (ps *pendingStub) RebootRequired() bool    { return ps.rebootRequired }
+func (ps *pendingStub) IsOKState() bool         { return !ps.rebootRequired && ps.err == nil }
+func (ps *pendingStub) IsWarningState() bool    { return ps.rebootRequired }
+func (ps *pendingStub) IsCriticalState() bool   { return ps.err != nil }
+func (ps *pendingStub) PendingSince() time.Time { return ps.since }
+func (ps *pendingStub) Escalated() bool         { return false }
+func (ps *pendingStub) SetPending(since time.Time, _ bool) {
+	ps.since = since
+}
+
+// TestParseWindow asserts that valid maintenance window specifications are
+// accepted and invalid specifications are rejected.
+func TestParseWindow(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		"Sat 02:00-06:00",
+		"mon-fri 22:00-02:00",
+		"Fri-Mon 00:00-24:00",
+		"Sat,Sun 1:30-04:00",
+		"* 03:00-04:00",
+	}
+
+	for _, spec := range valid {
+		if _, err := ParseWindow(spec, time.UTC); err != nil {
+			t.Errorf("ERROR: failed to parse %q: %v", spec, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Sat",
+		"02:00-06:00",
+		"Someday 02:00-06:00",
+		"Sat 02:00",
+		"Sat 02:00-25:00",
+		"Sat 24:00-06:00",
+		"Sat 02:60-06:00",
+		"Sat 02:00-02:00",
+		"Sat 2-6",
+	}
+
+	for _, spec := range invalid {
+		if _, err := ParseWindow(spec, time.UTC); !errors.Is(err, ErrInvalidWindow) {
+			t.Errorf("ERROR: expected ErrInvalidWindow for %q; got %v", spec, err)
+		}
+	}
+
+	if _, err := LoadLocation("Not/A_Zone"); !errors.Is(err, ErrInvalidTimezone) {
+		t.Errorf("ERROR: expected ErrInvalidTimezone; got %v", err)
+	}
+}
+
+// TestWindowsActive asserts that windows crossing midnight and windows
+// specified in a time zone other than UTC are evaluated as expected.
+func TestWindowsActive(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("ERROR: failed to load time zone: %v", err)
+	}
+
+	tests := map[string]struct {
+		spec      string
+		location  *time.Location
+		now       time.Time
+		wantOK    bool
+		wantUntil time.Time
+	}{
+		"within window": {
+			spec:      "Sat 02:00-06:00",
+			location:  time.UTC,
+			now:       time.Date(2023, 5, 13, 3, 0, 0, 0, time.UTC),
+			wantOK:    true,
+			wantUntil: time.Date(2023, 5, 13, 6, 0, 0, 0, time.UTC),
+		},
+		"after window": {
+			spec:     "Sat 02:00-06:00",
+			location: time.UTC,
+			now:      time.Date(2023, 5, 13, 6, 0, 0, 0, time.UTC),
+		},
+		"wrong day": {
+			spec:     "Sat 02:00-06:00",
+			location: time.UTC,
+			now:      time.Date(2023, 5, 14, 3, 0, 0, 0, time.UTC),
+		},
+		"crossing midnight on following day": {
+			spec:      "Sat 22:00-02:00",
+			location:  time.UTC,
+			now:       time.Date(2023, 5, 14, 1, 0, 0, 0, time.UTC),
+			wantOK:    true,
+			wantUntil: time.Date(2023, 5, 14, 2, 0, 0, 0, time.UTC),
+		},
+		"time zone": {
+			spec:      "Sat 02:00-06:00",
+			location:  berlin,
+			now:       time.Date(2023, 5, 13, 3, 30, 0, 0, time.UTC),
+			wantOK:    true,
+			wantUntil: time.Date(2023, 5, 13, 4, 0, 0, 0, time.UTC),
+		},
+		"time zone outside window": {
+			spec:     "Sat 02:00-06:00",
+			location: berlin,
+			now:      time.Date(2023, 5, 13, 4, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			windows, err := ParseWindows([]string{tt.spec}, tt.location)
+			if err != nil {
+				t.Fatalf("ERROR: failed to parse %q: %v", tt.spec, err)
+			}
+
+			until, ok := windows.Active(tt.now)
+			if ok != tt.wantOK || !until.Equal(tt.wantUntil) {
+				t.Errorf("ERROR: got active %t until %s; want %t until %s", ok, until, tt.wantOK, tt.wantUntil)
+			}
+		})
+	}
+}
+
+// TestWindowsApply asserts that the service state is unchanged within a
+// maintenance window, downgraded outside of a maintenance window and
+// escalated once a maintenance window has passed with a reboot still
+// pending.
+func TestWindowsApply(t *testing.T) {
+	t.Parallel()
+
+	windows, err := ParseWindows([]string{"Sat 02:00-06:00"}, time.UTC)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse maintenance window: %v", err)
+	}
+
+	// Wednesday.
+	now := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		assertion   *pendingStub
+		now         time.Time
+		wantApplied bool
+		wantLabel   string
+	}{
+		"no reboot required": {
+			assertion: &pendingStub{},
+			now:       now,
+		},
+		"evaluation error": {
+			assertion: &pendingStub{rebootRequired: true, err: errors.New("access denied")},
+			now:       now,
+		},
+		"within window": {
+			assertion:   &pendingStub{rebootRequired: true},
+			now:         time.Date(2023, 5, 13, 3, 0, 0, 0, time.UTC),
+			wantApplied: true,
+			wantLabel:   "WARNING",
+		},
+		"outside window": {
+			assertion:   &pendingStub{rebootRequired: true, since: now.Add(-time.Hour)},
+			now:         now,
+			wantApplied: true,
+			wantLabel:   "OK",
+		},
+		"outside window without pending state": {
+			assertion:   &pendingStub{rebootRequired: true},
+			now:         now,
+			wantApplied: true,
+			wantLabel:   "OK",
+		},
+		"window passed": {
+			assertion:   &pendingStub{rebootRequired: true, since: now.Add(-7 * 24 * time.Hour)},
+			now:         now,
+			wantApplied: true,
+			wantLabel:   "CRITICAL",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decision := windows.Apply(restart.RebootRequiredAsserters{tt.assertion}, tt.now)
+
+			if decision.Applied != tt.wantApplied {
+				t.Fatalf("ERROR: got applied %t; want %t", decision.Applied, tt.wantApplied)
+			}
+
+			if decision.Applied && decision.State.Label != tt.wantLabel {
+				t.Errorf("ERROR: got state %s (%s); want %s", decision.State.Label, decision.Reason, tt.wantLabel)
+			}
+		})
+	}
+
+	if decision := (Windows{}).Apply(restart.RebootRequiredAsserters{&pendingStub{rebootRequired: true}}, now); decision.Applied {
+		t.Error("ERROR: maintenance windows applied with no windows specified")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+// DefaultRestartRequiredIgnoredPaths provides the default collection of
+// paths for process related restart required assertions that should be
+// ignored.
+//
+// These paths are used for temporary or runtime files which are routinely
+// removed by applications while still in use and are not an indication of
+// an updated executable or library.
+//
+// Each entry includes the separator used between the process details and
+// the deleted file path of a matched path so that only the start of the
+// deleted file path is matched. Paths are normalized before comparison with
+// matched paths.
+func DefaultRestartRequiredIgnoredPaths() []string {
+	return []string{
+		": /tmp/",
+		": /var/tmp/",
+		": /run/",
+		": /dev/shm/",
+	}
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+import (
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// DefaultRestartRequiredAssertions provides the default collection of
+// process related restart required assertions using the specified proc
+// filesystem root.
+func DefaultRestartRequiredAssertions(procRoot string) restart.RebootRequiredAsserters {
+
+	var assertions = restart.RebootRequiredAsserters{
+
+		// Processes running an executable or using a shared library that has
+		// been replaced (e.g., by a package update) continue to use the
+		// older, deleted copy until restarted.
+		&Processes{
+			procRoot: procRoot,
+			evidenceExpected: ProcessRestartEvidence{
+				DeletedExecutable: true,
+				DeletedMappedFile: true,
+			},
+		},
+	}
+
+	return assertions
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+import (
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// DefaultRestartRequiredAssertions provides the default collection of
+// process related restart required assertions using the specified proc
+// filesystem root.
+func DefaultRestartRequiredAssertions(_ string) restart.RebootRequiredAsserters {
+	logger.Println("WARNING: Process assertions are only supported on Linux systems")
+
+	return restart.RebootRequiredAsserters{}
+}
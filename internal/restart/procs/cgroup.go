@@ -0,0 +1,228 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// procCgroupFile is the file (relative to a process directory) listing the
+// control groups for a process.
+const procCgroupFile string = "cgroup"
+
+// systemdV1Hierarchy is the name of the named cgroup v1 hierarchy maintained
+// by systemd to track units.
+const systemdV1Hierarchy string = "name=systemd"
+
+// UnitKind indicates the type of systemd unit responsible for a process.
+type UnitKind int
+
+// Supported UnitKind values. The order of these values is used to order
+// report output.
+const (
+	// UnitKindNone indicates that a process is not associated with a systemd
+	// unit (e.g., systemd is not in use or the cgroup is unavailable).
+	UnitKindNone UnitKind = iota
+
+	// UnitKindService indicates that a process belongs to a system service.
+	UnitKindService
+
+	// UnitKindUser indicates that a process belongs to a unit managed by a
+	// per-user service manager (user@UID.service).
+	UnitKindUser
+
+	// UnitKindSession indicates that a process belongs to a user login
+	// session scope (e.g., session-3.scope).
+	UnitKindSession
+
+	// UnitKindScope indicates that a process belongs to a scope unit other
+	// than a user session (e.g., init.scope, container scopes).
+	UnitKindScope
+
+	// UnitKindOther indicates that a process belongs to another unit type
+	// (e.g., mount, socket or swap units).
+	UnitKindOther
+)
+
+// unitSuffixes are the suffixes used by systemd unit names which may appear
+// as a cgroup path component.
+var unitSuffixes = []string{
+	".service",
+	".scope",
+	".socket",
+	".mount",
+	".swap",
+}
+
+// sessionScopeRegex matches the names of user login session scopes.
+var sessionScopeRegex = regexp.MustCompile(`^session-[^/]+\.scope$`)
+
+// userManagerRegex matches the names of per-user service manager units.
+var userManagerRegex = regexp.MustCompile(`^user@\d+\.service$`)
+
+// String provides a human readable description of the UnitKind.
+func (k UnitKind) String() string {
+	switch k {
+	case UnitKindService:
+		return "service"
+	case UnitKindUser:
+		return "user unit"
+	case UnitKindSession:
+		return "user session"
+	case UnitKindScope:
+		return "scope"
+	case UnitKindOther:
+		return "unit"
+	default:
+		return "no unit"
+	}
+}
+
+// readUnit retrieves the systemd unit (and unit kind) responsible for a
+// process. If a unit cannot be determined an empty string and UnitKindNone
+// are returned.
+func readUnit(procDir string) (string, UnitKind) {
+	cgroupFile := filepath.Join(procDir, procCgroupFile)
+
+	fh, err := os.Open(filepath.Clean(cgroupFile))
+	if err != nil {
+		logger.Printf("Unable to read cgroup file %q: %v", cgroupFile, err)
+		return "", UnitKindNone
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", cgroupFile, err)
+		}
+	}()
+
+	var cgroupPath string
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		path, ok := systemdCgroupPath(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		cgroupPath = path
+
+		// A populated cgroup v1 systemd hierarchy entry is authoritative.
+		// Systems running in "hybrid" mode also list a (usually empty)
+		// unified hierarchy entry.
+		if !strings.HasPrefix(scanner.Text(), "0::") {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Printf("Error reading cgroup file %q: %v", cgroupFile, err)
+	}
+
+	return unitFromCgroupPath(cgroupPath)
+}
+
+// systemdCgroupPath returns the cgroup path from a line in a cgroup file if
+// the line describes the cgroup v2 unified hierarchy or the cgroup v1
+// hierarchy maintained by systemd.
+//
+// Each line has the form:
+//
+//	hierarchy-ID:controller-list:cgroup-path
+//
+// The unified (v2) hierarchy uses an ID of 0 and an empty controller list.
+func systemdCgroupPath(line string) (string, bool) {
+	fields := strings.SplitN(line, ":", 3)
+	if len(fields) != 3 {
+		return "", false
+	}
+
+	id, controllers, path := fields[0], fields[1], fields[2]
+
+	switch {
+	case id == "0" && controllers == "":
+		if path == "/" || path == "" {
+			return "", false
+		}
+
+		return path, true
+
+	case controllers == systemdV1Hierarchy:
+		return path, true
+
+	default:
+		return "", false
+	}
+}
+
+// unitFromCgroupPath determines the systemd unit (and unit kind) from the
+// given cgroup path. Slice units are used to organize the cgroup tree and
+// are skipped. For processes managed by a per-user service manager the
+// user's unit is returned instead of the user manager unit.
+func unitFromCgroupPath(cgroupPath string) (string, UnitKind) {
+	var (
+		unit        string
+		userManager bool
+	)
+
+	for _, component := range strings.Split(cgroupPath, "/") {
+		if !isUnitName(component) {
+			continue
+		}
+
+		if unit == "" && userManagerRegex.MatchString(component) {
+			unit = component
+			userManager = true
+
+			continue
+		}
+
+		// The per-user service manager process itself is placed in the
+		// init.scope unit beneath the user manager unit.
+		if userManager && component == "init.scope" {
+			return unit, UnitKindService
+		}
+
+		if userManager {
+			return component, UnitKindUser
+		}
+
+		unit = component
+
+		break
+	}
+
+	switch {
+	case unit == "":
+		return "", UnitKindNone
+	case sessionScopeRegex.MatchString(unit):
+		return unit, UnitKindSession
+	case strings.HasSuffix(unit, ".scope"):
+		return unit, UnitKindScope
+	case strings.HasSuffix(unit, ".service"):
+		return unit, UnitKindService
+	default:
+		return unit, UnitKindOther
+	}
+}
+
+// isUnitName indicates whether the given cgroup path component is a systemd
+// unit name (other than a slice).
+func isUnitName(component string) bool {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(component, suffix) && len(component) > len(suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,12 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package procs provides functionality used to evaluate whether running
+// processes are using executables or shared libraries that have been deleted
+// (e.g., replaced by a package update), indicating the need for a service
+// restart.
+package procs
@@ -0,0 +1,898 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserter implementation isn't correct.
+var _ restart.RebootRequiredAsserter = (*Processes)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithDataDisplay implementation isn't correct.
+var _ restart.RebootRequiredAsserterWithDataDisplay = (*Processes)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var _ restart.EvaluationTimer = (*Processes)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var _ restart.PendingTracker = (*Processes)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*Processes)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*Processes)(nil)
+
+// Add "implements assertions" to fail the build if the restart.MatchedPath
+// implementation isn't correct.
+var _ restart.MatchedPath = (*MatchedPath)(nil)
+
+// Files (relative to a process directory) used to evaluate a process.
+const (
+	procExeFile  string = "exe"
+	procMapsFile string = "maps"
+	procCommFile string = "comm"
+)
+
+// deletedSuffix is appended by the kernel to the path of an executable or
+// mapped file which has been removed (unlinked) since it was opened.
+const deletedSuffix string = " (deleted)"
+
+// pseudoMappingPrefixes is a collection of prefixes for mapped "paths" which
+// do not represent files on disk. These mappings are often (or always)
+// flagged as deleted and are not considered evidence of a needed restart.
+var pseudoMappingPrefixes = []string{
+	"/memfd:",
+	"/SYSV",
+	"/drm mm object",
+	"/[aio]",
+	"/anon_hugepage",
+	"/dmabuf:",
+	"/dev/zero",
+}
+
+// ErrProcRootUnavailable indicates that the processes within the specified
+// proc filesystem root could not be listed.
+var ErrProcRootUnavailable = errors.New("unable to list processes in proc root")
+
+// ProcessRestartEvidence indicates what process evidence is required in
+// order to determine that a restart is needed.
+type ProcessRestartEvidence struct {
+	// DeletedExecutable indicates that a process running an executable which
+	// has since been deleted is sufficient evidence for a restart.
+	DeletedExecutable bool
+
+	// DeletedMappedFile indicates that a process with a mapped file (e.g.,
+	// shared library) which has since been deleted is sufficient evidence
+	// for a restart.
+	DeletedMappedFile bool
+}
+
+// ProcessesRuntime is a collection of values for Processes that are set
+// during evaluation. Unlike the static values set for Processes (e.g., proc
+// root, expected evidence), these values are not known until execution or
+// runtime.
+type ProcessesRuntime struct {
+	// err records any error that occurs while performing an evaluation.
+	err error
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound ProcessRestartEvidence
+
+	// numScanned is the number of processes evaluated.
+	numScanned int
+
+	// numSkipped is the number of processes which could not be evaluated due
+	// to insufficient permissions.
+	numSkipped int
+
+	// pathsMatched is a collection of deleted file paths (per process) that
+	// were matched during evaluation.
+	pathsMatched MatchedPathIndex
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
+}
+
+// MatchedPathIndex is a collection of path values that were matched during
+// evaluation of specified restart required assertions.
+type MatchedPathIndex map[string]MatchedPath
+
+// MatchedPath represents a deleted file in use by a process that was matched
+// when performing an evaluation of a "restart required" assertion.
+type MatchedPath struct {
+	// procRoot is the proc filesystem root used to evaluate the process.
+	procRoot string
+
+	// pid is the process ID for the process using the deleted file.
+	pid int
+
+	// command is the command name for the process using the deleted file.
+	command string
+
+	// path is the path to the deleted file (without the deleted suffix).
+	path string
+
+	// executable indicates whether the deleted file is the executable for
+	// the process.
+	executable bool
+
+	// unit is the name of the systemd unit responsible for the process. This
+	// is empty if the process is not associated with a unit.
+	unit string
+
+	// unitKind indicates the type of systemd unit responsible for the
+	// process.
+	unitKind UnitKind
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a restart is needed.
+	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
+}
+
+// Processes represents the collection of running processes which (if found
+// using deleted files) indicates that a restart is needed.
+type Processes struct {
+	// procRoot is the fully-qualified path to the proc filesystem used to
+	// locate running processes. This is usually /proc, but may be set to an
+	// alternate location (e.g., for testing purposes).
+	procRoot string
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime ProcessesRuntime
+
+	// evidenceExpected indicates what evidence is used to determine that a
+	// restart is needed.
+	evidenceExpected ProcessRestartEvidence
+}
+
+// Err exposes the underlying error (if any) as-is.
+func (p *Processes) Err() error {
+	return p.runtime.err
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (p *Processes) EvaluationDuration() time.Duration {
+	return p.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (p *Processes) SetEvaluationDuration(duration time.Duration) {
+	p.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (p *Processes) PendingSince() time.Time {
+	return p.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (p *Processes) Escalated() bool {
+	return p.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (p *Processes) SetPending(since time.Time, escalated bool) {
+	p.runtime.pendingSince = since
+	p.runtime.escalated = escalated
+}
+
+// Validate performs basic validation. An error is returned for any validation
+// failures.
+func (p *Processes) Validate() error {
+	if p.procRoot == "" {
+		return fmt.Errorf(
+			"invalid proc root: %w",
+			restart.ErrMissingValue,
+		)
+	}
+
+	if !p.evidenceExpected.DeletedExecutable &&
+		!p.evidenceExpected.DeletedMappedFile {
+		return fmt.Errorf(
+			"value unexpected: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	return nil
+}
+
+// String provides the fully qualified path to the proc filesystem root used
+// to evaluate running processes.
+func (p *Processes) String() string {
+	return p.procRoot
+}
+
+// NumScanned returns the number of processes evaluated.
+func (p *Processes) NumScanned() int {
+	return p.runtime.numScanned
+}
+
+// NumSkipped returns the number of processes which could not be evaluated
+// due to insufficient permissions.
+func (p *Processes) NumSkipped() int {
+	return p.runtime.numSkipped
+}
+
+// NumAffected returns the number of processes using deleted files which have
+// not been marked as ignored.
+func (p *Processes) NumAffected() int {
+	return len(p.affected())
+}
+
+// DataDisplay provides a summary of the evaluated processes for display
+// purposes.
+func (p *Processes) DataDisplay() string {
+	return fmt.Sprintf(
+		"processes: %d scanned, %d affected, %d skipped (permission denied)",
+		p.NumScanned(),
+		p.NumAffected(),
+		p.NumSkipped(),
+	)
+}
+
+// Evaluate applies the specified assertion to determine if a restart is
+// necessary.
+func (p *Processes) Evaluate() {
+	logger.Printf("Evaluating processes in %q", p.procRoot)
+
+	entries, err := os.ReadDir(p.procRoot)
+	if err != nil {
+		p.runtime.err = fmt.Errorf("%w: %w", ErrProcRootUnavailable, err)
+		return
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		p.evalProcess(pid)
+	}
+
+	logger.Printf(
+		"%d processes scanned, %d skipped, %d deleted files matched",
+		p.runtime.numScanned,
+		p.runtime.numSkipped,
+		len(p.runtime.pathsMatched),
+	)
+}
+
+// Reset discards the results of an earlier evaluation so that the Processes may
+// be evaluated again.
+func (p *Processes) Reset() {
+	p.runtime = ProcessesRuntime{}
+}
+
+// evalProcess evaluates the executable and mapped files for the specified
+// process. Processes which exit before evaluation completes are silently
+// skipped. Processes which cannot be evaluated due to insufficient
+// permissions are recorded as skipped.
+func (p *Processes) evalProcess(pid int) {
+	procDir := filepath.Join(p.procRoot, strconv.Itoa(pid))
+
+	command := readCommand(procDir)
+
+	exe, err := os.Readlink(filepath.Join(procDir, procExeFile))
+	switch {
+	case os.IsPermission(err):
+		logger.Printf("Skipping PID %d (%s): %v", pid, command, err)
+		p.runtime.numSkipped++
+		return
+
+	// Kernel threads do not have an executable and processes may have
+	// exited since the process listing was retrieved.
+	case err != nil:
+		logger.Printf("Unable to evaluate executable for PID %d (%s): %v", pid, command, err)
+		exe = ""
+	}
+
+	mappedFiles, err := readDeletedMappedFiles(filepath.Join(procDir, procMapsFile))
+	switch {
+	case os.IsPermission(err):
+		logger.Printf("Skipping PID %d (%s): %v", pid, command, err)
+		p.runtime.numSkipped++
+		return
+
+	case err != nil:
+		logger.Printf("Unable to evaluate mapped files for PID %d (%s): %v", pid, command, err)
+	}
+
+	p.runtime.numScanned++
+
+	deletedExe := p.evidenceExpected.DeletedExecutable && strings.HasSuffix(exe, deletedSuffix)
+	deletedMappedFiles := p.evidenceExpected.DeletedMappedFile && len(mappedFiles) > 0
+
+	if !deletedExe && !deletedMappedFiles {
+		return
+	}
+
+	unit, unitKind := readUnit(procDir)
+
+	logger.Printf("PID %d (%s) belongs to unit %q (%s)", pid, command, unit, unitKind)
+
+	if deletedExe {
+		exePath := strings.TrimSuffix(exe, deletedSuffix)
+
+		logger.Printf("PID %d (%s) uses deleted executable %q", pid, command, exePath)
+
+		p.SetFoundEvidenceDeletedExecutable()
+		p.addMatchedPath(MatchedPath{
+			procRoot:   p.procRoot,
+			pid:        pid,
+			command:    command,
+			path:       exePath,
+			executable: true,
+			unit:       unit,
+			unitKind:   unitKind,
+		})
+	}
+
+	if deletedMappedFiles {
+		for _, mappedFile := range mappedFiles {
+			logger.Printf("PID %d (%s) uses deleted mapped file %q", pid, command, mappedFile)
+
+			p.SetFoundEvidenceDeletedMappedFile()
+			p.addMatchedPath(MatchedPath{
+				procRoot: p.procRoot,
+				pid:      pid,
+				command:  command,
+				path:     mappedFile,
+				unit:     unit,
+				unitKind: unitKind,
+			})
+		}
+	}
+}
+
+// readCommand retrieves the command name for a process. If not available,
+// an empty string is returned.
+func readCommand(procDir string) string {
+	data, err := os.ReadFile(filepath.Join(procDir, procCommFile))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// readDeletedMappedFiles retrieves the unique paths of deleted files listed
+// in the specified maps file. Pseudo file mappings are excluded.
+func readDeletedMappedFiles(mapsFile string) ([]string, error) {
+	fh, err := os.Open(filepath.Clean(mapsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", mapsFile, err)
+		}
+	}()
+
+	seen := make(map[string]struct{})
+	deleted := make([]string, 0)
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		path := mappedPath(scanner.Text())
+
+		if !strings.HasSuffix(path, deletedSuffix) {
+			continue
+		}
+
+		path = strings.TrimSuffix(path, deletedSuffix)
+
+		if isPseudoMapping(path) {
+			continue
+		}
+
+		if _, ok := seen[path]; ok {
+			continue
+		}
+
+		seen[path] = struct{}{}
+		deleted = append(deleted, path)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+// mappedPath returns the pathname field from a line in a maps file. An empty
+// string is returned for anonymous mappings.
+//
+// Each line has the form:
+//
+//	address perms offset dev inode pathname
+//
+// The pathname field is padded with whitespace and may itself contain
+// spaces.
+func mappedPath(line string) string {
+	const pathnameField = 5
+
+	rest := line
+	for i := 0; i < pathnameField; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+
+		end := strings.IndexAny(rest, " \t")
+		if end == -1 {
+			return ""
+		}
+
+		rest = rest[end:]
+	}
+
+	path := strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(path, "/") {
+		return ""
+	}
+
+	return path
+}
+
+// isPseudoMapping indicates whether the given mapped path does not represent
+// a file on disk.
+func isPseudoMapping(path string) bool {
+	for _, prefix := range pseudoMappingPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addMatchedPath records the given deleted file as a successful assertion
+// match. Duplicate entries are ignored.
+func (p *Processes) addMatchedPath(matchedPath MatchedPath) {
+	if p.runtime.pathsMatched == nil {
+		p.runtime.pathsMatched = make(MatchedPathIndex)
+	}
+
+	key := matchedPath.Full()
+	if _, ok := p.runtime.pathsMatched[key]; !ok {
+		p.runtime.pathsMatched[key] = matchedPath
+	}
+}
+
+// MatchedPaths returns all recorded paths from successful assertion matches.
+func (p *Processes) MatchedPaths() restart.MatchedPaths {
+	pathStrings := make([]string, 0, len(p.runtime.pathsMatched))
+	matchedPaths := make(restart.MatchedPaths, 0, len(p.runtime.pathsMatched))
+
+	for path := range p.runtime.pathsMatched {
+		pathStrings = append(pathStrings, path)
+	}
+
+	sort.Strings(pathStrings)
+
+	for _, path := range pathStrings {
+		matchedPaths = append(matchedPaths, p.runtime.pathsMatched[path])
+	}
+
+	return matchedPaths
+}
+
+// Filter uses the list of specified ignore patterns to mark each matched path
+// for the Processes as ignored *IF* a match is found. Each ignore pattern is
+// compared against the full matched path (process ID, command name, systemd
+// unit name and deleted file path) as well as the systemd unit name and
+// deleted file path on their own. This allows ignoring all processes for a
+// unit by specifying the unit name using any ignore pattern kind (e.g.,
+// "exact:nginx.service" or "glob:*.service").
+//
+// If no matched paths are recorded Filter makes no changes. Filter should be
+// called before performing final state evaluation.
+func (p *Processes) Filter(ignorePatterns restart.IgnorePatterns) {
+	if len(ignorePatterns) == 0 {
+		logger.Printf("0 ignore patterns specified for %q; skipping Filter", p)
+		return
+	}
+
+	for originalPathString, matchedPath := range p.runtime.pathsMatched {
+		if ignorePattern, ok := ignorePatterns.MatchAny(restart.IgnoreTargetProcess, matchedPath.ignoreCandidates(), p.String()); ok {
+			logger.Printf("marking matched path %q as ignored", originalPathString)
+
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			p.runtime.pathsMatched[originalPathString] = matchedPath
+		}
+	}
+}
+
+// ExpectedEvidence returns the specified evidence that (if found) indicates a
+// restart is needed.
+func (p *Processes) ExpectedEvidence() ProcessRestartEvidence {
+	return p.evidenceExpected
+}
+
+// DiscoveredEvidence returns the discovered evidence from an earlier
+// evaluation.
+func (p *Processes) DiscoveredEvidence() ProcessRestartEvidence {
+	return p.runtime.evidenceFound
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (p *Processes) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(p.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (p *Processes) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(p.runtime.evidenceFound)
+}
+
+// SetFoundEvidenceDeletedExecutable records that the DeletedExecutable
+// restart evidence was found.
+func (p *Processes) SetFoundEvidenceDeletedExecutable() {
+	p.runtime.evidenceFound.DeletedExecutable = true
+}
+
+// SetFoundEvidenceDeletedMappedFile records that the DeletedMappedFile
+// restart evidence was found.
+func (p *Processes) SetFoundEvidenceDeletedMappedFile() {
+	p.runtime.evidenceFound.DeletedMappedFile = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (p *Processes) HasEvidence() bool {
+	return p.runtime.evidenceFound.DeletedExecutable ||
+		p.runtime.evidenceFound.DeletedMappedFile
+}
+
+// Ignored indicates whether the Processes have been marked as ignored.
+func (p *Processes) Ignored() bool {
+	if len(p.runtime.pathsMatched) == 0 {
+		return false
+	}
+
+	for _, v := range p.runtime.pathsMatched {
+		if !v.ignored {
+			return false
+		}
+	}
+
+	// The Processes are ignored *only* if all recorded match path entries
+	// are marked as ignored.
+	return true
+}
+
+// RebootRequired indicates whether an evaluation determined that a restart
+// is needed. If the Processes have been marked as ignored (all recorded
+// matched paths marked as ignored) the need for a restart is not indicated.
+func (p *Processes) RebootRequired() bool {
+	return !p.Ignored() && p.HasEvidence()
+}
+
+// IsCriticalState indicates whether an evaluation determined that the
+// Processes are in a CRITICAL state. Whether the Processes have been marked
+// as Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (p *Processes) IsCriticalState() bool {
+	switch {
+	case !p.Ignored() && p.RebootRequired():
+		return false
+	case !p.Ignored() && p.Err() != nil:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the
+// Processes are in a WARNING state. Whether the Processes have been marked as
+// Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (p *Processes) IsWarningState() bool {
+	return !p.Ignored() && p.RebootRequired()
+}
+
+// IsOKState indicates whether an evaluation determined that the Processes are
+// in an OK state. Whether the Processes have been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (p *Processes) IsOKState() bool {
+	switch {
+	case p.Ignored():
+		return true
+	case p.RebootRequired():
+		return false
+	case p.Err() != nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// affectedProcess is a process using one or more deleted files which have
+// not been marked as ignored.
+type affectedProcess struct {
+	pid      int
+	command  string
+	unit     string
+	unitKind UnitKind
+	paths    []MatchedPath
+}
+
+// affectedUnit is a systemd unit with one or more processes using deleted
+// files which have not been marked as ignored.
+type affectedUnit struct {
+	unit      string
+	unitKind  UnitKind
+	processes []affectedProcess
+}
+
+// affected returns the processes using deleted files which have not been
+// marked as ignored, ordered by process ID.
+func (p *Processes) affected() []affectedProcess {
+	index := make(map[int]*affectedProcess)
+
+	for _, matchedPath := range p.MatchedPaths() {
+		mp, ok := matchedPath.(MatchedPath)
+		if !ok || mp.ignored {
+			continue
+		}
+
+		proc, ok := index[mp.pid]
+		if !ok {
+			proc = &affectedProcess{
+				pid:      mp.pid,
+				command:  mp.command,
+				unit:     mp.unit,
+				unitKind: mp.unitKind,
+			}
+			index[mp.pid] = proc
+		}
+
+		proc.paths = append(proc.paths, mp)
+	}
+
+	processes := make([]affectedProcess, 0, len(index))
+	for _, proc := range index {
+		processes = append(processes, *proc)
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].pid < processes[j].pid
+	})
+
+	return processes
+}
+
+// affectedUnits returns the systemd units with processes using deleted files
+// which have not been marked as ignored. Units are ordered by kind (services
+// first, then user units, user sessions, other scopes and other units) and
+// then by name. Processes not associated with a unit are not included.
+func (p *Processes) affectedUnits() []affectedUnit {
+	index := make(map[string]*affectedUnit)
+
+	for _, proc := range p.affected() {
+		if proc.unitKind == UnitKindNone {
+			continue
+		}
+
+		unit, ok := index[proc.unit]
+		if !ok {
+			unit = &affectedUnit{unit: proc.unit, unitKind: proc.unitKind}
+			index[proc.unit] = unit
+		}
+
+		unit.processes = append(unit.processes, proc)
+	}
+
+	units := make([]affectedUnit, 0, len(index))
+	for _, unit := range index {
+		units = append(units, *unit)
+	}
+
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].unitKind != units[j].unitKind {
+			return units[i].unitKind < units[j].unitKind
+		}
+
+		return units[i].unit < units[j].unit
+	})
+
+	return units
+}
+
+// deletedFilesDisplay returns a sorted, comma separated list of the unique
+// deleted file names used by the given processes.
+func deletedFilesDisplay(processes ...affectedProcess) string {
+	seen := make(map[string]struct{})
+	files := make([]string, 0)
+
+	for _, proc := range processes {
+		for _, mp := range proc.paths {
+			file := mp.Base()
+			if mp.executable {
+				file += " (executable)"
+			}
+
+			if _, ok := seen[file]; ok {
+				continue
+			}
+
+			seen[file] = struct{}{}
+			files = append(files, file)
+		}
+	}
+
+	sort.Strings(files)
+
+	return strings.Join(files, ", ")
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a restart is needed.
+//
+// One reason is provided for each affected systemd unit. System services are
+// listed first followed by user units, user sessions and other scopes. One
+// reason is provided for each affected process not associated with a unit.
+func (p *Processes) RebootReasons() []string {
+	units := p.affectedUnits()
+	reasons := make([]string, 0, len(units))
+
+	for _, unit := range units {
+		processesLabel := "processes"
+		if len(unit.processes) == 1 {
+			processesLabel = "process"
+		}
+
+		unitLabel := unit.unit
+		if unit.unitKind != UnitKindService {
+			unitLabel = fmt.Sprintf("%s (%s)", unit.unit, unit.unitKind)
+		}
+
+		reasons = append(reasons, fmt.Sprintf(
+			"%s: %d %s using deleted %s",
+			unitLabel,
+			len(unit.processes),
+			processesLabel,
+			deletedFilesDisplay(unit.processes...),
+		))
+	}
+
+	for _, proc := range p.affected() {
+		if proc.unitKind != UnitKindNone {
+			continue
+		}
+
+		reasons = append(reasons, fmt.Sprintf(
+			"%s (PID %d) using deleted %s",
+			proc.command,
+			proc.pid,
+			deletedFilesDisplay(proc),
+		))
+	}
+
+	return reasons
+}
+
+// Root returns the left-most element of a matched path. This returned value
+// is the process directory within the proc filesystem root.
+func (mp MatchedPath) Root() string {
+	return filepath.Join(mp.procRoot, strconv.Itoa(mp.pid))
+}
+
+// Rel returns the path to the deleted file.
+func (mp MatchedPath) Rel() string {
+	return mp.path
+}
+
+// Base returns the last or right-most "leaf" element of the deleted file
+// path.
+func (mp MatchedPath) Base() string {
+	return filepath.Base(mp.path)
+}
+
+// Full returns the qualified matched path value. This value is composed of
+// the process directory, command name, systemd unit name (if available) and
+// deleted file path.
+func (mp MatchedPath) Full() string {
+	if mp.unit == "" {
+		return fmt.Sprintf("%s (%s): %s", mp.Root(), mp.command, mp.path)
+	}
+
+	return fmt.Sprintf("%s (%s) [%s]: %s", mp.Root(), mp.command, mp.unit, mp.path)
+}
+
+// String provides a human readable version of the matched path value.
+func (mp MatchedPath) String() string {
+	return mp.Full()
+}
+
+// ignoreCandidates returns the values ignore patterns are matched against:
+// the full matched path, the deleted file path and the systemd unit name (if
+// available). Matching the unit name and deleted file path separately allows
+// exact, glob and regex ignore patterns to be used for either since the full
+// matched path includes the process ID.
+func (mp MatchedPath) ignoreCandidates() []string {
+	candidates := []string{mp.Full(), mp.path}
+	if mp.unit != "" {
+		candidates = append(candidates, mp.unit)
+	}
+
+	return candidates
+}
+
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
+
+// PID returns the process ID for the process using the deleted file.
+func (mp MatchedPath) PID() int {
+	return mp.pid
+}
+
+// Command returns the command name for the process using the deleted file.
+func (mp MatchedPath) Command() string {
+	return mp.command
+}
+
+// Unit returns the name of the systemd unit responsible for the process
+// using the deleted file. An empty string is returned if the process is not
+// associated with a unit.
+func (mp MatchedPath) Unit() string {
+	return mp.unit
+}
+
+// UnitKind returns the type of systemd unit responsible for the process
+// using the deleted file.
+func (mp MatchedPath) UnitKind() UnitKind {
+	return mp.unitKind
+}
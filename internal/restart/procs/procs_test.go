@@ -0,0 +1,413 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package procs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// fixtureProcess describes a process to create within a fixture proc tree.
+type fixtureProcess struct {
+	pid     int
+	command string
+	exe     string
+	maps    []string
+	cgroup  []string
+}
+
+// newFixtureProcRoot creates a proc filesystem tree for the given processes
+// and returns the path to the tree.
+func newFixtureProcRoot(t *testing.T, processes []fixtureProcess) string {
+	t.Helper()
+
+	procRoot := t.TempDir()
+
+	for _, proc := range processes {
+		procDir := filepath.Join(procRoot, strconv.Itoa(proc.pid))
+		if err := os.MkdirAll(procDir, 0o750); err != nil {
+			t.Fatalf("ERROR: failed to create fixture process directory: %v", err)
+		}
+
+		files := map[string]string{
+			procCommFile: proc.command + "\n",
+			procMapsFile: strings.Join(proc.maps, "\n") + "\n",
+		}
+
+		if proc.cgroup != nil {
+			files[procCgroupFile] = strings.Join(proc.cgroup, "\n") + "\n"
+		}
+
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(procDir, name), []byte(content), 0o600); err != nil {
+				t.Fatalf("ERROR: failed to create fixture file: %v", err)
+			}
+		}
+
+		if proc.exe != "" {
+			if err := os.Symlink(proc.exe, filepath.Join(procDir, procExeFile)); err != nil {
+				t.Skipf("unable to create fixture symlink: %v", err)
+			}
+		}
+	}
+
+	// Non-process entries are expected to be skipped.
+	if err := os.WriteFile(filepath.Join(procRoot, "uptime"), []byte("1 1\n"), 0o600); err != nil {
+		t.Fatalf("ERROR: failed to create fixture file: %v", err)
+	}
+
+	return procRoot
+}
+
+// TestMappedPath asserts that the pathname field is correctly retrieved from
+// maps file entries.
+func TestMappedPath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 1835213                    /usr/lib/x86_64-linux-gnu/libc.so.6": "/usr/lib/x86_64-linux-gnu/libc.so.6",
+		"7f3c1e400000-7f3c1e428000 r-xp 00000000 fd:01 1835213                    /opt/my app/lib.so (deleted)":        "/opt/my app/lib.so (deleted)",
+		"7ffd5b9e1000-7ffd5ba02000 rw-p 00000000 00:00 0                          [stack]":                             "",
+		"7f3c1e600000-7f3c1e601000 rw-p 00000000 00:00 0 ":                                                             "",
+		"7f3c1e600000-7f3c1e601000 rw-s 00000000 00:01 1024                       /memfd:pulseaudio (deleted)":         "/memfd:pulseaudio (deleted)",
+	}
+
+	for line, want := range tests {
+		if got := mappedPath(line); got != want {
+			t.Errorf("ERROR: mappedPath(%q) = %q; want %q", line, got, want)
+		}
+	}
+}
+
+// TestProcessesEvaluate asserts that processes using deleted executables or
+// mapped files are detected using a fixture proc tree.
+func TestProcessesEvaluate(t *testing.T) {
+	t.Parallel()
+
+	procRoot := newFixtureProcRoot(t, []fixtureProcess{
+		{
+			pid:     1,
+			command: "systemd",
+			exe:     "/usr/lib/systemd/systemd",
+			maps: []string{
+				"55d0c0a00000-55d0c0a2e000 r--p 00000000 fd:01 1 /usr/lib/systemd/systemd",
+				"7f3c1e600000-7f3c1e601000 rw-s 00000000 00:01 2 /memfd:systemd-state (deleted)",
+			},
+		},
+		{
+			pid:     812,
+			command: "nginx",
+			exe:     "/usr/sbin/nginx",
+			maps: []string{
+				"55d0c0a00000-55d0c0a2e000 r--p 00000000 fd:01 10 /usr/sbin/nginx",
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 11 /usr/lib/x86_64-linux-gnu/libssl.so.3 (deleted)",
+				"7f3c1e428000-7f3c1e4a0000 r-xp 00028000 fd:01 11 /usr/lib/x86_64-linux-gnu/libssl.so.3 (deleted)",
+			},
+			cgroup: []string{"0::/system.slice/nginx.service"},
+		},
+		{
+			pid:     813,
+			command: "nginx",
+			exe:     "/usr/sbin/nginx",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 11 /usr/lib/x86_64-linux-gnu/libssl.so.3 (deleted)",
+			},
+			cgroup: []string{"0::/system.slice/nginx.service"},
+		},
+		{
+			pid:     1024,
+			command: "sshd",
+			exe:     "/usr/sbin/sshd (deleted)",
+			maps: []string{
+				"55d0c0a00000-55d0c0a2e000 r--p 00000000 fd:01 20 /usr/sbin/sshd (deleted)",
+				"7f3c1e400000-7f3c1e428000 rw-s 00000000 00:1a 21 /tmp/.session-cache (deleted)",
+			},
+			cgroup: []string{
+				"12:pids:/system.slice/ssh.service",
+				"1:name=systemd:/system.slice/ssh.service",
+				"0::/",
+			},
+		},
+		{
+			pid:     2048,
+			command: "bash",
+			exe:     "/usr/bin/bash",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 30 /usr/lib/x86_64-linux-gnu/libc.so.6 (deleted)",
+			},
+			cgroup: []string{"0::/user.slice/user-1000.slice/session-3.scope"},
+		},
+		{
+			pid:     4096,
+			command: "legacy-daemon",
+			exe:     "/opt/legacy/bin/legacy-daemon",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 40 /opt/legacy/lib/liblegacy.so (deleted)",
+			},
+		},
+		{
+			// Kernel thread; no executable and no mappings.
+			pid:     2,
+			command: "kthreadd",
+		},
+	})
+
+	processes := newTestProcesses(procRoot)
+
+	if err := processes.Validate(); err != nil {
+		t.Fatalf("ERROR: failed to validate assertion: %v", err)
+	}
+
+	processes.Evaluate()
+
+	if err := processes.Err(); err != nil {
+		t.Fatalf("ERROR: unexpected evaluation error: %v", err)
+	}
+
+	if got, want := processes.NumScanned(), 7; got != want {
+		t.Errorf("ERROR: NumScanned() = %d; want %d", got, want)
+	}
+
+	evidence := processes.DiscoveredEvidence()
+	if !evidence.DeletedExecutable || !evidence.DeletedMappedFile {
+		t.Errorf("ERROR: unexpected evidence found: %+v", evidence)
+	}
+
+	// The deleted executable is recorded once even though it is also listed
+	// in the maps file.
+	if got, want := len(processes.MatchedPaths()), 6; got != want {
+		t.Errorf("ERROR: got %d matched paths; want %d", got, want)
+	}
+
+	processes.Filter(restart.SubstringIgnorePatterns(DefaultRestartRequiredIgnoredPaths()))
+
+	if !processes.RebootRequired() {
+		t.Fatal("ERROR: expected restart to be required")
+	}
+
+	if !processes.IsWarningState() {
+		t.Error("ERROR: expected WARNING state")
+	}
+
+	wantReasons := []string{
+		"nginx.service: 2 processes using deleted libssl.so.3",
+		"ssh.service: 1 process using deleted sshd (executable)",
+		"session-3.scope (user session): 1 process using deleted libc.so.6",
+		"legacy-daemon (PID 4096) using deleted liblegacy.so",
+	}
+
+	gotReasons := processes.RebootReasons()
+	if strings.Join(gotReasons, "\n") != strings.Join(wantReasons, "\n") {
+		t.Errorf("ERROR: unexpected reasons\nwant %q\ngot %q", wantReasons, gotReasons)
+	}
+
+	processes.Filter(restart.SubstringIgnorePatterns([]string{"nginx.service"}))
+
+	if got, want := processes.NumAffected(), 3; got != want {
+		t.Errorf("ERROR: NumAffected() = %d after ignoring unit; want %d", got, want)
+	}
+
+	processes.Filter(restart.SubstringIgnorePatterns([]string{"ssh.service", "session-", "legacy-daemon"}))
+
+	if processes.RebootRequired() {
+		t.Error("ERROR: expected restart not required after ignoring all processes")
+	}
+
+	if !processes.IsOKState() {
+		t.Error("ERROR: expected OK state after ignoring all processes")
+	}
+}
+
+// TestUnitFromCgroupPath asserts that the expected systemd unit is
+// determined for cgroup paths.
+func TestUnitFromCgroupPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cgroupPath string
+		wantUnit   string
+		wantKind   UnitKind
+	}{
+		{cgroupPath: "/system.slice/nginx.service", wantUnit: "nginx.service", wantKind: UnitKindService},
+		{cgroupPath: "/system.slice/system-getty.slice/getty@tty1.service", wantUnit: "getty@tty1.service", wantKind: UnitKindService},
+		{cgroupPath: "/system.slice/containerd.service/runtime", wantUnit: "containerd.service", wantKind: UnitKindService},
+		{cgroupPath: "/user.slice/user-1000.slice/session-3.scope", wantUnit: "session-3.scope", wantKind: UnitKindSession},
+		{cgroupPath: "/user.slice/user-1000.slice/user@1000.service/app.slice/pipewire.service", wantUnit: "pipewire.service", wantKind: UnitKindUser},
+		{cgroupPath: "/user.slice/user-1000.slice/user@1000.service/init.scope", wantUnit: "user@1000.service", wantKind: UnitKindService},
+		{cgroupPath: "/init.scope", wantUnit: "init.scope", wantKind: UnitKindScope},
+		{cgroupPath: "/system.slice/docker-0123abcd.scope", wantUnit: "docker-0123abcd.scope", wantKind: UnitKindScope},
+		{cgroupPath: "/system.slice/var-lib-data.mount", wantUnit: "var-lib-data.mount", wantKind: UnitKindOther},
+		{cgroupPath: "/", wantUnit: "", wantKind: UnitKindNone},
+		{cgroupPath: "", wantUnit: "", wantKind: UnitKindNone},
+	}
+
+	for _, tt := range tests {
+		gotUnit, gotKind := unitFromCgroupPath(tt.cgroupPath)
+		if gotUnit != tt.wantUnit || gotKind != tt.wantKind {
+			t.Errorf(
+				"ERROR: unitFromCgroupPath(%q) = (%q, %s); want (%q, %s)",
+				tt.cgroupPath, gotUnit, gotKind, tt.wantUnit, tt.wantKind,
+			)
+		}
+	}
+}
+
+// TestSystemdCgroupPath asserts that cgroup v1 and v2 entries are parsed as
+// expected.
+func TestSystemdCgroupPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{line: "0::/system.slice/nginx.service", want: "/system.slice/nginx.service", wantOK: true},
+		{line: "1:name=systemd:/system.slice/nginx.service", want: "/system.slice/nginx.service", wantOK: true},
+		{line: "4:memory:/system.slice/nginx.service", want: "", wantOK: false},
+		{line: "0::/", want: "", wantOK: false},
+		{line: "invalid", want: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, gotOK := systemdCgroupPath(tt.line)
+		if got != tt.want || gotOK != tt.wantOK {
+			t.Errorf(
+				"ERROR: systemdCgroupPath(%q) = (%q, %t); want (%q, %t)",
+				tt.line, got, gotOK, tt.want, tt.wantOK,
+			)
+		}
+	}
+}
+
+// TestProcessesEvaluateMissingProcRoot asserts that an unavailable proc root
+// is recorded as an error.
+func TestProcessesEvaluateMissingProcRoot(t *testing.T) {
+	t.Parallel()
+
+	processes := newTestProcesses(
+		filepath.Join(t.TempDir(), "missing"),
+	)
+
+	processes.Evaluate()
+
+	if processes.Err() == nil {
+		t.Fatal("ERROR: expected error for missing proc root")
+	}
+
+	if !processes.IsCriticalState() {
+		t.Error("ERROR: expected CRITICAL state for evaluation error")
+	}
+}
+
+// newTestProcesses returns a Processes value using the specified proc root
+// with all supported evidence expected.
+func newTestProcesses(procRoot string) *Processes {
+	return &Processes{
+		procRoot: procRoot,
+		evidenceExpected: ProcessRestartEvidence{
+			DeletedExecutable: true,
+			DeletedMappedFile: true,
+		},
+	}
+}
+
+// TestProcessesFilterKinds asserts that exact, glob and regex ignore patterns
+// are matched against the systemd unit name and deleted file path of each
+// matched path in addition to the full matched path.
+func TestProcessesFilterKinds(t *testing.T) {
+	t.Parallel()
+
+	procRoot := newFixtureProcRoot(t, []fixtureProcess{
+		{
+			pid:     812,
+			command: "nginx",
+			exe:     "/usr/sbin/nginx",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 11 /usr/lib/x86_64-linux-gnu/libssl.so.3 (deleted)",
+			},
+			cgroup: []string{"0::/system.slice/nginx.service"},
+		},
+		{
+			pid:     813,
+			command: "nginx",
+			exe:     "/usr/sbin/nginx",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 11 /usr/lib/x86_64-linux-gnu/libssl.so.3 (deleted)",
+			},
+			cgroup: []string{"0::/system.slice/nginx.service"},
+		},
+		{
+			pid:     4096,
+			command: "legacy-daemon",
+			exe:     "/opt/legacy/bin/legacy-daemon",
+			maps: []string{
+				"7f3c1e400000-7f3c1e428000 r--p 00000000 fd:01 40 /opt/legacy/lib/liblegacy.so (deleted)",
+			},
+		},
+	})
+
+	tests := map[string]struct {
+		pattern      string
+		wantAffected int
+	}{
+		"exact unit name": {
+			pattern:      "process:exact:nginx.service",
+			wantAffected: 1,
+		},
+		"glob unit name": {
+			pattern:      "process:glob:*.service",
+			wantAffected: 1,
+		},
+		"anchored regex unit name": {
+			pattern:      `process:regex:^nginx\.service$`,
+			wantAffected: 1,
+		},
+		"exact deleted file path": {
+			pattern:      "process:exact:/opt/legacy/lib/liblegacy.so",
+			wantAffected: 2,
+		},
+		"glob deleted file path": {
+			pattern:      "process:glob:/usr/lib/**/libssl.so.*",
+			wantAffected: 1,
+		},
+		"exact partial unit name": {
+			pattern:      "process:exact:nginx",
+			wantAffected: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ignorePattern, err := restart.ParseIgnorePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("ERROR: failed to parse ignore pattern %q: %v", tt.pattern, err)
+			}
+
+			processes := newTestProcesses(procRoot)
+			processes.Evaluate()
+
+			if err := processes.Err(); err != nil {
+				t.Fatalf("ERROR: unexpected evaluation error: %v", err)
+			}
+
+			processes.Filter(restart.IgnorePatterns{ignorePattern})
+
+			if got := processes.NumAffected(); got != tt.wantAffected {
+				t.Errorf("ERROR: NumAffected() = %d after applying %q; want %d", got, tt.pattern, tt.wantAffected)
+			}
+		})
+	}
+}
@@ -9,27 +9,14 @@
 
 package registry
 
-// NOTE: This package is not intended for non-Windows systems.
-
 import (
 	"github.com/atc0005/check-restart/internal/restart"
 )
 
-// DefaultRebootRequiredIgnoredPaths provides the default collection of paths
-// for registry related reboot required assertions that should be ignored.
-//
-// Paths are normalized before comparison with matched paths.
-//
-// For consistency, these entries should match the default path syntax for the
-// operating system in question.
-func DefaultRebootRequiredIgnoredPaths() []string {
-
-	logger.Println("WARNING: This tool is not supported for non-Windows systems!")
-	return []string{}
-}
-
 // DefaultRebootRequiredAssertions provides the default collection of registry
-// related reboot required assertions.
+// related reboot required assertions. Non-Windows systems do not have a
+// registry; see DefaultRebootRequiredAssertionsWithBackend to evaluate the
+// default assertions against registry data from another source.
 func DefaultRebootRequiredAssertions() restart.RebootRequiredAsserters {
 
 	logger.Println("WARNING: This tool is not supported for non-Windows systems!")
@@ -0,0 +1,154 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// RootKey represents a predefined registry root key (e.g.,
+// HKEY_LOCAL_MACHINE). The values match the predefined handles used by the
+// Windows API.
+type RootKey uint32
+
+// Registry root keys.
+const (
+	RootKeyClassesRoot     RootKey = 0x80000000
+	RootKeyCurrentUser     RootKey = 0x80000001
+	RootKeyLocalMachine    RootKey = 0x80000002
+	RootKeyUsers           RootKey = 0x80000003
+	RootKeyPerformanceData RootKey = 0x80000004
+	RootKeyCurrentConfig   RootKey = 0x80000005
+)
+
+// Registry value type codes. The values match the REG_* constants used by
+// the Windows API.
+// https://learn.microsoft.com/en-us/windows/win32/sysinfo/registry-value-types
+const (
+	ValueTypeNone                     uint32 = 0
+	ValueTypeSZ                       uint32 = 1
+	ValueTypeExpandSZ                 uint32 = 2
+	ValueTypeBinary                   uint32 = 3
+	ValueTypeDWORD                    uint32 = 4
+	ValueTypeDWORDBigEndian           uint32 = 5
+	ValueTypeLink                     uint32 = 6
+	ValueTypeMultiSZ                  uint32 = 7
+	ValueTypeResourceList             uint32 = 8
+	ValueTypeFullResourceDescriptor   uint32 = 9
+	ValueTypeResourceRequirementsList uint32 = 10
+	ValueTypeQWORD                    uint32 = 11
+)
+
+// keyPathSeparator is the separator used between registry key path
+// elements.
+const keyPathSeparator string = `\`
+
+// Backend represents a source of registry data. This is usually the registry
+// of the local system, but may also be an in-memory collection of keys and
+// values loaded from an exported registry file.
+type Backend interface {
+	// OpenKey opens the key at the given path beneath the specified root
+	// key. ErrNotExist is returned if the key does not exist.
+	OpenKey(root RootKey, path string) (BackendKey, error)
+}
+
+// BackendKey represents an open registry key provided by a Backend.
+type BackendKey interface {
+	// ReadValue returns the raw data and type code for the named value.
+	// ErrNotExist is returned if the value does not exist.
+	ReadValue(name string) ([]byte, uint32, error)
+
+	// ReadValueType returns the type code for the named value. ErrNotExist
+	// is returned if the value does not exist.
+	ReadValueType(name string) (uint32, error)
+
+	// ReadSubKeyNames returns the names of all subkeys of the key.
+	ReadSubKeyNames() ([]string, error)
+
+	// Close releases any resources associated with the open key.
+	Close() error
+}
+
+// backendSetter is implemented by registry assertions which allow the
+// source of registry data to be specified.
+type backendSetter interface {
+	SetBackend(backend Backend)
+}
+
+// UseBackend sets the source of registry data for each registry assertion in
+// the given collection. Assertions of other types are skipped.
+func UseBackend(assertions restart.RebootRequiredAsserters, backend Backend) {
+	for _, assertion := range assertions {
+		if setter, ok := assertion.(backendSetter); ok {
+			setter.SetBackend(backend)
+		}
+	}
+}
+
+// ParseRootKey returns the RootKey for the given root key name. Both the
+// full (e.g., HKEY_LOCAL_MACHINE) and abbreviated (e.g., HKLM) forms of the
+// name are accepted.
+func ParseRootKey(name string) (RootKey, error) {
+	switch strings.ToUpper(name) {
+	case RegKeyRootNameClassesRoot, "HKCR":
+		return RootKeyClassesRoot, nil
+	case RegKeyRootNameCurrentUser, "HKCU":
+		return RootKeyCurrentUser, nil
+	case RegKeyRootNameLocalMachine, "HKLM":
+		return RootKeyLocalMachine, nil
+	case RegKeyRootNameUsers, "HKU":
+		return RootKeyUsers, nil
+	case RegKeyRootNameCurrentConfig, "HKCC":
+		return RootKeyCurrentConfig, nil
+	case RegKeyRootNamePerformanceData:
+		return RootKeyPerformanceData, nil
+	default:
+		return 0, fmt.Errorf("root key %q: %w", name, ErrInvalidRootKey)
+	}
+}
+
+// splitKeyPath splits a fully qualified registry key path (e.g.,
+// HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft) into the root key and the
+// remaining (unqualified) path.
+func splitKeyPath(fullPath string) (RootKey, string, error) {
+	rootName, path, _ := strings.Cut(fullPath, keyPathSeparator)
+
+	root, err := ParseRootKey(rootName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return root, strings.Trim(path, keyPathSeparator), nil
+}
+
+// joinKeyPath joins the given registry key path elements using the registry
+// key path separator. Unlike filepath.Join this behaves the same on all
+// platforms.
+func joinKeyPath(elems ...string) string {
+	nonEmpty := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		if elem = strings.Trim(elem, keyPathSeparator); elem != "" {
+			nonEmpty = append(nonEmpty, elem)
+		}
+	}
+
+	return strings.Join(nonEmpty, keyPathSeparator)
+}
+
+// baseKeyPath returns the last element of the given registry key path.
+func baseKeyPath(path string) string {
+	path = strings.TrimRight(path, keyPathSeparator)
+	if i := strings.LastIndex(path, keyPathSeparator); i >= 0 {
+		return path[i+1:]
+	}
+
+	return path
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+// unsupportedBackend is used when a Backend is not specified on a system
+// without a registry.
+type unsupportedBackend struct{}
+
+// defaultBackend returns the Backend used when one is not specified.
+func defaultBackend() Backend {
+	return unsupportedBackend{}
+}
+
+// OpenKey returns ErrUnsupportedOS; there is no registry to open keys from.
+func (unsupportedBackend) OpenKey(_ RootKey, _ string) (BackendKey, error) {
+	return nil, ErrUnsupportedOS
+}
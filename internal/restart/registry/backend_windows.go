@@ -0,0 +1,105 @@
+//go:build windows
+
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Add "implements assertions" to fail the build if the Backend
+// implementation isn't correct.
+var (
+	_ Backend    = SystemBackend{}
+	_ BackendKey = (*systemKey)(nil)
+)
+
+// SystemBackend provides access to the registry of the local system.
+type SystemBackend struct{}
+
+// systemKey is an open key in the registry of the local system.
+type systemKey struct {
+	key registry.Key
+}
+
+// defaultBackend returns the Backend used when one is not specified.
+func defaultBackend() Backend {
+	return SystemBackend{}
+}
+
+// OpenKey opens the key at the given path beneath the specified root key.
+func (SystemBackend) OpenKey(root RootKey, path string) (BackendKey, error) {
+	// Enumerating subkeys requires requesting access to do so along with
+	// permission to query values.
+	//
+	// We specify both permissions by combining the values via OR.
+	// https://stackoverflow.com/questions/47814070/golang-cant-enumerate-subkeys-of-registry-key
+	key, err := registry.OpenKey(
+		registry.Key(root),
+		path,
+		registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS,
+	)
+	switch {
+	case errors.Is(err, registry.ErrNotExist):
+		return nil, ErrNotExist
+	case err != nil:
+		return nil, err
+	}
+
+	return &systemKey{key: key}, nil
+}
+
+// ReadValue returns the raw data and type code for the named value.
+func (sk *systemKey) ReadValue(name string) ([]byte, uint32, error) {
+	bufSize, _, err := sk.key.GetValue(name, nil)
+	switch {
+	case errors.Is(err, registry.ErrNotExist):
+		return nil, 0, ErrNotExist
+	case err != nil:
+		return nil, 0, err
+	}
+
+	buffer := make([]byte, bufSize)
+	n, valType, err := sk.key.GetValue(name, buffer)
+	if err != nil {
+		return nil, valType, fmt.Errorf(
+			"failed to retrieve data for value %s: %w",
+			name,
+			err,
+		)
+	}
+
+	return buffer[:n], valType, nil
+}
+
+// ReadValueType returns the type code for the named value.
+func (sk *systemKey) ReadValueType(name string) (uint32, error) {
+	_, valType, err := sk.key.GetValue(name, nil)
+	switch {
+	case errors.Is(err, registry.ErrNotExist):
+		return 0, ErrNotExist
+	case err != nil:
+		return 0, err
+	}
+
+	return valType, nil
+}
+
+// ReadSubKeyNames returns the names of all subkeys of the key.
+func (sk *systemKey) ReadSubKeyNames() ([]string, error) {
+	return sk.key.ReadSubKeyNames(0)
+}
+
+// Close releases the handle to the open key.
+func (sk *systemKey) Close() error {
+	return sk.key.Close()
+}
@@ -9,5 +9,8 @@
 // registry keys and values in order to determine the need for a service
 // restart or system reboot.
 //
-// This package is not intended for non-Windows systems.
+// Registry data is retrieved using a Backend. By default the registry of the
+// local Windows system is used. A MemoryBackend populated from exported
+// registry files (.reg) allows evaluating registry assertions on non-Windows
+// systems, such as when examining a registry export from another host.
 package registry
@@ -0,0 +1,638 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrInvalidHive indicates that the content of a registry hive file could
+// not be parsed.
+var ErrInvalidHive = errors.New("invalid registry hive")
+
+// Add "implements assertions" to fail the build if the Backend
+// implementation isn't correct.
+var (
+	_ Backend    = (*HiveBackend)(nil)
+	_ BackendKey = (*hiveKey)(nil)
+)
+
+// Registry hive file layout details.
+//
+// See also:
+//
+// - https://github.com/msuhanov/regf/blob/master/Windows%20registry%20file%20format%20specification.md
+// - https://github.com/libyal/libregf/blob/main/documentation/Windows%20NT%20Registry%20File%20(REGF)%20format.asciidoc
+const (
+	// hiveBaseBlockSize is the size of the base block (file header) which
+	// precedes the hive bins.
+	hiveBaseBlockSize int = 4096
+
+	// hiveBaseBlockSignature is the signature at the start of a hive file.
+	hiveBaseBlockSignature string = "regf"
+
+	// hiveBaseBlockMinorVersionOffset is the offset of the minor version in
+	// the base block. Big data cells are used starting with minor version 4.
+	hiveBaseBlockMinorVersionOffset int = 0x18
+
+	// hiveBaseBlockRootCellOffset is the offset of the root key cell offset
+	// in the base block.
+	hiveBaseBlockRootCellOffset int = 0x24
+
+	// hiveBigDataMinorVersion is the minor version starting with which
+	// values with large data use big data cells.
+	hiveBigDataMinorVersion uint32 = 4
+
+	// hiveBigDataSegmentSize is the maximum size of each segment of value
+	// data stored using a big data cell.
+	hiveBigDataSegmentSize uint32 = 16344
+
+	// hiveMaxIndexDepth is the maximum depth of nested subkey lists (index
+	// roots) followed when enumerating subkeys. This guards against loops in
+	// corrupted hives.
+	hiveMaxIndexDepth int = 4
+)
+
+// Cell signatures.
+const (
+	hiveCellKeyNode    string = "nk"
+	hiveCellValue      string = "vk"
+	hiveCellFastLeaf   string = "lf"
+	hiveCellHashLeaf   string = "lh"
+	hiveCellIndexLeaf  string = "li"
+	hiveCellIndexRoot  string = "ri"
+	hiveCellBigData    string = "db"
+	hiveKeyNodeMinSize int    = 0x4C
+	hiveValueMinSize   int    = 0x14
+)
+
+// Flags used by key node and value cells.
+const (
+	// hiveKeyFlagCompressedName indicates that a key name is stored using
+	// single byte (Latin-1) characters instead of UTF-16.
+	hiveKeyFlagCompressedName uint16 = 0x0020
+
+	// hiveValueFlagCompressedName indicates that a value name is stored
+	// using single byte (Latin-1) characters instead of UTF-16.
+	hiveValueFlagCompressedName uint16 = 0x0001
+
+	// hiveValueDataInline is set in the data size of a value when the data
+	// (4 bytes or less) is stored in place of the data offset.
+	hiveValueDataInline uint32 = 0x80000000
+)
+
+// Hive is a read-only registry hive loaded from a hive file (e.g., the
+// SOFTWARE or SYSTEM files from the System32\config folder of a Windows
+// installation).
+//
+// Transaction log files (e.g., SYSTEM.LOG1) are not applied; changes not yet
+// written to the hive file by the system which owns it are not visible.
+type Hive struct {
+	// name identifies the source of the hive for error messages.
+	name string
+
+	// bins is the content of the hive following the base block. Cell
+	// offsets are relative to the start of this data.
+	bins []byte
+
+	// rootCell is the offset of the root key node.
+	rootCell uint32
+
+	// minorVersion is the minor version of the hive format.
+	minorVersion uint32
+}
+
+// hiveKeyNode represents the details of a key node cell needed to navigate
+// a hive.
+type hiveKeyNode struct {
+	name        string
+	numSubKeys  uint32
+	subKeysList uint32
+	numValues   uint32
+	valuesList  uint32
+}
+
+// hiveValue represents a value cell.
+type hiveValue struct {
+	name       string
+	dataSize   uint32
+	dataOffset uint32
+	valType    uint32
+}
+
+// hiveKey is an open key within a Hive.
+type hiveKey struct {
+	hive *Hive
+	node hiveKeyNode
+}
+
+// LoadHive loads the registry hive from the given hive file.
+func LoadHive(filename string) (*Hive, error) {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry hive: %w", err)
+	}
+
+	hive, err := ParseHive(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return hive, nil
+}
+
+// ParseHive parses the content of a registry hive file. The given name is
+// used to identify the hive in error messages.
+func ParseHive(name string, data []byte) (*Hive, error) {
+	if len(data) < hiveBaseBlockSize || !bytes.HasPrefix(data, []byte(hiveBaseBlockSignature)) {
+		return nil, fmt.Errorf("%s: missing base block: %w", name, ErrInvalidHive)
+	}
+
+	// The primary and secondary sequence numbers differ if the hive was not
+	// cleanly written (e.g., the system was not shut down cleanly).
+	if seq1, seq2 := binary.LittleEndian.Uint32(data[4:]), binary.LittleEndian.Uint32(data[8:]); seq1 != seq2 {
+		logger.Printf(
+			"Hive %s is dirty (sequence numbers %d and %d); transaction logs are not applied",
+			name,
+			seq1,
+			seq2,
+		)
+	}
+
+	hive := Hive{
+		name:         name,
+		bins:         data[hiveBaseBlockSize:],
+		rootCell:     binary.LittleEndian.Uint32(data[hiveBaseBlockRootCellOffset:]),
+		minorVersion: binary.LittleEndian.Uint32(data[hiveBaseBlockMinorVersionOffset:]),
+	}
+
+	if _, err := hive.keyNode(hive.rootCell); err != nil {
+		return nil, fmt.Errorf("%s: invalid root key: %w", name, err)
+	}
+
+	return &hive, nil
+}
+
+// errorf wraps ErrInvalidHive with the name of the hive and the given
+// details.
+func (h *Hive) errorf(offset uint32, format string, args ...interface{}) error {
+	return fmt.Errorf(
+		"%s: cell at offset %#x: %s: %w",
+		h.name,
+		offset,
+		fmt.Sprintf(format, args...),
+		ErrInvalidHive,
+	)
+}
+
+// cell returns the data of the allocated cell at the given offset.
+func (h *Hive) cell(offset uint32) ([]byte, error) {
+	start := int(offset)
+	if start < 0 || start+4 > len(h.bins) {
+		return nil, h.errorf(offset, "offset out of range")
+	}
+
+	// Allocated cells use a negative size.
+	size := int32(binary.LittleEndian.Uint32(h.bins[start:]))
+	if size >= 0 {
+		return nil, h.errorf(offset, "cell is not allocated")
+	}
+
+	end := start + int(-size)
+	if -size < 4 || end > len(h.bins) {
+		return nil, h.errorf(offset, "invalid cell size %d", -size)
+	}
+
+	return h.bins[start+4 : end], nil
+}
+
+// signedCell returns the data of the allocated cell at the given offset
+// after asserting that the cell has one of the given signatures. The
+// signature of the cell is returned along with the data.
+func (h *Hive) signedCell(offset uint32, signatures ...string) (string, []byte, error) {
+	data, err := h.cell(offset)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(data) >= 2 {
+		for _, signature := range signatures {
+			if string(data[:2]) == signature {
+				return signature, data, nil
+			}
+		}
+	}
+
+	return "", nil, h.errorf(offset, "expected %v cell", signatures)
+}
+
+// keyNode parses the key node cell at the given offset.
+func (h *Hive) keyNode(offset uint32) (hiveKeyNode, error) {
+	_, data, err := h.signedCell(offset, hiveCellKeyNode)
+	if err != nil {
+		return hiveKeyNode{}, err
+	}
+
+	if len(data) < hiveKeyNodeMinSize {
+		return hiveKeyNode{}, h.errorf(offset, "truncated key node")
+	}
+
+	flags := binary.LittleEndian.Uint16(data[0x02:])
+	nameLength := int(binary.LittleEndian.Uint16(data[0x48:]))
+	if hiveKeyNodeMinSize+nameLength > len(data) {
+		return hiveKeyNode{}, h.errorf(offset, "truncated key name")
+	}
+
+	return hiveKeyNode{
+		name: decodeHiveName(
+			data[hiveKeyNodeMinSize:hiveKeyNodeMinSize+nameLength],
+			flags&hiveKeyFlagCompressedName != 0,
+		),
+		numSubKeys:  binary.LittleEndian.Uint32(data[0x14:]),
+		subKeysList: binary.LittleEndian.Uint32(data[0x1C:]),
+		numValues:   binary.LittleEndian.Uint32(data[0x24:]),
+		valuesList:  binary.LittleEndian.Uint32(data[0x28:]),
+	}, nil
+}
+
+// subKeyOffsets returns the offsets of the key node cells for all subkeys
+// of the given key node. The number of subkeys recorded for the key node is
+// not trusted for allocation; the subkey lists must agree with it.
+func (h *Hive) subKeyOffsets(node hiveKeyNode) ([]uint32, error) {
+	if node.numSubKeys == 0 {
+		return nil, nil
+	}
+
+	offsets, err := h.appendSubKeyList(
+		nil,
+		node.subKeysList,
+		0,
+		int(node.numSubKeys),
+		make(map[uint32]struct{}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(offsets) != int(node.numSubKeys) {
+		return nil, h.errorf(
+			node.subKeysList,
+			"subkey list has %d of %d subkeys",
+			len(offsets),
+			node.numSubKeys,
+		)
+	}
+
+	return offsets, nil
+}
+
+// appendSubKeyList appends the offsets of the key node cells listed by the
+// subkey list cell at the given offset. Index roots (lists of subkey lists)
+// are followed up to hiveMaxIndexDepth levels. An error is returned as soon
+// as more than maxSubKeys offsets are listed or a subkey list is referenced
+// more than once (as recorded by visited).
+func (h *Hive) appendSubKeyList(
+	offsets []uint32,
+	listOffset uint32,
+	depth int,
+	maxSubKeys int,
+	visited map[uint32]struct{},
+) ([]uint32, error) {
+	if depth > hiveMaxIndexDepth {
+		return nil, h.errorf(listOffset, "subkey lists nested too deeply")
+	}
+
+	if _, ok := visited[listOffset]; ok {
+		return nil, h.errorf(listOffset, "subkey list referenced more than once")
+	}
+	visited[listOffset] = struct{}{}
+
+	signature, data, err := h.signedCell(
+		listOffset,
+		hiveCellFastLeaf,
+		hiveCellHashLeaf,
+		hiveCellIndexLeaf,
+		hiveCellIndexRoot,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, h.errorf(listOffset, "truncated subkey list")
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[2:]))
+
+	// Fast and hash leaf elements include a hash of the key name along with
+	// the offset of the key node.
+	elementSize := 4
+	if signature == hiveCellFastLeaf || signature == hiveCellHashLeaf {
+		elementSize = 8
+	}
+
+	if 4+count*elementSize > len(data) {
+		return nil, h.errorf(listOffset, "truncated subkey list")
+	}
+
+	if signature != hiveCellIndexRoot && len(offsets)+count > maxSubKeys {
+		return nil, h.errorf(listOffset, "subkey lists have more than %d subkeys", maxSubKeys)
+	}
+
+	for i := 0; i < count; i++ {
+		offset := binary.LittleEndian.Uint32(data[4+i*elementSize:])
+
+		if signature == hiveCellIndexRoot {
+			if offsets, err = h.appendSubKeyList(offsets, offset, depth+1, maxSubKeys, visited); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		offsets = append(offsets, offset)
+	}
+
+	return offsets, nil
+}
+
+// values parses all value cells for the given key node.
+func (h *Hive) values(node hiveKeyNode) ([]hiveValue, error) {
+	if node.numValues == 0 {
+		return nil, nil
+	}
+
+	data, err := h.cell(node.valuesList)
+	if err != nil {
+		return nil, err
+	}
+
+	if int(node.numValues)*4 > len(data) {
+		return nil, h.errorf(node.valuesList, "truncated values list")
+	}
+
+	values := make([]hiveValue, 0, node.numValues)
+	for i := 0; i < int(node.numValues); i++ {
+		value, err := h.value(binary.LittleEndian.Uint32(data[i*4:]))
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// value parses the value cell at the given offset.
+func (h *Hive) value(offset uint32) (hiveValue, error) {
+	_, data, err := h.signedCell(offset, hiveCellValue)
+	if err != nil {
+		return hiveValue{}, err
+	}
+
+	if len(data) < hiveValueMinSize {
+		return hiveValue{}, h.errorf(offset, "truncated value")
+	}
+
+	nameLength := int(binary.LittleEndian.Uint16(data[0x02:]))
+	flags := binary.LittleEndian.Uint16(data[0x10:])
+	if hiveValueMinSize+nameLength > len(data) {
+		return hiveValue{}, h.errorf(offset, "truncated value name")
+	}
+
+	return hiveValue{
+		name: decodeHiveName(
+			data[hiveValueMinSize:hiveValueMinSize+nameLength],
+			flags&hiveValueFlagCompressedName != 0,
+		),
+		dataSize:   binary.LittleEndian.Uint32(data[0x04:]),
+		dataOffset: binary.LittleEndian.Uint32(data[0x08:]),
+		valType:    binary.LittleEndian.Uint32(data[0x0C:]),
+	}, nil
+}
+
+// valueData retrieves the data for the given value.
+func (h *Hive) valueData(value hiveValue) ([]byte, error) {
+	size := value.dataSize &^ hiveValueDataInline
+
+	// Small values are stored in place of the data offset.
+	if value.dataSize&hiveValueDataInline != 0 {
+		if size > 4 {
+			return nil, fmt.Errorf(
+				"%s: value %s: invalid inline data size %d: %w",
+				h.name,
+				value.name,
+				size,
+				ErrInvalidHive,
+			)
+		}
+
+		inline := make([]byte, 4)
+		binary.LittleEndian.PutUint32(inline, value.dataOffset)
+
+		return inline[:size], nil
+	}
+
+	if size == 0 {
+		return []byte{}, nil
+	}
+
+	data, err := h.cell(value.dataOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > hiveBigDataSegmentSize &&
+		h.minorVersion >= hiveBigDataMinorVersion &&
+		bytes.HasPrefix(data, []byte(hiveCellBigData)) {
+		return h.bigData(value.dataOffset, data, size)
+	}
+
+	if int(size) > len(data) {
+		return nil, h.errorf(value.dataOffset, "truncated value data")
+	}
+
+	return append([]byte(nil), data[:size]...), nil
+}
+
+// bigData retrieves value data stored as a list of segments using a big
+// data cell.
+func (h *Hive) bigData(offset uint32, cell []byte, size uint32) ([]byte, error) {
+	if len(cell) < 8 {
+		return nil, h.errorf(offset, "truncated big data cell")
+	}
+
+	count := int(binary.LittleEndian.Uint16(cell[2:]))
+	listOffset := binary.LittleEndian.Uint32(cell[4:])
+
+	list, err := h.cell(listOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	if count*4 > len(list) {
+		return nil, h.errorf(listOffset, "truncated big data segment list")
+	}
+
+	// The data size is not trusted for allocation unless the segments (and
+	// the hive itself) are able to hold that much data.
+	if uint64(size) > uint64(count)*uint64(hiveBigDataSegmentSize) || int64(size) > int64(len(h.bins)) {
+		return nil, h.errorf(offset, "invalid big data size %d for %d segments", size, count)
+	}
+
+	data := make([]byte, 0, size)
+	for i := 0; i < count && uint32(len(data)) < size; i++ {
+		segmentOffset := binary.LittleEndian.Uint32(list[i*4:])
+
+		segment, err := h.cell(segmentOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := size - uint32(len(data))
+		segmentSize := min(remaining, hiveBigDataSegmentSize, uint32(len(segment)))
+
+		data = append(data, segment[:segmentSize]...)
+	}
+
+	if uint32(len(data)) != size {
+		return nil, h.errorf(offset, "big data has %d of %d bytes", len(data), size)
+	}
+
+	return data, nil
+}
+
+// OpenKey opens the key at the given path relative to the root key of the
+// hive. An empty path opens the root key.
+func (h *Hive) OpenKey(path string) (BackendKey, error) {
+	node, err := h.keyNode(h.rootCell)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, elem := range strings.Split(path, keyPathSeparator) {
+		if elem == "" {
+			continue
+		}
+
+		offsets, err := h.subKeyOffsets(node)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, offset := range offsets {
+			subKey, err := h.keyNode(offset)
+			if err != nil {
+				return nil, err
+			}
+
+			if strings.EqualFold(subKey.name, elem) {
+				node = subKey
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, ErrNotExist
+		}
+	}
+
+	return &hiveKey{hive: h, node: node}, nil
+}
+
+// ReadValue returns the raw data and type code for the named value.
+func (hk *hiveKey) ReadValue(name string) ([]byte, uint32, error) {
+	value, err := hk.find(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := hk.hive.valueData(value)
+	if err != nil {
+		return nil, value.valType, err
+	}
+
+	return data, value.valType, nil
+}
+
+// ReadValueType returns the type code for the named value.
+func (hk *hiveKey) ReadValueType(name string) (uint32, error) {
+	value, err := hk.find(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return value.valType, nil
+}
+
+// find returns the named value of the key.
+func (hk *hiveKey) find(name string) (hiveValue, error) {
+	values, err := hk.hive.values(hk.node)
+	if err != nil {
+		return hiveValue{}, err
+	}
+
+	for _, value := range values {
+		if strings.EqualFold(value.name, name) {
+			return value, nil
+		}
+	}
+
+	return hiveValue{}, ErrNotExist
+}
+
+// ReadSubKeyNames returns the names of all subkeys of the key.
+func (hk *hiveKey) ReadSubKeyNames() ([]string, error) {
+	offsets, err := hk.hive.subKeyOffsets(hk.node)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(offsets))
+	for _, offset := range offsets {
+		subKey, err := hk.hive.keyNode(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, subKey.name)
+	}
+
+	return names, nil
+}
+
+// Close is a NOOP; there are no resources associated with an open key.
+func (hk *hiveKey) Close() error {
+	return nil
+}
+
+// decodeHiveName decodes a key or value name stored using single byte
+// (Latin-1) characters if compressed or UTF-16 otherwise.
+func decodeHiveName(raw []byte, compressed bool) string {
+	if !compressed {
+		return string(utf16.Decode(bytesToUTF16(raw)))
+	}
+
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
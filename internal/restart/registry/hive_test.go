@@ -0,0 +1,551 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"unicode/utf16"
+)
+
+// testHiveKey describes a key used to build a test hive.
+type testHiveKey struct {
+	name    string
+	subKeys []testHiveKey
+	values  []testHiveValue
+
+	// listType is the subkey list type used for the key. An index root
+	// (ri) references a single index leaf (li) listing the subkeys.
+	listType string
+}
+
+// testHiveValue describes a value used to build a test hive.
+type testHiveValue struct {
+	name    string
+	valType uint32
+	data    []byte
+}
+
+// hiveBuilder assembles the cells of a single hive bin.
+type hiveBuilder struct {
+	bin []byte
+}
+
+// addCell appends an allocated cell holding the given data and returns the
+// offset of the cell.
+func (hb *hiveBuilder) addCell(data []byte) uint32 {
+	offset := uint32(len(hb.bin))
+
+	size := (4 + len(data) + 7) &^ 7
+	cell := make([]byte, size)
+	binary.LittleEndian.PutUint32(cell, uint32(-int32(size)))
+	copy(cell[4:], data)
+
+	hb.bin = append(hb.bin, cell...)
+
+	return offset
+}
+
+// addValue appends the cells for the given value and returns the offset of
+// the value cell.
+func (hb *hiveBuilder) addValue(value testHiveValue) uint32 {
+	dataSize := uint32(len(value.data))
+
+	var dataOffset uint32
+	switch {
+	case dataSize <= 4:
+		inline := make([]byte, 4)
+		copy(inline, value.data)
+		dataOffset = binary.LittleEndian.Uint32(inline)
+		dataSize |= hiveValueDataInline
+
+	case dataSize > hiveBigDataSegmentSize:
+		var segments []byte
+		for start := 0; start < len(value.data); start += int(hiveBigDataSegmentSize) {
+			end := min(start+int(hiveBigDataSegmentSize), len(value.data))
+			segments = binary.LittleEndian.AppendUint32(segments, hb.addCell(value.data[start:end]))
+		}
+
+		list := hb.addCell(segments)
+		db := []byte(hiveCellBigData)
+		db = binary.LittleEndian.AppendUint16(db, uint16(len(segments)/4))
+		db = binary.LittleEndian.AppendUint32(db, list)
+		dataOffset = hb.addCell(db)
+
+	default:
+		dataOffset = hb.addCell(value.data)
+	}
+
+	vk := make([]byte, hiveValueMinSize)
+	copy(vk, hiveCellValue)
+	binary.LittleEndian.PutUint16(vk[0x02:], uint16(len(value.name)))
+	binary.LittleEndian.PutUint32(vk[0x04:], dataSize)
+	binary.LittleEndian.PutUint32(vk[0x08:], dataOffset)
+	binary.LittleEndian.PutUint32(vk[0x0C:], value.valType)
+	binary.LittleEndian.PutUint16(vk[0x10:], hiveValueFlagCompressedName)
+	vk = append(vk, value.name...)
+
+	return hb.addCell(vk)
+}
+
+// addKey appends the cells for the given key (and all subkeys and values)
+// and returns the offset of the key node cell. Key names containing
+// non-ASCII characters are stored as UTF-16.
+func (hb *hiveBuilder) addKey(key testHiveKey) uint32 {
+	var subKeysList uint32
+	if len(key.subKeys) > 0 {
+		elements := make([]byte, 0, len(key.subKeys)*8)
+		for _, subKey := range key.subKeys {
+			elements = binary.LittleEndian.AppendUint32(elements, hb.addKey(subKey))
+			if key.listType != hiveCellIndexLeaf && key.listType != hiveCellIndexRoot {
+				// The name hash is not used when reading.
+				elements = binary.LittleEndian.AppendUint32(elements, 0)
+			}
+		}
+
+		listType := key.listType
+		if listType == "" {
+			listType = hiveCellHashLeaf
+		}
+
+		if listType == hiveCellIndexRoot {
+			leaf := binary.LittleEndian.AppendUint16([]byte(hiveCellIndexLeaf), uint16(len(key.subKeys)))
+			leafOffset := hb.addCell(append(leaf, elements...))
+
+			elements = binary.LittleEndian.AppendUint32(nil, leafOffset)
+			listType = hiveCellIndexRoot
+		}
+
+		count := len(key.subKeys)
+		if listType == hiveCellIndexRoot {
+			count = 1
+		}
+
+		list := binary.LittleEndian.AppendUint16([]byte(listType), uint16(count))
+		subKeysList = hb.addCell(append(list, elements...))
+	}
+
+	var valuesList uint32
+	if len(key.values) > 0 {
+		offsets := make([]byte, 0, len(key.values)*4)
+		for _, value := range key.values {
+			offsets = binary.LittleEndian.AppendUint32(offsets, hb.addValue(value))
+		}
+
+		valuesList = hb.addCell(offsets)
+	}
+
+	name := []byte(key.name)
+	flags := hiveKeyFlagCompressedName
+	for _, r := range key.name {
+		if r > 0x7F {
+			name = nil
+			for _, unit := range utf16.Encode([]rune(key.name)) {
+				name = binary.LittleEndian.AppendUint16(name, unit)
+			}
+			flags = 0
+
+			break
+		}
+	}
+
+	nk := make([]byte, hiveKeyNodeMinSize)
+	copy(nk, hiveCellKeyNode)
+	binary.LittleEndian.PutUint16(nk[0x02:], flags)
+	binary.LittleEndian.PutUint32(nk[0x14:], uint32(len(key.subKeys)))
+	binary.LittleEndian.PutUint32(nk[0x1C:], subKeysList)
+	binary.LittleEndian.PutUint32(nk[0x24:], uint32(len(key.values)))
+	binary.LittleEndian.PutUint32(nk[0x28:], valuesList)
+	binary.LittleEndian.PutUint16(nk[0x48:], uint16(len(name)))
+	nk = append(nk, name...)
+
+	return hb.addCell(nk)
+}
+
+// buildHive returns the content of a hive file with the given root key.
+func buildHive(root testHiveKey) []byte {
+	hb := hiveBuilder{bin: make([]byte, 0x20)}
+	rootCell := hb.addKey(root)
+
+	// Hive bins are sized in multiples of 4096 bytes.
+	for len(hb.bin)%hiveBaseBlockSize != 0 {
+		hb.bin = append(hb.bin, 0)
+	}
+
+	copy(hb.bin, "hbin")
+	binary.LittleEndian.PutUint32(hb.bin[0x08:], uint32(len(hb.bin)))
+
+	base := make([]byte, hiveBaseBlockSize)
+	copy(base, hiveBaseBlockSignature)
+	binary.LittleEndian.PutUint32(base[0x04:], 1)
+	binary.LittleEndian.PutUint32(base[0x08:], 1)
+	binary.LittleEndian.PutUint32(base[0x14:], 1)
+	binary.LittleEndian.PutUint32(base[hiveBaseBlockMinorVersionOffset:], 5)
+	binary.LittleEndian.PutUint32(base[hiveBaseBlockRootCellOffset:], rootCell)
+	binary.LittleEndian.PutUint32(base[0x28:], uint32(len(hb.bin)))
+
+	return append(base, hb.bin...)
+}
+
+// testPath returns a chain of keys for the given path elements with the
+// given values assigned to the last key.
+func testPath(values []testHiveValue, elems ...string) testHiveKey {
+	key := testHiveKey{name: elems[len(elems)-1], values: values}
+	for i := len(elems) - 2; i >= 0; i-- {
+		key = testHiveKey{name: elems[i], subKeys: []testHiveKey{key}}
+	}
+
+	return key
+}
+
+// testSystemHive returns a SYSTEM hive where control set 2 is in use and
+// the computer is pending a rename.
+func testSystemHive() []byte {
+	computerName := func(name string, value string) testHiveKey {
+		return testHiveKey{
+			name: name,
+			values: []testHiveValue{
+				{name: "ComputerName", valType: ValueTypeSZ, data: encodeString(value)},
+			},
+		}
+	}
+
+	controlSet := func(name string, pending string) testHiveKey {
+		return testHiveKey{
+			name: name,
+			subKeys: []testHiveKey{
+				{
+					name: "Control",
+					subKeys: []testHiveKey{
+						{
+							name:     "ComputerName",
+							listType: hiveCellIndexRoot,
+							subKeys: []testHiveKey{
+								computerName("ActiveComputerName", "WEB01"),
+								computerName("ComputerName", pending),
+							},
+						},
+					},
+				},
+				testPath(nil, "Services", "Netlogon"),
+			},
+		}
+	}
+
+	return buildHive(testHiveKey{
+		name:     "ROOT",
+		listType: hiveCellIndexLeaf,
+		subKeys: []testHiveKey{
+			controlSet("ControlSet001", "WEB01"),
+			controlSet("ControlSet002", "WEB02"),
+			testPath([]testHiveValue{
+				{name: "Current", valType: ValueTypeDWORD, data: encodeDWORD(2)},
+			}, "Select"),
+		},
+	})
+}
+
+// testSoftwareHive returns a SOFTWARE hive with a variety of value types and
+// evidence of a pending reboot.
+func testSoftwareHive() []byte {
+	bigData := bytes.Repeat([]byte{0xAB, 0xCD}, 20000)
+
+	return buildHive(testHiveKey{
+		name:     "ROOT",
+		listType: hiveCellFastLeaf,
+		subKeys: []testHiveKey{
+			{
+				name: "Microsoft",
+				subKeys: []testHiveKey{
+					testPath([]testHiveValue{
+						{name: "UpdateExeVolatile", valType: ValueTypeDWORD, data: encodeDWORD(0)},
+					}, "Updates"),
+					testPath([]testHiveValue{
+						{name: "SystemRoot", valType: ValueTypeSZ, data: encodeString(`C:\WINDOWS`)},
+					}, "Windows NT", "CurrentVersion"),
+					{
+						name: "Windows",
+						subKeys: []testHiveKey{
+							{
+								name: "CurrentVersion",
+								subKeys: []testHiveKey{
+									testPath(nil, "RunOnce"),
+									testPath(nil, "WindowsUpdate", "Auto Update", "RebootRequired"),
+								},
+							},
+						},
+					},
+				},
+			},
+			testPath([]testHiveValue{
+				{name: "", valType: ValueTypeSZ, data: encodeString("default")},
+				{name: "Paths", valType: ValueTypeMultiSZ, data: encodeStrings([]string{`\??\C:\a`, `\??\C:\b`})},
+				{name: "Big", valType: ValueTypeQWORD, data: encodeQWORD(1 << 40)},
+				{name: "Blob", valType: ValueTypeBinary, data: bigData},
+				{name: "Flag", valType: ValueTypeDWORD, data: encodeDWORD(7)},
+			}, "Vendor", "Pr\u00fcfung"),
+		},
+	})
+}
+
+// TestHiveValues asserts that keys and values are read from a hive using
+// each supported subkey list and value storage format.
+func TestHiveValues(t *testing.T) {
+	t.Parallel()
+
+	hive, err := ParseHive("SOFTWARE", testSoftwareHive())
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse hive: %v", err)
+	}
+
+	key, err := hive.OpenKey("vendor\\PR\u00dcFUNG")
+	if err != nil {
+		t.Fatalf("ERROR: failed to open key with UTF-16 name: %v", err)
+	}
+
+	if got, _, err := readStringValue(key, ""); err != nil || got != "default" {
+		t.Errorf("ERROR: default value = %q, %v; want %q", got, err, "default")
+	}
+
+	wantStrings := []string{`\??\C:\a`, `\??\C:\b`}
+	if got, _, err := readStringsValue(key, "paths"); err != nil || !reflect.DeepEqual(got, wantStrings) {
+		t.Errorf("ERROR: multi-string value = %q, %v; want %q", got, err, wantStrings)
+	}
+
+	if got, _, err := readIntegerValue(key, "Big"); err != nil || got != 1<<40 {
+		t.Errorf("ERROR: qword value = %d, %v; want %d", got, err, uint64(1<<40))
+	}
+
+	if got, _, err := readIntegerValue(key, "Flag"); err != nil || got != 7 {
+		t.Errorf("ERROR: inline dword value = %d, %v; want 7", got, err)
+	}
+
+	wantBlob := bytes.Repeat([]byte{0xAB, 0xCD}, 20000)
+	if got, _, err := readBinaryValue(key, "Blob"); err != nil || !bytes.Equal(got, wantBlob) {
+		t.Errorf("ERROR: big data value has %d bytes, %v; want %d bytes", len(got), err, len(wantBlob))
+	}
+
+	if _, err := key.ReadValueType("Missing"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("ERROR: expected ErrNotExist for missing value; got %v", err)
+	}
+
+	if _, err := hive.OpenKey(`Vendor\Missing`); !errors.Is(err, ErrNotExist) {
+		t.Errorf("ERROR: expected ErrNotExist for missing key; got %v", err)
+	}
+
+	root, err := hive.OpenKey("")
+	if err != nil {
+		t.Fatalf("ERROR: failed to open root key: %v", err)
+	}
+
+	wantNames := []string{"Microsoft", "Vendor"}
+	if got, err := root.ReadSubKeyNames(); err != nil || !reflect.DeepEqual(got, wantNames) {
+		t.Errorf("ERROR: subkey names = %q, %v; want %q", got, err, wantNames)
+	}
+}
+
+// TestParseHiveInvalid asserts that malformed hives are rejected.
+func TestParseHiveInvalid(t *testing.T) {
+	t.Parallel()
+
+	valid := testSoftwareHive()
+
+	truncated := valid[:hiveBaseBlockSize+0x20]
+
+	badRoot := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(badRoot[hiveBaseBlockRootCellOffset:], 0x7FFFFFF0)
+
+	tests := map[string][]byte{
+		"empty":           {},
+		"bad signature":   append([]byte("xxxx"), valid[4:]...),
+		"truncated bins":  truncated,
+		"root out of bin": badRoot,
+	}
+
+	for name, data := range tests {
+		if _, err := ParseHive(name, data); !errors.Is(err, ErrInvalidHive) {
+			t.Errorf("ERROR: %s: expected ErrInvalidHive; got %v", name, err)
+		}
+	}
+}
+
+// TestHiveSubKeyCountInvalid asserts that a key node whose subkey count does
+// not agree with its subkey list is rejected instead of being trusted.
+func TestHiveSubKeyCountInvalid(t *testing.T) {
+	t.Parallel()
+
+	data := testSoftwareHive()
+
+	// The subkey count of the root key node follows the cell size.
+	rootCell := binary.LittleEndian.Uint32(data[hiveBaseBlockRootCellOffset:])
+	binary.LittleEndian.PutUint32(data[hiveBaseBlockSize+int(rootCell)+4+0x14:], 0xFFFFFFFF)
+
+	hive, err := ParseHive("bad subkey count", data)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse hive: %v", err)
+	}
+
+	if _, err := hive.OpenKey("Microsoft"); !errors.Is(err, ErrInvalidHive) {
+		t.Errorf("ERROR: expected ErrInvalidHive opening subkey; got %v", err)
+	}
+
+	root, err := hive.OpenKey("")
+	if err != nil {
+		t.Fatalf("ERROR: failed to open root key: %v", err)
+	}
+
+	if _, err := root.ReadSubKeyNames(); !errors.Is(err, ErrInvalidHive) {
+		t.Errorf("ERROR: expected ErrInvalidHive reading subkey names; got %v", err)
+	}
+}
+
+// TestHiveSubKeyListRepeated asserts that an index root listing the same
+// subkey list more than once is rejected, even if the number of subkeys
+// listed agrees with the key node.
+func TestHiveSubKeyListRepeated(t *testing.T) {
+	t.Parallel()
+
+	data := buildHive(testHiveKey{
+		name:     "ROOT",
+		listType: hiveCellIndexRoot,
+		subKeys:  []testHiveKey{{name: "A"}, {name: "B"}},
+	})
+
+	// The single index leaf listed by the index root is listed again in the
+	// unused space of the index root cell.
+	rootNode := hiveBaseBlockSize + int(binary.LittleEndian.Uint32(data[hiveBaseBlockRootCellOffset:])) + 4
+	indexRoot := hiveBaseBlockSize + int(binary.LittleEndian.Uint32(data[rootNode+0x1C:])) + 4
+	binary.LittleEndian.PutUint16(data[indexRoot+2:], 2)
+	copy(data[indexRoot+8:indexRoot+12], data[indexRoot+4:indexRoot+8])
+	binary.LittleEndian.PutUint32(data[rootNode+0x14:], 4)
+
+	hive, err := ParseHive("repeated subkey list", data)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse hive: %v", err)
+	}
+
+	root, err := hive.OpenKey("")
+	if err != nil {
+		t.Fatalf("ERROR: failed to open root key: %v", err)
+	}
+
+	if names, err := root.ReadSubKeyNames(); !errors.Is(err, ErrInvalidHive) {
+		t.Errorf("ERROR: expected ErrInvalidHive; got %q, %v", names, err)
+	}
+}
+
+// TestHiveBigDataSizeInvalid asserts that a big data value whose size
+// exceeds its segments is rejected without allocating the claimed size. This
+// test is not run in parallel so that allocations by other tests are not
+// counted.
+func TestHiveBigDataSizeInvalid(t *testing.T) {
+	data := testSoftwareHive()
+
+	// The name of a value follows the fixed size portion of the value cell.
+	vk := -1
+	for i := 0; i+hiveValueMinSize+4 <= len(data); i++ {
+		if bytes.HasPrefix(data[i:], []byte(hiveCellValue)) &&
+			bytes.HasPrefix(data[i+hiveValueMinSize:], []byte("Blob")) {
+			vk = i
+
+			break
+		}
+	}
+
+	if vk < 0 {
+		t.Fatal("ERROR: value cell for big data value not found")
+	}
+
+	binary.LittleEndian.PutUint32(data[vk+0x04:], 0x7FFFFFFF)
+
+	hive, err := ParseHive("bad big data size", data)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse hive: %v", err)
+	}
+
+	key, err := hive.OpenKey("Vendor\\Pr\u00fcfung")
+	if err != nil {
+		t.Fatalf("ERROR: failed to open key: %v", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	_, _, err = key.ReadValue("Blob")
+
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrInvalidHive) {
+		t.Errorf("ERROR: expected ErrInvalidHive; got %v", err)
+	}
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("ERROR: allocated %d bytes reading value with invalid size", allocated)
+	}
+}
+
+// TestLoadWindowsRoot asserts that the default registry and file assertions
+// can be evaluated against an offline Windows system.
+func TestLoadWindowsRoot(t *testing.T) {
+	t.Parallel()
+
+	// Mounted filesystems may not use the same case as a running system.
+	windowsRoot := t.TempDir()
+	configDir := filepath.Join(windowsRoot, "WINDOWS", "system32", "config")
+	if err := os.MkdirAll(configDir, 0o750); err != nil {
+		t.Fatalf("ERROR: failed to create fixture directory: %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"SOFTWARE": testSoftwareHive(),
+		"SYSTEM":   testSystemHive(),
+	} {
+		if err := os.WriteFile(filepath.Join(configDir, name), data, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create fixture file: %v", err)
+		}
+	}
+
+	backend, err := LoadWindowsRoot(windowsRoot)
+	if err != nil {
+		t.Fatalf("ERROR: failed to load offline system: %v", err)
+	}
+
+	if got, want := OfflineSystemRoot(windowsRoot, backend), filepath.Join(windowsRoot, "WINDOWS"); got != want {
+		t.Errorf("ERROR: OfflineSystemRoot() = %q; want %q", got, want)
+	}
+
+	// CurrentControlSet is resolved using the Select key.
+	key, err := backend.OpenKey(RootKeyLocalMachine, `SYSTEM\CurrentControlSet\Control\ComputerName\ComputerName`)
+	if err != nil {
+		t.Fatalf("ERROR: failed to open key via CurrentControlSet: %v", err)
+	}
+
+	if got, _, err := readStringValue(key, "ComputerName"); err != nil || got != "WEB02" {
+		t.Errorf("ERROR: ComputerName = %q, %v; want %q", got, err, "WEB02")
+	}
+
+	assertions := DefaultRebootRequiredAssertionsWithBackend(backend)
+	assertions.Evaluate(context.Background())
+
+	if assertions.HasErrors(false) {
+		t.Errorf("ERROR: unexpected errors for offline system: %v", assertions.Errs(false))
+	}
+
+	// The RebootRequired key and mismatched computer names are expected to
+	// match.
+	if got, want := assertions.NumMatched(), 2; got != want {
+		t.Errorf("ERROR: got %d matched assertions; want %d", got, want)
+	}
+}
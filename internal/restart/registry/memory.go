@@ -0,0 +1,219 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// Add "implements assertions" to fail the build if the Backend
+// implementation isn't correct.
+var (
+	_ Backend    = (*MemoryBackend)(nil)
+	_ BackendKey = (*memoryKey)(nil)
+)
+
+// MemoryBackend is an in-memory collection of registry keys and values. It
+// is intended for evaluating registry assertions against registry data
+// exported from another system (e.g., a .reg file) or test fixtures.
+//
+// As with the Windows registry, key and value names are case-insensitive.
+// A MemoryBackend should not be modified while assertions are evaluated.
+type MemoryBackend struct {
+	roots map[RootKey]*memoryKey
+}
+
+// memoryKey is a registry key stored by a MemoryBackend.
+type memoryKey struct {
+	// name is the name of the key as originally specified.
+	name string
+
+	// subKeys is the collection of subkeys indexed by lowercase name.
+	subKeys map[string]*memoryKey
+
+	// values is the collection of values indexed by lowercase name.
+	values map[string]memoryValue
+}
+
+// memoryValue is a registry value stored by a MemoryBackend.
+type memoryValue struct {
+	valType uint32
+	data    []byte
+}
+
+// NewMemoryBackend creates an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		roots: make(map[RootKey]*memoryKey),
+	}
+}
+
+// newMemoryKey creates an empty memoryKey with the given name.
+func newMemoryKey(name string) *memoryKey {
+	return &memoryKey{
+		name:    name,
+		subKeys: make(map[string]*memoryKey),
+		values:  make(map[string]memoryValue),
+	}
+}
+
+// find returns the key at the given path beneath the specified root key or
+// nil if it does not exist. If create is true, any missing keys along the
+// path are created.
+func (mb *MemoryBackend) find(root RootKey, path string, create bool) *memoryKey {
+	current, ok := mb.roots[root]
+	if !ok {
+		if !create {
+			return nil
+		}
+
+		current = newMemoryKey(getRootKeyName(root))
+		mb.roots[root] = current
+	}
+
+	for _, elem := range strings.Split(path, keyPathSeparator) {
+		if elem == "" {
+			continue
+		}
+
+		next, ok := current.subKeys[strings.ToLower(elem)]
+		if !ok {
+			if !create {
+				return nil
+			}
+
+			next = newMemoryKey(elem)
+			current.subKeys[strings.ToLower(elem)] = next
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+// CreateKey creates the key at the given path beneath the specified root
+// key along with any missing parent keys.
+func (mb *MemoryBackend) CreateKey(root RootKey, path string) {
+	mb.find(root, path, true)
+}
+
+// DeleteKey removes the key (and all subkeys) at the given path beneath the
+// specified root key. Removing a key that does not exist is not an error.
+func (mb *MemoryBackend) DeleteKey(root RootKey, path string) {
+	path = strings.Trim(path, keyPathSeparator)
+
+	parentPath, name := "", path
+	if i := strings.LastIndex(path, keyPathSeparator); i >= 0 {
+		parentPath, name = path[:i], path[i+1:]
+	}
+
+	if name == "" {
+		delete(mb.roots, root)
+		return
+	}
+
+	if parent := mb.find(root, parentPath, false); parent != nil {
+		delete(parent.subKeys, strings.ToLower(name))
+	}
+}
+
+// SetValue sets the raw data and type code for the named value of the key
+// at the given path, creating the key if needed. An empty name refers to the
+// default value of the key.
+func (mb *MemoryBackend) SetValue(root RootKey, path string, name string, valType uint32, data []byte) {
+	key := mb.find(root, path, true)
+	key.values[strings.ToLower(name)] = memoryValue{
+		valType: valType,
+		data:    append([]byte(nil), data...),
+	}
+}
+
+// SetStringValue sets the named REG_SZ value of the key at the given path.
+func (mb *MemoryBackend) SetStringValue(root RootKey, path string, name string, value string) {
+	mb.SetValue(root, path, name, ValueTypeSZ, encodeString(value))
+}
+
+// SetStringsValue sets the named REG_MULTI_SZ value of the key at the given
+// path.
+func (mb *MemoryBackend) SetStringsValue(root RootKey, path string, name string, values []string) {
+	mb.SetValue(root, path, name, ValueTypeMultiSZ, encodeStrings(values))
+}
+
+// SetDWordValue sets the named REG_DWORD value of the key at the given path.
+func (mb *MemoryBackend) SetDWordValue(root RootKey, path string, name string, value uint32) {
+	mb.SetValue(root, path, name, ValueTypeDWORD, encodeDWORD(value))
+}
+
+// SetQWordValue sets the named REG_QWORD value of the key at the given path.
+func (mb *MemoryBackend) SetQWordValue(root RootKey, path string, name string, value uint64) {
+	mb.SetValue(root, path, name, ValueTypeQWORD, encodeQWORD(value))
+}
+
+// SetBinaryValue sets the named REG_BINARY value of the key at the given
+// path.
+func (mb *MemoryBackend) SetBinaryValue(root RootKey, path string, name string, value []byte) {
+	mb.SetValue(root, path, name, ValueTypeBinary, value)
+}
+
+// DeleteValue removes the named value from the key at the given path.
+// Removing a value that does not exist is not an error.
+func (mb *MemoryBackend) DeleteValue(root RootKey, path string, name string) {
+	if key := mb.find(root, path, false); key != nil {
+		delete(key.values, strings.ToLower(name))
+	}
+}
+
+// OpenKey opens the key at the given path beneath the specified root key.
+func (mb *MemoryBackend) OpenKey(root RootKey, path string) (BackendKey, error) {
+	key := mb.find(root, path, false)
+	if key == nil {
+		return nil, ErrNotExist
+	}
+
+	return key, nil
+}
+
+// ReadValue returns a copy of the raw data and the type code for the named
+// value.
+func (mk *memoryKey) ReadValue(name string) ([]byte, uint32, error) {
+	value, ok := mk.values[strings.ToLower(name)]
+	if !ok {
+		return nil, 0, ErrNotExist
+	}
+
+	return append([]byte(nil), value.data...), value.valType, nil
+}
+
+// ReadValueType returns the type code for the named value.
+func (mk *memoryKey) ReadValueType(name string) (uint32, error) {
+	value, ok := mk.values[strings.ToLower(name)]
+	if !ok {
+		return 0, ErrNotExist
+	}
+
+	return value.valType, nil
+}
+
+// ReadSubKeyNames returns the sorted names of all subkeys of the key.
+func (mk *memoryKey) ReadSubKeyNames() ([]string, error) {
+	names := make([]string, 0, len(mk.subKeys))
+	for _, subKey := range mk.subKeys {
+		names = append(names, subKey.name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Close is a NOOP; there are no resources associated with an open key.
+func (mk *memoryKey) Close() error {
+	return nil
+}
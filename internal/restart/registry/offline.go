@@ -0,0 +1,222 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Keys and values used to resolve details of an offline Windows system.
+const (
+	// currentControlSetKey is the name of the key which (on a running
+	// system) links to the control set in use. The link is not present in
+	// the SYSTEM hive file.
+	currentControlSetKey string = "CurrentControlSet"
+
+	// selectKeyPath is the path within the SYSTEM hive of the key which
+	// records the control set in use.
+	selectKeyPath string = "Select"
+
+	// selectCurrentValue is the value recording the number of the control
+	// set in use.
+	selectCurrentValue string = "Current"
+
+	// currentVersionKeyPath is the path of the key which records the
+	// location of the Windows installation.
+	currentVersionKeyPath string = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+	// systemRootValue is the value recording the location of the Windows
+	// installation (e.g., C:\Windows).
+	systemRootValue string = "SystemRoot"
+
+	// defaultSystemRoot is the location of the Windows installation used if
+	// the location cannot be determined from the registry.
+	defaultSystemRoot string = `C:\Windows`
+)
+
+// offlineHives is the collection of hive files (relative to the root of the
+// system drive) loaded for an offline Windows system along with the
+// HKEY_LOCAL_MACHINE key each is mounted as.
+var offlineHives = []struct {
+	file    string
+	keyPath string
+}{
+	{file: `Windows\System32\config\SOFTWARE`, keyPath: "SOFTWARE"},
+	{file: `Windows\System32\config\SYSTEM`, keyPath: "SYSTEM"},
+}
+
+// HiveBackend provides registry data from hives mounted at specific key
+// paths, much like the registry of a running system is assembled from hive
+// files.
+type HiveBackend struct {
+	mounts []hiveMount
+}
+
+// hiveMount is a Hive mounted at a key path beneath a root key.
+type hiveMount struct {
+	root  RootKey
+	path  string
+	hive  *Hive
+	alias string
+}
+
+// NewHiveBackend creates a HiveBackend without any mounted hives.
+func NewHiveBackend() *HiveBackend {
+	return &HiveBackend{}
+}
+
+// Mount makes the keys and values of the given hive available beneath the
+// given key path (e.g., HKEY_LOCAL_MACHINE\SOFTWARE).
+//
+// If the hive contains a Select key (as the SYSTEM hive does) references to
+// the CurrentControlSet key are resolved to the control set recorded as
+// being in use.
+func (hb *HiveBackend) Mount(root RootKey, path string, hive *Hive) {
+	mount := hiveMount{
+		root: root,
+		path: strings.Trim(path, keyPathSeparator),
+		hive: hive,
+	}
+
+	if key, err := hive.OpenKey(selectKeyPath); err == nil {
+		current, _, err := readIntegerValue(key, selectCurrentValue)
+		switch {
+		case err != nil:
+			logger.Printf("Failed to determine current control set for hive %s: %v", hive.name, err)
+		default:
+			mount.alias = fmt.Sprintf("ControlSet%03d", current)
+			logger.Printf("Resolving %s as %s for hive %s", currentControlSetKey, mount.alias, hive.name)
+		}
+	}
+
+	hb.mounts = append(hb.mounts, mount)
+}
+
+// OpenKey opens the key at the given path beneath the specified root key.
+func (hb *HiveBackend) OpenKey(root RootKey, path string) (BackendKey, error) {
+	path = strings.Trim(path, keyPathSeparator)
+
+	for _, mount := range hb.mounts {
+		if mount.root != root {
+			continue
+		}
+
+		var rest string
+		switch {
+		case strings.EqualFold(path, mount.path):
+		case len(path) > len(mount.path) &&
+			strings.EqualFold(path[:len(mount.path)], mount.path) &&
+			path[len(mount.path):len(mount.path)+1] == keyPathSeparator:
+			rest = path[len(mount.path)+1:]
+		default:
+			continue
+		}
+
+		if mount.alias != "" {
+			first, remaining, _ := strings.Cut(rest, keyPathSeparator)
+			if strings.EqualFold(first, currentControlSetKey) {
+				rest = joinKeyPath(mount.alias, remaining)
+			}
+		}
+
+		return mount.hive.OpenKey(rest)
+	}
+
+	return nil, ErrNotExist
+}
+
+// LoadWindowsRoot creates a HiveBackend for the offline Windows system with
+// a system drive mounted at the given path (e.g., /mnt/c). The SOFTWARE and
+// SYSTEM hives are mounted beneath HKEY_LOCAL_MACHINE.
+func LoadWindowsRoot(windowsRoot string) (*HiveBackend, error) {
+	hb := NewHiveBackend()
+
+	for _, offlineHive := range offlineHives {
+		hiveFile := resolvePathFold(windowsRoot, offlineHive.file)
+
+		hive, err := LoadHive(hiveFile)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to load %s hive for offline system: %w",
+				offlineHive.keyPath,
+				err,
+			)
+		}
+
+		hb.Mount(RootKeyLocalMachine, offlineHive.keyPath, hive)
+	}
+
+	return hb, nil
+}
+
+// OfflineSystemRoot returns the location (beneath the given path where the
+// system drive is mounted) of the Windows installation for an offline
+// system. The location recorded in the registry is used if available.
+func OfflineSystemRoot(windowsRoot string, backend Backend) string {
+	systemRoot := defaultSystemRoot
+
+	key, err := backend.OpenKey(RootKeyLocalMachine, currentVersionKeyPath)
+	if err == nil {
+		defer func() { _ = key.Close() }()
+
+		value, _, err := readStringValue(key, systemRootValue)
+		switch {
+		case err != nil:
+			logger.Printf("Failed to read %s value; using %s: %v", systemRootValue, defaultSystemRoot, err)
+		case value != "":
+			systemRoot = value
+		}
+	}
+
+	// Drop the drive letter; the given path is where the system drive is
+	// mounted.
+	if len(systemRoot) >= 2 && systemRoot[1] == ':' {
+		systemRoot = systemRoot[2:]
+	}
+
+	return resolvePathFold(windowsRoot, systemRoot)
+}
+
+// resolvePathFold joins the given Windows path (using backslashes as
+// separators) to the given root path. Path elements are matched
+// case-insensitively against existing entries since filesystems mounted on
+// other platforms may be case-sensitive. Elements without a match are used
+// as-is.
+func resolvePathFold(root string, windowsPath string) string {
+	resolved := root
+
+	for _, elem := range strings.Split(windowsPath, keyPathSeparator) {
+		if elem == "" {
+			continue
+		}
+
+		candidate := filepath.Join(resolved, elem)
+		if _, err := os.Lstat(candidate); !errors.Is(err, os.ErrNotExist) {
+			resolved = candidate
+			continue
+		}
+
+		entries, err := os.ReadDir(resolved)
+		if err == nil {
+			for _, entry := range entries {
+				if strings.EqualFold(entry.Name(), elem) {
+					candidate = filepath.Join(resolved, entry.Name())
+					break
+				}
+			}
+		}
+
+		resolved = candidate
+	}
+
+	return resolved
+}
@@ -0,0 +1,369 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrInvalidRegFile indicates that the content of an exported registry file
+// (.reg) could not be parsed.
+var ErrInvalidRegFile = errors.New("invalid registry export file")
+
+// Header lines used by exported registry files.
+const (
+	regFileHeaderV5 string = "Windows Registry Editor Version 5.00"
+	regFileHeaderV4 string = "REGEDIT4"
+)
+
+// Prefixes used for value data in exported registry files.
+const (
+	regDataPrefixDWORD   string = "dword:"
+	regDataPrefixHex     string = "hex:"
+	regDataPrefixHexType string = "hex("
+)
+
+// Byte order marks used to detect the encoding of exported registry files.
+var (
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// LoadRegFiles creates a MemoryBackend populated from the given exported
+// registry files (.reg). Files are imported in the order given; later files
+// may add to, replace or delete keys and values from earlier files.
+func LoadRegFiles(filenames ...string) (*MemoryBackend, error) {
+	mb := NewMemoryBackend()
+
+	for _, filename := range filenames {
+		if err := mb.ImportRegFile(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	return mb, nil
+}
+
+// ImportRegFile imports the keys and values from the given exported registry
+// file (.reg).
+func (mb *MemoryBackend) ImportRegFile(filename string) error {
+	fh, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return fmt.Errorf("failed to open registry file: %w", err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", filename, err)
+		}
+	}()
+
+	if err := mb.ImportReg(fh); err != nil {
+		return fmt.Errorf("failed to import registry file %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// ImportReg imports the keys and values from the content of an exported
+// registry file. Both the "Windows Registry Editor Version 5.00" (UTF-16 or
+// UTF-8 encoded) and "REGEDIT4" formats are supported.
+//
+// As when importing a file using the Registry Editor, keys prefixed with a
+// hyphen (e.g., [-HKEY_LOCAL_MACHINE\SOFTWARE\Example]) and values set to a
+// hyphen (e.g., "Example"=-) are deleted.
+func (mb *MemoryBackend) ImportReg(r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(decodeRegText(raw), "\n")
+
+	var (
+		version    int
+		root       RootKey
+		path       string
+		keyOpen    bool
+		keyDeleted bool
+	)
+
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(strings.TrimRight(lines[i], "\r"))
+
+		// Join continuation lines used to wrap long hex data.
+		for strings.HasSuffix(line, `\`) && !strings.HasPrefix(line, "[") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, `\`) +
+				strings.TrimSpace(strings.TrimRight(lines[i], "\r"))
+		}
+
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+			continue
+
+		case version == 0:
+			switch line {
+			case regFileHeaderV5:
+				version = 5
+			case regFileHeaderV4:
+				version = 4
+			default:
+				return regFileError(lineNum, "missing file header", line)
+			}
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return regFileError(lineNum, "unterminated key", line)
+			}
+
+			keyPath := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			keyDeleted = strings.HasPrefix(keyPath, "-")
+
+			var err error
+			root, path, err = splitKeyPath(strings.TrimPrefix(keyPath, "-"))
+			if err != nil {
+				return regFileError(lineNum, err.Error(), line)
+			}
+
+			keyOpen = true
+
+			switch {
+			case keyDeleted:
+				mb.DeleteKey(root, path)
+			default:
+				mb.CreateKey(root, path)
+			}
+
+		case !keyOpen:
+			return regFileError(lineNum, "value specified before key", line)
+
+		case keyDeleted:
+			// Values listed beneath a deleted key are ignored.
+			continue
+
+		default:
+			name, data, ok := parseRegValueName(line)
+			if !ok {
+				return regFileError(lineNum, "invalid value name", line)
+			}
+
+			if data == "-" {
+				mb.DeleteValue(root, path, name)
+				continue
+			}
+
+			valType, value, err := parseRegValueData(data, version)
+			if err != nil {
+				return regFileError(lineNum, err.Error(), line)
+			}
+
+			mb.SetValue(root, path, name, valType, value)
+		}
+	}
+
+	if version == 0 {
+		return fmt.Errorf("missing file header: %w", ErrInvalidRegFile)
+	}
+
+	return nil
+}
+
+// regFileError wraps ErrInvalidRegFile with details of the offending line.
+func regFileError(lineNum int, msg string, line string) error {
+	return fmt.Errorf("line %d: %s: %q: %w", lineNum, msg, line, ErrInvalidRegFile)
+}
+
+// decodeRegText decodes the content of an exported registry file using the
+// encoding indicated by the byte order mark (if present). Content without a
+// byte order mark is treated as UTF-8.
+func decodeRegText(raw []byte) string {
+	var order binary.ByteOrder
+
+	switch {
+	case bytes.HasPrefix(raw, bomUTF16LE):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(raw, bomUTF16BE):
+		order = binary.BigEndian
+	case bytes.HasPrefix(raw, bomUTF8):
+		return string(raw[len(bomUTF8):])
+	default:
+		return string(raw)
+	}
+
+	raw = raw[2:]
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = order.Uint16(raw[i*2:])
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// parseRegValueName parses the value name from the start of a value line,
+// returning the name and the (unparsed) data following the equals sign. An
+// at sign (@) refers to the default value of a key and is returned as an
+// empty name.
+func parseRegValueName(line string) (string, string, bool) {
+	var (
+		name string
+		rest string
+	)
+
+	switch {
+	case strings.HasPrefix(line, "@"):
+		rest = line[1:]
+
+	case strings.HasPrefix(line, `"`):
+		var ok bool
+		name, rest, ok = parseRegQuotedString(line)
+		if !ok {
+			return "", "", false
+		}
+
+	default:
+		return "", "", false
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return "", "", false
+	}
+
+	return name, strings.TrimSpace(rest[1:]), true
+}
+
+// parseRegQuotedString parses a quoted string from the start of the given
+// text, returning the unescaped string and any remaining text.
+func parseRegQuotedString(text string) (string, string, bool) {
+	var sb strings.Builder
+
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) && (text[i+1] == '\\' || text[i+1] == '"') {
+				i++
+			}
+			sb.WriteByte(text[i])
+
+		case '"':
+			return sb.String(), text[i+1:], true
+
+		default:
+			sb.WriteByte(text[i])
+		}
+	}
+
+	return "", "", false
+}
+
+// parseRegValueData parses the data for a value, returning the value type
+// code and the raw data as stored in the registry.
+func parseRegValueData(data string, version int) (uint32, []byte, error) {
+	switch {
+	case strings.HasPrefix(data, `"`):
+		value, rest, ok := parseRegQuotedString(data)
+		if !ok || strings.TrimSpace(rest) != "" {
+			return 0, nil, errors.New("invalid string data")
+		}
+
+		return ValueTypeSZ, encodeString(value), nil
+
+	case strings.HasPrefix(strings.ToLower(data), regDataPrefixDWORD):
+		value, err := strconv.ParseUint(data[len(regDataPrefixDWORD):], 16, 32)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid dword data: %w", err)
+		}
+
+		return ValueTypeDWORD, encodeDWORD(uint32(value)), nil
+
+	case strings.HasPrefix(strings.ToLower(data), regDataPrefixHex):
+		value, err := parseRegHexBytes(data[len(regDataPrefixHex):])
+		if err != nil {
+			return 0, nil, err
+		}
+
+		return ValueTypeBinary, value, nil
+
+	case strings.HasPrefix(strings.ToLower(data), regDataPrefixHexType):
+		typeCode, hexData, ok := strings.Cut(data[len(regDataPrefixHexType):], "):")
+		if !ok {
+			return 0, nil, errors.New("invalid hex data type")
+		}
+
+		valType, err := strconv.ParseUint(typeCode, 16, 32)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid hex data type: %w", err)
+		}
+
+		value, err := parseRegHexBytes(hexData)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		// REGEDIT4 files store string data using the ANSI code page instead
+		// of UTF-16.
+		if version == 4 && (uint32(valType) == ValueTypeExpandSZ || uint32(valType) == ValueTypeMultiSZ) {
+			value = ansiToUTF16Bytes(value)
+		}
+
+		return uint32(valType), value, nil
+
+	default:
+		return 0, nil, errors.New("unsupported value data")
+	}
+}
+
+// parseRegHexBytes parses a comma separated list of hex encoded bytes.
+func parseRegHexBytes(text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []byte{}, nil
+	}
+
+	fields := strings.Split(text, ",")
+	value := make([]byte, 0, len(fields))
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		b, err := strconv.ParseUint(field, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex data: %w", err)
+		}
+
+		value = append(value, byte(b))
+	}
+
+	return value, nil
+}
+
+// ansiToUTF16Bytes converts single byte encoded string data to little-endian
+// UTF-16. Bytes are treated as Latin-1 characters.
+func ansiToUTF16Bytes(data []byte) []byte {
+	converted := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		converted = append(converted, b, 0)
+	}
+
+	return converted
+}
@@ -1,5 +1,3 @@
-//go:build windows
-
 // Copyright 2022 Adam Chalkley
 //
 // https://github.com/atc0005/check-restart
@@ -13,13 +11,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/atc0005/check-restart/internal/restart"
 	"github.com/atc0005/check-restart/internal/textutils"
-	"golang.org/x/sys/windows/registry"
 )
 
 // Add "implements assertions" to fail the build if the
@@ -33,6 +30,39 @@ var (
 	_ restart.RebootRequiredAsserter = (*KeyPair)(nil)
 )
 
+// Add "implements assertions" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var (
+	_ restart.EvaluationTimer = (*Key)(nil)
+	_ restart.EvaluationTimer = (*KeyPair)(nil)
+)
+
+// Add "implements assertions" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var (
+	_ restart.PendingTracker = (*Key)(nil)
+	_ restart.PendingTracker = (*KeyPair)(nil)
+)
+
+// Add "implements assertions" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var (
+	_ restart.EvidenceReporter = (*Key)(nil)
+	_ restart.EvidenceReporter = (*KeyStrings)(nil)
+	_ restart.EvidenceReporter = (*KeyPair)(nil)
+)
+
+// Add "implements assertions" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var (
+	_ restart.Resetter = (*Key)(nil)
+	_ restart.Resetter = (*KeyInt)(nil)
+	_ restart.Resetter = (*KeyBinary)(nil)
+	_ restart.Resetter = (*KeyString)(nil)
+	_ restart.Resetter = (*KeyStrings)(nil)
+	_ restart.Resetter = (*KeyPair)(nil)
+)
+
 // Add "implements assertions" to fail the build if the
 // restart.RebootRequiredAsserterWithDataDisplay implementation isn't correct.
 var (
@@ -59,6 +89,18 @@ var _ restart.MatchedPath = (*MatchedPath)(nil)
 // ErrUnsupportedOS indicates that an unsupported OS has been detected.
 var ErrUnsupportedOS = errors.New("unsupported OS detected; this package requires a Windows OS to run properly")
 
+// ErrNotExist indicates that a requested registry key or value does not
+// exist.
+var ErrNotExist = errors.New("registry key or value does not exist")
+
+// ErrUnexpectedType indicates that a registry value is not of the type
+// expected by the caller.
+var ErrUnexpectedType = errors.New("unexpected registry value type")
+
+// ErrInvalidValueData indicates that the data for a registry value is not
+// valid for the type of the value.
+var ErrInvalidValueData = errors.New("invalid registry value data")
+
 // ErrInvalidNumberOfKeysInKeyPair indicates that either too few or too many
 // keys were provided for a key pair.
 var ErrInvalidNumberOfKeysInKeyPair = errors.New("invalid number of keys in key pair")
@@ -150,7 +192,7 @@ type KeyRebootRequired interface {
 	Requirements() KeyAssertions
 	Path() string
 	Value() string
-	RootKey() registry.Key
+	RootKey() RootKey
 	String() string
 }
 
@@ -172,6 +214,10 @@ type MatchedPath struct {
 	// ignored indicates whether this value has been marked by filtering logic
 	// as not considered when determining whether a reboot is needed.
 	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
 }
 
 // MatchedPathIndex is a collection of path values that were matched during
@@ -206,6 +252,12 @@ func (mp MatchedPath) String() string {
 	return mp.Full()
 }
 
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
+
 // KeyRebootEvidence indicates what registry key evidence is required in order
 // to determine that a reboot is needed.
 type KeyRebootEvidence struct {
@@ -282,7 +334,7 @@ type KeyRuntime struct {
 	// be used after it is closed and should not remain open any longer than
 	// necessary.
 	// https://learn.microsoft.com/en-us/windows/win32/api/winreg/nf-winreg-regclosekey
-	handle *registry.Key
+	handle BackendKey
 
 	// err records any error that occurs while performing an evaluation.
 	err error
@@ -302,13 +354,24 @@ type KeyRuntime struct {
 	// pathsMatched is a collection of path values that were matched during
 	// evaluation of specified reboot required assertions.
 	pathsMatched MatchedPathIndex
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
 }
 
 // Key represents a registry key that if found (and requirements met)
 // indicates a reboot is needed.
 type Key struct {
 	// root is the root or base registry key (e.g, HKEY_LOCAL_MACHINE).
-	root registry.Key
+	root RootKey
 
 	// runtime is a collection of values that are set during evaluation.
 	// Unlike static values that are known ahead of time, these values are not
@@ -329,6 +392,10 @@ type Key struct {
 	// requirements indicates what requirements must be met. If not met, this
 	// indicates that an error has occurred.
 	requirements KeyAssertions
+
+	// backend is the source of registry data used to evaluate the Key. If
+	// not set, the registry of the local system is used.
+	backend Backend
 }
 
 // Keys is a collection of Key values.
@@ -347,6 +414,17 @@ type KeyPairRuntime struct {
 	// evidenceFound is the collection of evidence found when evaluating
 	// a specified assertion.
 	evidenceFound KeyPairRebootEvidence
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
 }
 
 // KeyPair represents two Keys that are evaluated together.
@@ -429,6 +507,11 @@ type KeyString struct {
 	// expectedData represents the data that will be compared against the
 	// actual data stored for a registry key value.
 	expectedData string
+
+	// lookupEnv if set, is used instead of the environment of the current
+	// process to expand environment variable references within REG_EXPAND_SZ
+	// data. This allows evaluating assertions against an offline system.
+	lookupEnv func(key string) (string, bool)
 }
 
 // KeyStringsRuntime is a collection of values that are set during evaluation.
@@ -450,7 +533,7 @@ type KeyStringsRuntime struct {
 }
 
 // KeyStrings represents a Key containing multiple strings for comparison.
-// That collection of strings maps to a registry.MULTI_SZ key type and is
+// That collection of strings maps to a REG_MULTI_SZ key type and is
 // retrieved as a slice of strings.
 type KeyStrings struct {
 	Key
@@ -471,6 +554,65 @@ type KeyStrings struct {
 	additionalEvidence KeyStringsRebootEvidence
 }
 
+// NewKey creates a Key assertion for the registry key at the given path
+// beneath the specified root key. The value name is optional unless the
+// ValueExists evidence marker is used.
+func NewKey(root RootKey, path string, value string, evidence KeyRebootEvidence, requirements KeyAssertions) *Key {
+	return &Key{
+		root:             root,
+		path:             path,
+		value:            value,
+		evidenceExpected: evidence,
+		requirements:     requirements,
+	}
+}
+
+// NewKeyInt creates a KeyInt assertion comparing the integer data for the
+// value of the given Key against the expected data.
+func NewKeyInt(key *Key, expectedData uint64) *KeyInt {
+	return &KeyInt{
+		Key:          *key,
+		expectedData: expectedData,
+	}
+}
+
+// NewKeyString creates a KeyString assertion comparing the string data for
+// the value of the given Key against the expected data.
+func NewKeyString(key *Key, expectedData string) *KeyString {
+	return &KeyString{
+		Key:          *key,
+		expectedData: expectedData,
+	}
+}
+
+// NewKeyStrings creates a KeyStrings assertion searching the multi-string
+// data for the value of the given Key for the expected data.
+func NewKeyStrings(key *Key, expectedData []string, additionalEvidence KeyStringsRebootEvidence) *KeyStrings {
+	return &KeyStrings{
+		Key:                *key,
+		expectedData:       expectedData,
+		additionalEvidence: additionalEvidence,
+	}
+}
+
+// NewKeyBinary creates a KeyBinary assertion comparing the binary data for
+// the value of the given Key against the expected data.
+func NewKeyBinary(key *Key, expectedData []byte) *KeyBinary {
+	return &KeyBinary{
+		Key:          *key,
+		expectedData: expectedData,
+	}
+}
+
+// NewKeyPair creates a KeyPair assertion comparing the data for the values
+// of the given pair of Keys.
+func NewKeyPair(keys Keys, additionalEvidence KeyPairRebootEvidence) *KeyPair {
+	return &KeyPair{
+		Keys:               keys,
+		additionalEvidence: additionalEvidence,
+	}
+}
+
 // AddMatchedPath records given paths as successful assertion matches.
 // Duplicate entries are ignored.
 func (k *Key) AddMatchedPath(paths ...string) {
@@ -487,7 +629,7 @@ func (k *Key) AddMatchedPath(paths ...string) {
 			matchedPath := MatchedPath{
 				root:     getRootKeyName(k.RootKey()),
 				relative: path,
-				base:     filepath.Base(path),
+				base:     baseKeyPath(path),
 			}
 
 			k.runtime.pathsMatched[path] = matchedPath
@@ -569,6 +711,18 @@ func (k *Key) DiscoveredEvidence() KeyRebootEvidence {
 	return k.runtime.evidenceFound
 }
 
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (k *Key) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(k.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (k *Key) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(k.runtime.evidenceFound)
+}
+
 // HasEvidence indicates whether any evidence was found for an assertion
 // evaluation.
 func (k *Key) HasEvidence() bool {
@@ -672,7 +826,7 @@ func (k *Key) Path() string {
 }
 
 // RootKey returns the specified registry root key.
-func (k *Key) RootKey() registry.Key {
+func (k *Key) RootKey() RootKey {
 	return k.root
 }
 
@@ -681,9 +835,24 @@ func (k *Key) Value() string {
 	return k.value
 }
 
+// Backend returns the source of registry data used to evaluate the Key. If a
+// backend has not been specified the registry of the local system is used.
+func (k *Key) Backend() Backend {
+	if k.backend == nil {
+		return defaultBackend()
+	}
+
+	return k.backend
+}
+
+// SetBackend sets the source of registry data used to evaluate the Key.
+func (k *Key) SetBackend(backend Backend) {
+	k.backend = backend
+}
+
 // Handle returns the current handle to the open registry key if it exists,
 // otherwise returns nil.
-func (k *Key) Handle() *registry.Key {
+func (k *Key) Handle() BackendKey {
 	return k.runtime.handle
 }
 
@@ -699,14 +868,9 @@ func (k *Key) open() error {
 
 	logger.Printf("Handle does not exist, attempting to open registry key %q", k)
 
-	// Enumerating subkeys requires requesting access to do so along with
-	// permission to query values.
-	//
-	// We specify both permissions by combining the values via OR.
-	// https://stackoverflow.com/questions/47814070/golang-cant-enumerate-subkeys-of-registry-key
-	openKey, err := registry.OpenKey(k.RootKey(), k.Path(), registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	openKey, err := k.Backend().OpenKey(k.RootKey(), k.Path())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if k.Requirements().KeyRequired {
 			logger.Printf("Key %q not found, but marked as required.", k)
 			return ErrMissingRequiredKey
@@ -740,7 +904,7 @@ func (k *Key) open() error {
 
 	}
 
-	k.runtime.handle = &openKey
+	k.runtime.handle = openKey
 
 	// TODO: Any other feasible way to handle this? This is a logic problem
 	// that needs to be resolved.
@@ -1003,7 +1167,7 @@ func (k *Key) evalSubKeys() error {
 		logger.Printf("SubKeysExist specified; checking for subkeys for %q", k)
 
 		// Fetch subkey names and record as matched paths.
-		subKeyNames, err := k.runtime.handle.ReadSubKeyNames(0)
+		subKeyNames, err := k.runtime.handle.ReadSubKeyNames()
 		if err != nil {
 			return fmt.Errorf(
 				"unexpected error occurred while retrieving subkey names for key %s: %w",
@@ -1021,7 +1185,7 @@ func (k *Key) evalSubKeys() error {
 			relativePathSubKeyNames := make([]string, 0, len(subKeyNames))
 			for _, subKeyName := range subKeyNames {
 				relativePathSubKeyNames = append(
-					relativePathSubKeyNames, filepath.Join(
+					relativePathSubKeyNames, joinKeyPath(
 						k.path, subKeyName,
 					))
 			}
@@ -1059,9 +1223,9 @@ func (k *Key) evalValue() error {
 
 	logger.Printf("Value %q specified for key %q", k.Value(), k)
 
-	_, valTypeCode, err := k.runtime.handle.GetValue(k.Value(), nil)
+	valTypeCode, err := k.runtime.handle.ReadValueType(k.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if k.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but marked as required.", k.Value())
 			return fmt.Errorf(
@@ -1121,6 +1285,38 @@ func (k *Key) Err() error {
 	return k.runtime.err
 }
 
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (k *Key) EvaluationDuration() time.Duration {
+	return k.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (k *Key) SetEvaluationDuration(duration time.Duration) {
+	k.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (k *Key) PendingSince() time.Time {
+	return k.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (k *Key) Escalated() bool {
+	return k.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (k *Key) SetPending(since time.Time, escalated bool) {
+	k.runtime.pendingSince = since
+	k.runtime.escalated = escalated
+}
+
 // Ignored indicates whether the Key has been marked as ignored.
 //
 // For the entire key to be ignored, this means that *all* recorded matched
@@ -1254,6 +1450,17 @@ func (k *Key) Evaluate() {
 	k.evaluate(true)
 }
 
+// Reset discards the results of an earlier evaluation so that the Key may be
+// evaluated again. A handle to the registry key retained from an earlier
+// evaluation is closed.
+func (k *Key) Reset() {
+	if err := k.close(); err != nil {
+		logger.Printf("Failed to close handle for %s during reset: %v", k, err)
+	}
+
+	k.runtime = KeyRuntime{}
+}
+
 // Filter uses the list of specified ignore patterns to mark each matched path
 // for the Key as ignored *IF* a match is found.
 //
@@ -1262,7 +1469,14 @@ func (k *Key) Evaluate() {
 //
 // If no matched paths are recorded Filter makes no changes. Filter should be
 // called before performing final state evaluation.
-func (k *Key) Filter(ignorePatterns []string) {
+func (k *Key) Filter(ignorePatterns restart.IgnorePatterns) {
+	k.filter(ignorePatterns, k.String())
+}
+
+// filter marks each matched path for the Key as ignored if matched by an
+// ignore pattern applicable to registry assertions or to one of the given
+// assertions.
+func (k *Key) filter(ignorePatterns restart.IgnorePatterns, assertions ...string) {
 
 	numIgnorePatterns := len(ignorePatterns)
 	var numIgnorePatternsApplied int
@@ -1281,22 +1495,15 @@ func (k *Key) Filter(ignorePatterns []string) {
 	for originalPathString, matchedPath := range k.runtime.pathsMatched {
 		logger.Printf("Searching matched path %q for ignore pattern matches", originalPathString)
 
-		normalizedPathString := textutils.NormalizePath(originalPathString)
-		logger.Printf("Normalizing matched path %q as %q", originalPathString, normalizedPathString)
-
-		for _, ignorePattern := range ignorePatterns {
-
-			normalizedIgnorePattern := textutils.NormalizePath(ignorePattern)
-			logger.Printf("Normalizing ignore pattern %q as %q", ignorePattern, normalizedIgnorePattern)
+		ignorePattern, ok := ignorePatterns.Match(restart.IgnoreTargetRegistry, originalPathString, assertions...)
+		if ok {
+			logger.Printf("matchedPath %q matches ignorePattern %q", originalPathString, ignorePattern)
+			logger.Printf("marking matched path %q as ignored", originalPathString)
 
-			if strings.Contains(normalizedPathString, normalizedIgnorePattern) {
-				logger.Printf("matchedPath %q contains ignorePattern %q", originalPathString, ignorePattern)
-				logger.Printf("marking matched path %q as ignored", originalPathString)
-
-				matchedPath.ignored = true
-				k.runtime.pathsMatched[originalPathString] = matchedPath
-				numIgnorePatternsApplied++
-			}
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			k.runtime.pathsMatched[originalPathString] = matchedPath
+			numIgnorePatternsApplied++
 		}
 	}
 
@@ -1377,9 +1584,9 @@ func (kb *KeyBinary) Evaluate() {
 		return
 	}
 
-	foundData, _, err := kb.Handle().GetBinaryValue(kb.Value())
+	foundData, _, err := readBinaryValue(kb.Handle(), kb.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if kb.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but marked as required.", kb)
 			kb.Key.runtime.err = fmt.Errorf(
@@ -1446,6 +1653,13 @@ func (kb *KeyBinary) Evaluate() {
 	// indicated that a reboot was necessary.
 }
 
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed Key) so that the KeyBinary may be evaluated again.
+func (kb *KeyBinary) Reset() {
+	kb.Key.Reset()
+	kb.runtime = KeyBinaryRuntime{}
+}
+
 // Data returns the actual data stored for a registry key value.
 func (ki *KeyInt) Data() uint64 {
 	return ki.runtime.data
@@ -1483,9 +1697,9 @@ func (ki *KeyInt) Evaluate() {
 		return
 	}
 
-	foundData, _, err := ki.Handle().GetIntegerValue(ki.Value())
+	foundData, _, err := readIntegerValue(ki.Handle(), ki.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if ki.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but marked as required.", ki)
 
@@ -1553,6 +1767,13 @@ func (ki *KeyInt) Evaluate() {
 	// indicated that a reboot was necessary.
 }
 
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed Key) so that the KeyInt may be evaluated again.
+func (ki *KeyInt) Reset() {
+	ki.Key.Reset()
+	ki.runtime = KeyIntRuntime{}
+}
+
 // Data returns the actual data stored for a registry key value.
 func (ks *KeyString) Data() string {
 	return ks.runtime.data
@@ -1563,6 +1784,13 @@ func (ks *KeyString) ExpectedData() string {
 	return ks.expectedData
 }
 
+// SetEnvLookup sets the function used to expand environment variable
+// references within REG_EXPAND_SZ data instead of the environment of the
+// current process.
+func (ks *KeyString) SetEnvLookup(lookupEnv func(key string) (string, bool)) {
+	ks.lookupEnv = lookupEnv
+}
+
 // DataDisplay provides a string representation of a registry key values's
 // actual data for display purposes.
 func (ks *KeyString) DataDisplay() string {
@@ -1590,9 +1818,9 @@ func (ks *KeyString) Evaluate() {
 		return
 	}
 
-	foundData, _, err := ks.Handle().GetStringValue(ks.Value())
+	foundData, valType, err := readStringValue(ks.Handle(), ks.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if ks.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but is marked as required.", ks.Value())
 
@@ -1635,6 +1863,12 @@ func (ks *KeyString) Evaluate() {
 
 	logger.Printf("Data for value %q retrieved ...", ks.Value())
 	logger.Printf("foundData: %v", foundData)
+
+	if valType == ValueTypeExpandSZ {
+		foundData = restart.ExpandEnvironmentStrings(foundData, ks.lookupEnv)
+		logger.Printf("Expanded data: %v", foundData)
+	}
+
 	logger.Print("Saving retrieved data for later use ...")
 	ks.runtime.data = foundData
 
@@ -1660,6 +1894,13 @@ func (ks *KeyString) Evaluate() {
 	// indicated that a reboot was necessary.
 }
 
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed Key) so that the KeyString may be evaluated again.
+func (ks *KeyString) Reset() {
+	ks.Key.Reset()
+	ks.runtime = KeyStringRuntime{}
+}
+
 // Data returns the actual data stored for a registry key value.
 func (ks *KeyStrings) Data() []string {
 	return ks.runtime.data
@@ -1762,6 +2003,18 @@ func (ks *KeyStrings) AdditionalEvidence() KeyStringsRebootEvidence {
 	return ks.additionalEvidence
 }
 
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (ks *KeyStrings) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(ks.Key.evidenceExpected, ks.additionalEvidence)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (ks *KeyStrings) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(ks.Key.runtime.evidenceFound, ks.runtime.evidenceFound)
+}
+
 // RebootReasons returns a list of the reasons associated with the evidence
 // found for an evaluation that indicates a reboot is needed.
 func (ks *KeyStrings) RebootReasons() []string {
@@ -1907,9 +2160,9 @@ func (ks *KeyStrings) Evaluate() {
 		return
 	}
 
-	foundData, _, err := ks.Handle().GetStringsValue(ks.Value())
+	foundData, _, err := readStringsValue(ks.Handle(), ks.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if ks.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but marked as required.", ks.Value())
 
@@ -1960,6 +2213,13 @@ func (ks *KeyStrings) Evaluate() {
 
 }
 
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed Key) so that the KeyStrings may be evaluated again.
+func (ks *KeyStrings) Reset() {
+	ks.Key.Reset()
+	ks.runtime = KeyStringsRuntime{}
+}
+
 // Data returns the actual data stores for both registry key values.
 func (kp *KeyPair) Data() [][]byte {
 	return kp.runtime.data
@@ -2000,6 +2260,18 @@ func (kp *KeyPair) AdditionalEvidence() KeyPairRebootEvidence {
 	return kp.additionalEvidence
 }
 
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (kp *KeyPair) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(kp.additionalEvidence)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (kp *KeyPair) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(kp.runtime.evidenceFound)
+}
+
 // RebootReasons returns a list of the reasons associated with the evidence
 // found for an evaluation that indicates a reboot is needed.
 func (kp *KeyPair) RebootReasons() []string {
@@ -2078,6 +2350,38 @@ func (kp *KeyPair) Err() error {
 	return nil
 }
 
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (kp *KeyPair) EvaluationDuration() time.Duration {
+	return kp.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (kp *KeyPair) SetEvaluationDuration(duration time.Duration) {
+	kp.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (kp *KeyPair) PendingSince() time.Time {
+	return kp.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (kp *KeyPair) Escalated() bool {
+	return kp.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (kp *KeyPair) SetPending(since time.Time, escalated bool) {
+	kp.runtime.pendingSince = since
+	kp.runtime.escalated = escalated
+}
+
 // HasEvidence indicates whether any evidence was found for an assertion
 // evaluation.
 func (kp *KeyPair) HasEvidence() bool {
@@ -2097,12 +2401,20 @@ func (kp *KeyPair) HasEvidence() bool {
 	return kp.runtime.evidenceFound.PairedValuesDoNotMatch
 }
 
+// SetBackend sets the source of registry data used to evaluate the enclosed
+// Keys.
+func (kp *KeyPair) SetBackend(backend Backend) {
+	for _, key := range kp.Keys {
+		key.SetBackend(backend)
+	}
+}
+
 // gatherKeyPairData retrieves the data for a registry Key out from a pair for
 // evaluation.
 func (kp *KeyPair) gatherKeyPairData(key *Key) {
-	bufSize, valType, err := key.Handle().GetValue(key.Value(), nil)
+	buffer, valType, err := key.Handle().ReadValue(key.Value())
 	switch {
-	case errors.Is(err, registry.ErrNotExist):
+	case errors.Is(err, ErrNotExist):
 		if key.Requirements().ValueRequired {
 			logger.Printf("Value %q not found, but marked as required.", key.Value())
 
@@ -2144,33 +2456,12 @@ func (kp *KeyPair) gatherKeyPairData(key *Key) {
 	}
 
 	logger.Printf(
-		"Required buffer size %d for value %v of type %v ...",
-		bufSize,
+		"Retrieved %d bytes for value %v of type %v ...",
+		len(buffer),
 		key.Value(),
 		getValueType(valType),
 	)
 
-	buffer := make([]byte, bufSize)
-	_, _, err = key.Handle().GetValue(key.Value(), buffer)
-
-	// We intentionally use simpler error handling here since we just
-	// evaluated whether the registry key value is required to be
-	// present and have successfully retrieved the necessary buffer
-	// size for the data associated with registry key value; if an
-	// error occurs at this point it doesn't really matter what
-	// requirement is being applied.
-	//
-	// TODO: Worth the complexity to implement a retry?
-	if err != nil {
-		key.runtime.err = fmt.Errorf(
-			"failed to retrieve data for value %s: %w",
-			key.Value(),
-			err,
-		)
-
-		return
-	}
-
 	logger.Printf("data in raw/hex format: % x", buffer)
 	logger.Printf("data in string format: %s", buffer)
 
@@ -2181,6 +2472,17 @@ func (kp *KeyPair) gatherKeyPairData(key *Key) {
 // evalKeyPairData evaluates retrieved data values.
 func (kp *KeyPair) evalKeyPairData() {
 
+	// An optional value which was not found leaves nothing to compare.
+	if len(kp.runtime.data) != len(kp.Keys) {
+		logger.Printf(
+			"Data retrieved for %d of %d values; skipping comparison",
+			len(kp.runtime.data),
+			len(kp.Keys),
+		)
+
+		return
+	}
+
 	fqpath1 := fmt.Sprintf(`%s\%s`, kp.Keys[0].Path(), kp.Keys[0].Value())
 	fqpath2 := fmt.Sprintf(`%s\%s`, kp.Keys[1].Path(), kp.Keys[1].Value())
 
@@ -2213,54 +2515,77 @@ func (kp *KeyPair) evalKeyPairData() {
 func (kp *KeyPair) Evaluate() {
 
 	for _, key := range kp.Keys {
+		if !kp.evaluateKey(key) {
+			return
+		}
+	}
 
-		// Evaluate embedded "base" Key first where we check shared
-		// requirements and reboot evidence. We also explicitly indicate that
-		// we wish to retain a handle to the open registry key (for use here).
-		key.evaluate(false)
+	// compare retrieved data values
+	kp.evalKeyPairData()
+}
 
-		defer key.closeAndLog()
+// Reset discards the results of an earlier evaluation (including those of
+// the enclosed Keys) so that the KeyPair may be evaluated again.
+func (kp *KeyPair) Reset() {
+	for _, key := range kp.Keys {
+		key.Reset()
+	}
 
-		// Early exit logic kill switch.
-		switch {
-		case key.Err() != nil:
-			// Go no further if an error occurred evaluating the "base" Key.
-			//
-			// Unlike other Key* types, this type is not meant to collect the
-			// actual data registry values for later display purposes, only
-			// make comparisons if requested. So, unlike the other Key* types,
-			// it is sufficient to use only the base Key evaluation results if
-			// an error occurred or a reboot was found to be required.
-			return
-		case key.HasEvidence():
-			// Go no further if the "base" Key evaluation was sufficient to
-			// determine a reboot is needed.
-			return
-		case !kp.AdditionalEvidence().PairedValuesDoNotMatch:
-			// Exit early if we are not evaluating whether the data for each
-			// registry key value matches.
-			return
-		case key.Value() == "":
-			// Go no further if there isn't a registry key value to process.
-			return
-		}
+	kp.runtime = KeyPairRuntime{}
+}
+
+// evaluateKey evaluates the given Key from the pair and retrieves the data
+// for its value for later comparison. The handle to the open registry key is
+// closed before returning so that handles are not held (or shared) across
+// the evaluation of both keys. Whether evaluation of the pair should
+// continue is returned.
+func (kp *KeyPair) evaluateKey(key *Key) bool {
+	// Evaluate embedded "base" Key first where we check shared requirements
+	// and reboot evidence. We also explicitly indicate that we wish to retain
+	// a handle to the open registry key (for use here).
+	key.evaluate(false)
 
-		kp.gatherKeyPairData(key)
+	defer key.closeAndLog()
 
+	// Early exit logic kill switch.
+	switch {
+	case key.Err() != nil:
+		// Go no further if an error occurred evaluating the "base" Key.
+		//
+		// Unlike other Key* types, this type is not meant to collect the
+		// actual data registry values for later display purposes, only make
+		// comparisons if requested. So, unlike the other Key* types, it is
+		// sufficient to use only the base Key evaluation results if an error
+		// occurred or a reboot was found to be required.
+		return false
+	case key.HasEvidence():
+		// Go no further if the "base" Key evaluation was sufficient to
+		// determine a reboot is needed.
+		return false
+	case !kp.AdditionalEvidence().PairedValuesDoNotMatch:
+		// Exit early if we are not evaluating whether the data for each
+		// registry key value matches.
+		return false
+	case key.Value() == "":
+		// Go no further if there isn't a registry key value to process.
+		return false
 	}
 
-	// compare retrieved data values
-	kp.evalKeyPairData()
+	kp.gatherKeyPairData(key)
+
+	return key.Err() == nil
 }
 
 // Filter uses the list of specified ignore patterns to mark each matched path
 // for the enclosed Keys as ignored *IF* a match is found. If no matched paths
 // are recorded Filter makes no changes. Filter should be called before
 // performing final state evaluation.
-func (kp *KeyPair) Filter(ignorePatterns []string) {
+//
+// Ignore patterns targeting either the KeyPair or an enclosed Key apply.
+func (kp *KeyPair) Filter(ignorePatterns restart.IgnorePatterns) {
 
 	for i := range kp.Keys {
-		kp.Keys[i].Filter(ignorePatterns)
+		kp.Keys[i].filter(ignorePatterns, kp.String(), kp.Keys[i].String())
 	}
 }
 
@@ -2373,29 +2698,29 @@ func getValueType(valType uint32) string {
 	var keyType string
 
 	switch valType {
-	case registry.NONE:
+	case ValueTypeNone:
 		keyType = RegKeyTypeNone
-	case registry.SZ:
+	case ValueTypeSZ:
 		keyType = RegKeyTypeSZ
-	case registry.EXPAND_SZ:
+	case ValueTypeExpandSZ:
 		keyType = RegKeyTypeExpandSZ
-	case registry.BINARY:
+	case ValueTypeBinary:
 		keyType = RegKeyTypeBinary
-	case registry.DWORD:
+	case ValueTypeDWORD:
 		keyType = RegKeyTypeDWORD
-	case registry.DWORD_BIG_ENDIAN:
+	case ValueTypeDWORDBigEndian:
 		keyType = RegKeyTypeDWORDBigEndian
-	case registry.LINK:
+	case ValueTypeLink:
 		keyType = RegKeyTypeLink
-	case registry.MULTI_SZ:
+	case ValueTypeMultiSZ:
 		keyType = RegKeyTypeMultiSZ
-	case registry.RESOURCE_LIST:
+	case ValueTypeResourceList:
 		keyType = RegKeyTypeResourceList
-	case registry.FULL_RESOURCE_DESCRIPTOR:
+	case ValueTypeFullResourceDescriptor:
 		keyType = RegKeyTypeFullResourceDescriptor
-	case registry.RESOURCE_REQUIREMENTS_LIST:
+	case ValueTypeResourceRequirementsList:
 		keyType = RegKeyTypeResourceRequirementsList
-	case registry.QWORD:
+	case ValueTypeQWORD:
 		keyType = RegKeyTypeQWORD
 	default:
 		keyType = RegKeyTypeUnknown
@@ -2408,21 +2733,21 @@ func getValueType(valType uint32) string {
 // key.
 //
 // TODO: Export for external use?
-func getRootKeyName(key registry.Key) string {
+func getRootKeyName(key RootKey) string {
 	var keyName string
 
 	switch key {
-	case registry.CLASSES_ROOT:
+	case RootKeyClassesRoot:
 		keyName = RegKeyRootNameClassesRoot
-	case registry.CURRENT_USER:
+	case RootKeyCurrentUser:
 		keyName = RegKeyRootNameCurrentUser
-	case registry.LOCAL_MACHINE:
+	case RootKeyLocalMachine:
 		keyName = RegKeyRootNameLocalMachine
-	case registry.USERS:
+	case RootKeyUsers:
 		keyName = RegKeyRootNameUsers
-	case registry.CURRENT_CONFIG:
+	case RootKeyCurrentConfig:
 		keyName = RegKeyRootNameCurrentConfig
-	case registry.PERFORMANCE_DATA:
+	case RootKeyPerformanceData:
 		keyName = RegKeyRootNamePerformanceData
 	default:
 		keyName = RegKeyRootNameUnknown
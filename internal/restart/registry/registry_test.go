@@ -0,0 +1,466 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/atc0005/check-restart/internal/restart"
+)
+
+// healthyHostRegExport is a registry export from a host which does not need
+// a reboot.
+const healthyHostRegExport = `Windows Registry Editor Version 5.00
+
+[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Updates]
+"UpdateExeVolatile"=dword:00000000
+
+[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\RunOnce]
+
+[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Services\Pending]
+
+[HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Services\Netlogon]
+"Start"=dword:00000003
+
+[HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Control\ComputerName\ActiveComputerName]
+"ComputerName"="WEB01"
+
+[HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Control\ComputerName\ComputerName]
+"ComputerName"="WEB01"
+`
+
+// brokenHostRegExport is applied on top of healthyHostRegExport to produce a
+// registry export from a host which needs a reboot.
+const brokenHostRegExport = `Windows Registry Editor Version 5.00
+
+; Pending rename of the host.
+[HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Control\ComputerName\ComputerName]
+"ComputerName"="WEB02"
+
+[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired]
+"3b1fa7a4-5c1e-4b3f-8d1a-0e6f3f0c2a11"=dword:00000001
+
+[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Services\Pending\117cab2d-82b1-4b5a-a08c-4d62dbee7782]
+`
+
+// encodeUTF16LE encodes text as UTF-16LE with a byte order mark as used by
+// the Registry Editor when exporting keys.
+func encodeUTF16LE(text string) []byte {
+	units := utf16.Encode([]rune(text))
+
+	data := []byte{0xFF, 0xFE}
+	for _, unit := range units {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+
+	return data
+}
+
+// TestImportReg asserts that the supported value types and deletion syntax
+// of exported registry files are imported as expected.
+func TestImportReg(t *testing.T) {
+	t.Parallel()
+
+	const content = "Windows Registry Editor Version 5.00\r\n" +
+		"\r\n" +
+		"[HKEY_LOCAL_MACHINE\\SYSTEM\\CurrentControlSet\\Control\\Session Manager]\r\n" +
+		"@=\"default\"\r\n" +
+		"\"Quoted \\\"Name\\\"\"=\"C:\\\\Windows\\\\Temp\"\r\n" +
+		"\"Counter\"=dword:0000002a\r\n" +
+		"\"Big\"=hex(b):01,00,00,00,00,00,00,00\r\n" +
+		"\"Blob\"=hex:de,ad,\\\r\n" +
+		"  be,ef\r\n" +
+		"\"PendingFileRenameOperations\"=hex(7):5c,00,3f,00,3f,00,5c,00,61,00,00,00,\\\r\n" +
+		"  00,00,00,00\r\n" +
+		"\"Removed\"=\"soon\"\r\n" +
+		"\"Removed\"=-\r\n" +
+		"\r\n" +
+		"[HKEY_LOCAL_MACHINE\\SOFTWARE\\Obsolete\\Child]\r\n" +
+		"[-HKEY_LOCAL_MACHINE\\SOFTWARE\\Obsolete]\r\n" +
+		"\"Ignored\"=\"value beneath deleted key\"\r\n"
+
+	mb := NewMemoryBackend()
+	if err := mb.ImportReg(bytes.NewReader(encodeUTF16LE(content))); err != nil {
+		t.Fatalf("ERROR: failed to import registry content: %v", err)
+	}
+
+	key, err := mb.OpenKey(RootKeyLocalMachine, `system\currentcontrolset\control\SESSION MANAGER`)
+	if err != nil {
+		t.Fatalf("ERROR: failed to open imported key: %v", err)
+	}
+
+	if got, _, err := readStringValue(key, ""); err != nil || got != "default" {
+		t.Errorf("ERROR: default value = %q, %v; want %q", got, err, "default")
+	}
+
+	if got, _, err := readStringValue(key, `Quoted "Name"`); err != nil || got != `C:\Windows\Temp` {
+		t.Errorf("ERROR: quoted value = %q, %v; want %q", got, err, `C:\Windows\Temp`)
+	}
+
+	if got, _, err := readIntegerValue(key, "counter"); err != nil || got != 42 {
+		t.Errorf("ERROR: dword value = %d, %v; want 42", got, err)
+	}
+
+	if got, _, err := readIntegerValue(key, "Big"); err != nil || got != 1 {
+		t.Errorf("ERROR: qword value = %d, %v; want 1", got, err)
+	}
+
+	if got, _, err := readBinaryValue(key, "Blob"); err != nil || !bytes.Equal(got, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("ERROR: binary value = % x, %v; want de ad be ef", got, err)
+	}
+
+	wantStrings := []string{`\??\a`, ""}
+	if got, _, err := readStringsValue(key, "PendingFileRenameOperations"); err != nil || !reflect.DeepEqual(got, wantStrings) {
+		t.Errorf("ERROR: multi-string value = %q, %v; want %q", got, err, wantStrings)
+	}
+
+	if _, err := key.ReadValueType("Removed"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("ERROR: expected deleted value to be missing; got %v", err)
+	}
+
+	if _, _, err := readIntegerValue(key, ""); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("ERROR: expected ErrUnexpectedType reading string as integer; got %v", err)
+	}
+
+	if _, err := mb.OpenKey(RootKeyLocalMachine, `SOFTWARE\Obsolete\Child`); !errors.Is(err, ErrNotExist) {
+		t.Errorf("ERROR: expected deleted key to be missing; got %v", err)
+	}
+}
+
+// TestImportRegInvalid asserts that invalid content is rejected with the
+// offending line identified.
+func TestImportRegInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content  string
+		wantLine string
+	}{
+		"missing header": {
+			content:  "[HKEY_LOCAL_MACHINE\\SOFTWARE]\n",
+			wantLine: "line 1:",
+		},
+		"unknown root key": {
+			content:  regFileHeaderV5 + "\n\n[HKEY_NOWHERE\\SOFTWARE]\n",
+			wantLine: "line 3:",
+		},
+		"value before key": {
+			content:  regFileHeaderV5 + "\n\"Name\"=\"value\"\n",
+			wantLine: "line 2:",
+		},
+		"invalid dword": {
+			content:  regFileHeaderV5 + "\n[HKEY_LOCAL_MACHINE\\SOFTWARE]\n\"Name\"=dword:xyz\n",
+			wantLine: "line 3:",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewMemoryBackend().ImportReg(strings.NewReader(tt.content))
+
+			switch {
+			case !errors.Is(err, ErrInvalidRegFile):
+				t.Errorf("ERROR: expected ErrInvalidRegFile; got %v", err)
+			case !strings.Contains(err.Error(), tt.wantLine):
+				t.Errorf("ERROR: error %q does not reference %q", err, tt.wantLine)
+			}
+		})
+	}
+}
+
+// TestDefaultRebootRequiredAssertionsWithBackend asserts that the default
+// registry assertions can be evaluated against exported registry files.
+func TestDefaultRebootRequiredAssertionsWithBackend(t *testing.T) {
+	t.Parallel()
+
+	healthy := NewMemoryBackend()
+	if err := healthy.ImportReg(strings.NewReader(healthyHostRegExport)); err != nil {
+		t.Fatalf("ERROR: failed to import registry content: %v", err)
+	}
+
+	assertions := DefaultRebootRequiredAssertionsWithBackend(healthy)
+	if err := assertions.Validate(); err != nil {
+		t.Fatalf("ERROR: failed to validate assertions: %v", err)
+	}
+
+	assertions.Evaluate(context.Background())
+
+	if assertions.HasErrors(false) {
+		t.Errorf("ERROR: unexpected errors for healthy host: %v", assertions.Errs(false))
+	}
+
+	if assertions.RebootRequired() {
+		t.Error("ERROR: reboot unexpectedly required for healthy host")
+	}
+
+	broken := NewMemoryBackend()
+	for _, content := range []string{healthyHostRegExport, brokenHostRegExport} {
+		if err := broken.ImportReg(strings.NewReader(content)); err != nil {
+			t.Fatalf("ERROR: failed to import registry content: %v", err)
+		}
+	}
+
+	// Evaluate concurrently to assert that the evaluation of each assertion
+	// (e.g., the keys of a KeyPair) does not depend on shared state.
+	assertions = DefaultRebootRequiredAssertionsWithBackend(broken)
+	if _, unfinished := assertions.EvaluateConcurrently(context.Background(), 4); len(unfinished) > 0 {
+		t.Fatalf("ERROR: %d assertions did not complete", len(unfinished))
+	}
+	assertions.Filter(restart.SubstringIgnorePatterns(DefaultRebootRequiredIgnoredPaths()))
+
+	if assertions.HasErrors(false) {
+		t.Errorf("ERROR: unexpected errors for broken host: %v", assertions.Errs(false))
+	}
+
+	if !assertions.RebootRequired() {
+		t.Fatal("ERROR: reboot not required for broken host")
+	}
+
+	// The RebootRequired key and mismatched computer names are expected to
+	// match. The pending services subkey matches, but is ignored by default
+	// and so is not counted as a match.
+	if got, want := assertions.NumMatched(), 2; got != want {
+		t.Errorf("ERROR: got %d matched assertions; want %d", got, want)
+	}
+
+	if got, want := assertions.NumIgnored(), 1; got != want {
+		t.Errorf("ERROR: got %d ignored assertions; want %d", got, want)
+	}
+}
+
+// TestKeyPathHelpers asserts that registry key paths are joined and split
+// using the registry key path separator on all platforms.
+func TestKeyPathHelpers(t *testing.T) {
+	t.Parallel()
+
+	if got, want := joinKeyPath(`SOFTWARE\Example\`, `Sub`), `SOFTWARE\Example\Sub`; got != want {
+		t.Errorf("ERROR: joinKeyPath() = %q; want %q", got, want)
+	}
+
+	if got, want := baseKeyPath(`SOFTWARE\Example\Sub`), `Sub`; got != want {
+		t.Errorf("ERROR: baseKeyPath() = %q; want %q", got, want)
+	}
+
+	root, path, err := splitKeyPath(`HKLM\SOFTWARE\Example`)
+	if err != nil || root != RootKeyLocalMachine || path != `SOFTWARE\Example` {
+		t.Errorf("ERROR: splitKeyPath() = %v, %q, %v", root, path, err)
+	}
+}
+
+// TestKeyStringExpandSZ asserts that environment variable references within
+// REG_EXPAND_SZ data are expanded before comparison.
+func TestKeyStringExpandSZ(t *testing.T) {
+	t.Parallel()
+
+	lookupEnv := func(key string) (string, bool) {
+		if key == "SystemRoot" {
+			return `C:\Windows`, true
+		}
+		return "", false
+	}
+
+	tests := map[string]struct {
+		data       string
+		expected   string
+		lookupEnv  func(key string) (string, bool)
+		wantData   string
+		wantReboot bool
+	}{
+		"expanded data matches": {
+			data:      `%SystemRoot%\Vendor`,
+			expected:  `C:\Windows\Vendor`,
+			lookupEnv: lookupEnv,
+			wantData:  `C:\Windows\Vendor`,
+		},
+		"expanded data differs": {
+			data:       `%SystemRoot%\Vendor`,
+			expected:   `D:\Windows\Vendor`,
+			lookupEnv:  lookupEnv,
+			wantData:   `C:\Windows\Vendor`,
+			wantReboot: true,
+		},
+		"unresolved reference left as-is": {
+			data:      `%SystemRoot%\Vendor`,
+			expected:  `%SystemRoot%\Vendor`,
+			lookupEnv: func(string) (string, bool) { return "", false },
+			wantData:  `%SystemRoot%\Vendor`,
+		},
+		"mixed resolved and unresolved references": {
+			data:      `%SystemRoot%\%VendorDir%\100%%`,
+			expected:  `C:\Windows\%VendorDir%\100%%`,
+			lookupEnv: lookupEnv,
+			wantData:  `C:\Windows\%VendorDir%\100%%`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mb := NewMemoryBackend()
+			mb.SetValue(RootKeyLocalMachine, `SOFTWARE\Vendor`, "InstallDir", ValueTypeExpandSZ, encodeString(tt.data))
+
+			ks := NewKeyString(
+				NewKey(RootKeyLocalMachine, `SOFTWARE\Vendor`, "InstallDir", KeyRebootEvidence{DataOtherThanX: true}, KeyAssertions{}),
+				tt.expected,
+			)
+			ks.SetBackend(mb)
+			ks.SetEnvLookup(tt.lookupEnv)
+
+			ks.Evaluate()
+
+			if ks.Err() != nil {
+				t.Fatalf("ERROR: unexpected error: %v", ks.Err())
+			}
+
+			if got := ks.Data(); got != tt.wantData {
+				t.Errorf("ERROR: got data %q; want %q", got, tt.wantData)
+			}
+
+			if got := ks.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got RebootRequired() %t; want %t", got, tt.wantReboot)
+			}
+		})
+	}
+}
+
+// TestKeyPairOptionalValue asserts that a KeyPair whose optional value is
+// missing for one of its keys is evaluated without comparing the data.
+func TestKeyPairOptionalValue(t *testing.T) {
+	t.Parallel()
+
+	mb := NewMemoryBackend()
+	mb.SetStringValue(RootKeyLocalMachine, `SOFTWARE\Vendor\Active`, "Name", "WEB01")
+	mb.CreateKey(RootKeyLocalMachine, `SOFTWARE\Vendor\Pending`)
+
+	kp := NewKeyPair(
+		Keys{
+			NewKey(RootKeyLocalMachine, `SOFTWARE\Vendor\Active`, "Name", KeyRebootEvidence{}, KeyAssertions{KeyRequired: true}),
+			NewKey(RootKeyLocalMachine, `SOFTWARE\Vendor\Pending`, "Name", KeyRebootEvidence{}, KeyAssertions{KeyRequired: true}),
+		},
+		KeyPairRebootEvidence{PairedValuesDoNotMatch: true},
+	)
+	kp.SetBackend(mb)
+
+	kp.Evaluate()
+
+	if kp.Err() != nil {
+		t.Errorf("ERROR: unexpected error: %v", kp.Err())
+	}
+
+	if kp.RebootRequired() {
+		t.Error("ERROR: reboot unexpectedly required when optional value is missing")
+	}
+}
+
+// errReadValue is returned by readValueErrBackend keys when reading data.
+var errReadValue = errors.New("read failure")
+
+// readValueErrBackend is a Backend whose keys fail to read value data. The
+// keys opened are recorded.
+type readValueErrBackend struct {
+	*MemoryBackend
+	opened []string
+}
+
+// readValueErrKey is a key opened by readValueErrBackend.
+type readValueErrKey struct {
+	BackendKey
+}
+
+// OpenKey opens the key at the given path and records it as opened.
+func (b *readValueErrBackend) OpenKey(root RootKey, path string) (BackendKey, error) {
+	key, err := b.MemoryBackend.OpenKey(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	b.opened = append(b.opened, path)
+
+	return readValueErrKey{key}, nil
+}
+
+// ReadValue always fails.
+func (readValueErrKey) ReadValue(string) ([]byte, uint32, error) {
+	return nil, 0, errReadValue
+}
+
+// TestKeyPairReadValueError asserts that a KeyPair stops evaluating once the
+// data for the value of one of its keys cannot be retrieved and records the
+// error without evaluating the second key.
+func TestKeyPairReadValueError(t *testing.T) {
+	t.Parallel()
+
+	mb := NewMemoryBackend()
+	mb.SetStringValue(RootKeyLocalMachine, `SOFTWARE\Vendor\Active`, "Name", "WEB01")
+	mb.SetStringValue(RootKeyLocalMachine, `SOFTWARE\Vendor\Pending`, "Name", "WEB02")
+
+	backend := &readValueErrBackend{MemoryBackend: mb}
+
+	kp := NewKeyPair(
+		Keys{
+			NewKey(RootKeyLocalMachine, `SOFTWARE\Vendor\Active`, "Name", KeyRebootEvidence{}, KeyAssertions{KeyRequired: true}),
+			NewKey(RootKeyLocalMachine, `SOFTWARE\Vendor\Pending`, "Name", KeyRebootEvidence{}, KeyAssertions{KeyRequired: true}),
+		},
+		KeyPairRebootEvidence{PairedValuesDoNotMatch: true},
+	)
+	kp.SetBackend(backend)
+
+	kp.Evaluate()
+
+	if !errors.Is(kp.Err(), errReadValue) {
+		t.Errorf("ERROR: got error %v; want %v", kp.Err(), errReadValue)
+	}
+
+	if kp.RebootRequired() {
+		t.Error("ERROR: reboot unexpectedly required when value data cannot be retrieved")
+	}
+
+	if want := []string{`SOFTWARE\Vendor\Active`}; !reflect.DeepEqual(backend.opened, want) {
+		t.Errorf("ERROR: got opened keys %q; want %q", backend.opened, want)
+	}
+}
+
+// TestKeyReset asserts that the results of an earlier evaluation are
+// discarded when a Key is reset so that a later evaluation reflects only the
+// current state of the registry.
+func TestKeyReset(t *testing.T) {
+	t.Parallel()
+
+	path := `SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootPending`
+
+	mb := NewMemoryBackend()
+	mb.CreateKey(RootKeyLocalMachine, path)
+
+	key := NewKey(RootKeyLocalMachine, path, "", KeyRebootEvidence{KeyExists: true}, KeyAssertions{})
+	key.SetBackend(mb)
+
+	key.Evaluate()
+
+	if !key.RebootRequired() || len(key.MatchedPaths()) != 1 {
+		t.Fatalf("ERROR: got RebootRequired() %t with %d matched paths; want reboot required with 1 matched path", key.RebootRequired(), len(key.MatchedPaths()))
+	}
+
+	mb.DeleteKey(RootKeyLocalMachine, path)
+
+	restart.RebootRequiredAsserters{key}.Reset()
+	key.Evaluate()
+
+	if key.RebootRequired() || key.HasEvidence() || len(key.MatchedPaths()) != 0 {
+		t.Errorf("ERROR: got RebootRequired() %t with %d matched paths after reset; want no reboot required", key.RebootRequired(), len(key.MatchedPaths()))
+	}
+}
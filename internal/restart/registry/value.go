@@ -0,0 +1,209 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package registry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// The helper functions in this file retrieve and decode registry value data
+// from a BackendKey. They mirror the behavior of the typed Get*Value methods
+// provided by the golang.org/x/sys/windows/registry package so that
+// evaluation results are the same regardless of the Backend in use.
+
+// readStringValue retrieves the string data for the named value. Only
+// REG_SZ and REG_EXPAND_SZ values are supported; environment variable
+// references are not expanded.
+func readStringValue(key BackendKey, name string) (string, uint32, error) {
+	data, valType, err := key.ReadValue(name)
+	if err != nil {
+		return "", valType, err
+	}
+
+	switch valType {
+	case ValueTypeSZ, ValueTypeExpandSZ:
+	default:
+		return "", valType, fmt.Errorf(
+			"value %s of type %s: %w",
+			name,
+			getValueType(valType),
+			ErrUnexpectedType,
+		)
+	}
+
+	return utf16BytesToString(data), valType, nil
+}
+
+// readStringsValue retrieves the collection of strings for the named
+// REG_MULTI_SZ value.
+func readStringsValue(key BackendKey, name string) ([]string, uint32, error) {
+	data, valType, err := key.ReadValue(name)
+	if err != nil {
+		return nil, valType, err
+	}
+
+	if valType != ValueTypeMultiSZ {
+		return nil, valType, fmt.Errorf(
+			"value %s of type %s: %w",
+			name,
+			getValueType(valType),
+			ErrUnexpectedType,
+		)
+	}
+
+	units := bytesToUTF16(data)
+	if len(units) == 0 {
+		return nil, valType, nil
+	}
+
+	// Remove the terminating NUL for the collection as a whole.
+	if units[len(units)-1] == 0 {
+		units = units[:len(units)-1]
+	}
+
+	values := make([]string, 0, 5)
+	from := 0
+	for i, unit := range units {
+		if unit == 0 {
+			values = append(values, string(utf16.Decode(units[from:i])))
+			from = i + 1
+		}
+	}
+
+	return values, valType, nil
+}
+
+// readIntegerValue retrieves the integer data for the named REG_DWORD or
+// REG_QWORD value.
+func readIntegerValue(key BackendKey, name string) (uint64, uint32, error) {
+	data, valType, err := key.ReadValue(name)
+	if err != nil {
+		return 0, valType, err
+	}
+
+	switch valType {
+	case ValueTypeDWORD:
+		if len(data) != 4 {
+			return 0, valType, fmt.Errorf(
+				"DWORD value %s is %d bytes long: %w",
+				name,
+				len(data),
+				ErrInvalidValueData,
+			)
+		}
+
+		return uint64(binary.LittleEndian.Uint32(data)), valType, nil
+
+	case ValueTypeQWORD:
+		if len(data) != 8 {
+			return 0, valType, fmt.Errorf(
+				"QWORD value %s is %d bytes long: %w",
+				name,
+				len(data),
+				ErrInvalidValueData,
+			)
+		}
+
+		return binary.LittleEndian.Uint64(data), valType, nil
+
+	default:
+		return 0, valType, fmt.Errorf(
+			"value %s of type %s: %w",
+			name,
+			getValueType(valType),
+			ErrUnexpectedType,
+		)
+	}
+}
+
+// readBinaryValue retrieves the binary data for the named REG_BINARY value.
+func readBinaryValue(key BackendKey, name string) ([]byte, uint32, error) {
+	data, valType, err := key.ReadValue(name)
+	if err != nil {
+		return nil, valType, err
+	}
+
+	if valType != ValueTypeBinary {
+		return nil, valType, fmt.Errorf(
+			"value %s of type %s: %w",
+			name,
+			getValueType(valType),
+			ErrUnexpectedType,
+		)
+	}
+
+	return data, valType, nil
+}
+
+// bytesToUTF16 converts little-endian encoded bytes to UTF-16 code units. A
+// trailing odd byte is discarded.
+func bytesToUTF16(data []byte) []uint16 {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+
+	return units
+}
+
+// utf16BytesToString decodes little-endian UTF-16 encoded bytes up to (but
+// not including) the first NUL.
+func utf16BytesToString(data []byte) string {
+	units := bytesToUTF16(data)
+	for i, unit := range units {
+		if unit == 0 {
+			units = units[:i]
+			break
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// encodeString encodes the given string as NUL terminated little-endian
+// UTF-16 bytes as used by REG_SZ and REG_EXPAND_SZ values.
+func encodeString(value string) []byte {
+	units := append(utf16.Encode([]rune(value)), 0)
+
+	data := make([]byte, len(units)*2)
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(data[i*2:], unit)
+	}
+
+	return data
+}
+
+// encodeStrings encodes the given collection of strings as used by
+// REG_MULTI_SZ values; each string is NUL terminated and the collection is
+// terminated by an additional NUL.
+func encodeStrings(values []string) []byte {
+	data := make([]byte, 0, 64)
+	for _, value := range values {
+		data = append(data, encodeString(value)...)
+	}
+
+	return append(data, 0, 0)
+}
+
+// encodeDWORD encodes the given value as used by REG_DWORD values.
+func encodeDWORD(value uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+
+	return data
+}
+
+// encodeQWORD encodes the given value as used by REG_QWORD values.
+func encodeQWORD(value uint64) []byte {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, value)
+
+	return data
+}
@@ -0,0 +1,251 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/go-nagios"
+)
+
+// CheckRebootResultVersion is the version of the CheckRebootResult document
+// schema. The version is incremented for changes which are not backwards
+// compatible (e.g., removed or renamed fields); new fields may be added
+// without changing the version.
+const CheckRebootResultVersion int = 1
+
+// CheckRebootResult is a machine-readable representation of the evaluation
+// results suitable for consumption by other tools. Unlike the text reports,
+// paths are emitted as-is without normalizing path separators.
+type CheckRebootResult struct {
+	// Version is the document schema version.
+	Version int `json:"version"`
+
+	// State is the overall service state label (e.g., WARNING).
+	State string `json:"state"`
+
+	// ExitCode is the plugin exit code associated with the overall service
+	// state.
+	ExitCode int `json:"exit_code"`
+
+	// Summary is the one-line summary of the evaluation results.
+	Summary string `json:"summary"`
+
+	// Counts is the number of assertions by evaluation result.
+	Counts AssertionCounts `json:"counts"`
+
+	// Assertions is the result of each assertion, including those which did
+	// not complete evaluation.
+	Assertions []AssertionResult `json:"assertions"`
+
+	// Errors is the collection of errors recorded for the evaluation.
+	Errors []string `json:"errors"`
+}
+
+// AssertionCounts is the number of assertions by evaluation result.
+type AssertionCounts struct {
+	Applied    int `json:"applied"`
+	Matched    int `json:"matched"`
+	NotMatched int `json:"not_matched"`
+	Ignored    int `json:"ignored"`
+	Errors     int `json:"errors"`
+	Unfinished int `json:"unfinished"`
+}
+
+// AssertionResult is the evaluation result of a single assertion.
+type AssertionResult struct {
+	// Identity is the stable identity of the assertion (see
+	// restart.AssertionIdentities).
+	Identity string `json:"identity"`
+
+	// Type is the assertion type (e.g., registry.KeyString).
+	Type string `json:"type"`
+
+	// Path is the fully qualified path of the assertion.
+	Path string `json:"path"`
+
+	// Completed indicates whether evaluation of the assertion completed.
+	Completed bool `json:"completed"`
+
+	// RebootRequired indicates whether the assertion indicates a reboot is
+	// needed.
+	RebootRequired bool `json:"reboot_required"`
+
+	// Ignored indicates whether the assertion has been marked as ignored.
+	Ignored bool `json:"ignored"`
+
+	// ExpectedEvidence is the names of the evidence markers which (if
+	// found) indicate a reboot is needed.
+	ExpectedEvidence []string `json:"expected_evidence"`
+
+	// DiscoveredEvidence is the names of the evidence markers found during
+	// evaluation.
+	DiscoveredEvidence []string `json:"discovered_evidence"`
+
+	// Reasons is the list of reasons a reboot is needed.
+	Reasons []string `json:"reasons"`
+
+	// MatchedPaths is the collection of paths matched during evaluation.
+	MatchedPaths []MatchedPathResult `json:"matched_paths"`
+
+	// DataDisplay is the data associated with the assertion (if supported).
+	DataDisplay string `json:"data_display,omitempty"`
+
+	// Error is the error (if any) recorded during evaluation.
+	Error string `json:"error,omitempty"`
+
+	// PendingSince is the time the assertion was first found to indicate a
+	// reboot is needed (if recorded).
+	PendingSince *time.Time `json:"pending_since,omitempty"`
+
+	// Escalated indicates whether the assertion has been escalated to a
+	// CRITICAL state after the pending grace period.
+	Escalated bool `json:"escalated"`
+
+	// EvaluationSeconds is the amount of time taken to evaluate the
+	// assertion.
+	EvaluationSeconds float64 `json:"evaluation_seconds"`
+}
+
+// MatchedPathResult is a path matched during evaluation of an assertion.
+type MatchedPathResult struct {
+	// Path is the fully qualified matched path.
+	Path string `json:"path"`
+
+	// Ignored indicates whether the matched path has been marked as
+	// ignored.
+	Ignored bool `json:"ignored"`
+
+	// IgnoredBy is the ignore pattern responsible for marking the matched
+	// path as ignored.
+	IgnoredBy string `json:"ignored_by,omitempty"`
+}
+
+// CheckRebootJSON returns a machine-readable representation of the given
+// completed and unfinished assertions along with the given overall service
+// state, one-line summary and errors. The given identities are used to
+// identify each assertion; see restart.AssertionIdentities.
+func CheckRebootJSON(
+	completed restart.RebootRequiredAsserters,
+	unfinished restart.RebootRequiredAsserters,
+	identities map[restart.RebootRequiredAsserter]string,
+	state nagios.ServiceState,
+	summary string,
+	errs []error,
+) CheckRebootResult {
+	result := CheckRebootResult{
+		Version:  CheckRebootResultVersion,
+		State:    state.Label,
+		ExitCode: state.ExitCode,
+		Summary:  summary,
+		Counts: AssertionCounts{
+			Applied:    completed.NumApplied() + len(unfinished),
+			Matched:    completed.NumMatched(),
+			NotMatched: completed.NumNotMatched(),
+			Ignored:    completed.NumIgnored(),
+			Errors:     completed.NumErrors(false),
+			Unfinished: len(unfinished),
+		},
+		Assertions: make([]AssertionResult, 0, len(completed)+len(unfinished)),
+		Errors:     make([]string, 0, len(errs)),
+	}
+
+	for _, assertion := range completed {
+		result.Assertions = append(result.Assertions, assertionResult(assertion, identities, true))
+	}
+
+	for _, assertion := range unfinished {
+		result.Assertions = append(result.Assertions, assertionResult(assertion, identities, false))
+	}
+
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	return result
+}
+
+// assertionResult returns the evaluation result of the given assertion.
+// Evaluation results are only recorded for completed assertions.
+func assertionResult(
+	assertion restart.RebootRequiredAsserter,
+	identities map[restart.RebootRequiredAsserter]string,
+	completed bool,
+) AssertionResult {
+	assertionType := strings.TrimPrefix(fmt.Sprintf("%T", assertion), "*")
+
+	identity, ok := identities[assertion]
+	if !ok {
+		identity = assertionType + ":" + assertion.String()
+	}
+
+	result := AssertionResult{
+		Identity:           identity,
+		Type:               assertionType,
+		Path:               assertion.String(),
+		Completed:          completed,
+		ExpectedEvidence:   nonNil(restart.ExpectedEvidenceMarkers(assertion)),
+		DiscoveredEvidence: []string{},
+		Reasons:            []string{},
+		MatchedPaths:       []MatchedPathResult{},
+	}
+
+	if !completed {
+		return result
+	}
+
+	result.RebootRequired = assertion.RebootRequired()
+	result.Ignored = assertion.Ignored()
+	result.DiscoveredEvidence = nonNil(restart.DiscoveredEvidenceMarkers(assertion))
+	result.Escalated = restart.IsEscalated(assertion)
+	result.EvaluationSeconds = restart.EvaluationDuration(assertion).Seconds()
+
+	if assertion.HasEvidence() {
+		result.Reasons = nonNil(assertion.RebootReasons())
+	}
+
+	for _, path := range assertion.MatchedPaths() {
+		mp := MatchedPathResult{Path: path.String()}
+
+		if v, ok := path.(restart.IgnoredMatchedPath); ok {
+			if ignorePattern, ignored := v.IgnoredBy(); ignored {
+				mp.Ignored = true
+				mp.IgnoredBy = ignorePattern.String()
+			}
+		}
+
+		result.MatchedPaths = append(result.MatchedPaths, mp)
+	}
+
+	if v, ok := assertion.(restart.RebootRequiredAsserterWithDataDisplay); ok {
+		result.DataDisplay = v.DataDisplay()
+	}
+
+	if err := assertion.Err(); err != nil {
+		result.Error = err.Error()
+	}
+
+	if since := restart.PendingSince(assertion); !since.IsZero() {
+		result.PendingSince = &since
+	}
+
+	return result
+}
+
+// nonNil returns the given collection or an empty collection if nil so that
+// empty collections are consistently represented in JSON output.
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+
+	return items
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+	"github.com/atc0005/go-nagios"
+)
+
+// TestCheckRebootJSON asserts that the JSON document lists the identity,
+// evidence and matched paths of each assertion without normalizing path
+// separators and that ignored matched paths are flagged.
+func TestCheckRebootJSON(t *testing.T) {
+	t.Parallel()
+
+	const (
+		pendingPath = `SOFTWARE\Vendor\RebootPending`
+		ignoredPath = `SOFTWARE\Other\RebootPending`
+	)
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, pendingPath)
+	mb.CreateKey(registry.RootKeyLocalMachine, ignoredPath)
+
+	pending := registry.NewKey(
+		registry.RootKeyLocalMachine,
+		pendingPath,
+		"",
+		registry.KeyRebootEvidence{KeyExists: true},
+		registry.KeyAssertions{},
+	)
+
+	ignored := registry.NewKey(
+		registry.RootKeyLocalMachine,
+		ignoredPath,
+		"",
+		registry.KeyRebootEvidence{KeyExists: true},
+		registry.KeyAssertions{},
+	)
+
+	unfinished := registry.NewKey(
+		registry.RootKeyLocalMachine,
+		`SOFTWARE\Slow`,
+		"",
+		registry.KeyRebootEvidence{KeyExists: true},
+		registry.KeyAssertions{},
+	)
+
+	assertions := restart.RebootRequiredAsserters{pending, ignored}
+	registry.UseBackend(assertions, mb)
+
+	identities := restart.AssertionIdentities(restart.RebootRequiredAsserters{pending, ignored, unfinished})
+
+	assertions.Evaluate(context.Background())
+
+	ignorePattern, err := restart.ParseIgnorePattern(`registry:Other`)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore pattern: %v", err)
+	}
+	assertions.Filter(restart.IgnorePatterns{ignorePattern})
+
+	result := CheckRebootJSON(
+		assertions,
+		restart.RebootRequiredAsserters{unfinished},
+		identities,
+		nagios.ServiceState{Label: nagios.StateWARNINGLabel, ExitCode: nagios.StateWARNINGExitCode},
+		"WARNING: Reboot needed",
+		[]error{errors.New("reboot assertions matched, reboot needed")},
+	)
+
+	if result.Version != CheckRebootResultVersion || result.ExitCode != nagios.StateWARNINGExitCode {
+		t.Errorf("ERROR: got version %d and exit code %d", result.Version, result.ExitCode)
+	}
+
+	wantCounts := AssertionCounts{Applied: 3, Matched: 1, NotMatched: 1, Ignored: 1, Unfinished: 1}
+	if result.Counts != wantCounts {
+		t.Errorf("ERROR: got counts %+v; want %+v", result.Counts, wantCounts)
+	}
+
+	if len(result.Assertions) != 3 {
+		t.Fatalf("ERROR: got %d assertions; want 3", len(result.Assertions))
+	}
+
+	got := result.Assertions[0]
+	if got.Identity != `registry.Key:HKEY_LOCAL_MACHINE\`+pendingPath || got.Type != "registry.Key" {
+		t.Errorf("ERROR: got identity %q and type %q", got.Identity, got.Type)
+	}
+
+	if len(got.ExpectedEvidence) != 1 || got.ExpectedEvidence[0] != "KeyExists" ||
+		len(got.DiscoveredEvidence) != 1 || got.DiscoveredEvidence[0] != "KeyExists" {
+		t.Errorf("ERROR: got expected evidence %v and discovered evidence %v", got.ExpectedEvidence, got.DiscoveredEvidence)
+	}
+
+	if len(got.MatchedPaths) != 1 || got.MatchedPaths[0].Path != `HKEY_LOCAL_MACHINE\`+pendingPath || got.MatchedPaths[0].Ignored {
+		t.Errorf("ERROR: got matched paths %+v", got.MatchedPaths)
+	}
+
+	if mp := result.Assertions[1].MatchedPaths; len(mp) != 1 || !mp[0].Ignored || mp[0].IgnoredBy == "" {
+		t.Errorf("ERROR: got matched paths %+v; want ignored matched path", mp)
+	}
+
+	if result.Assertions[2].Completed || result.Assertions[2].RebootRequired {
+		t.Errorf("ERROR: unfinished assertion reported as completed")
+	}
+
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("ERROR: failed to encode result: %v", err)
+	}
+}
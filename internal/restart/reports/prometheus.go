@@ -0,0 +1,189 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package reports
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/go-nagios"
+)
+
+// metricPrefix is the prefix used for all emitted metric names.
+const metricPrefix string = "check_restart_"
+
+// textfilePerms is the permissions of a written textfile. The textfile
+// collector (e.g., node_exporter) usually runs as a different user than the
+// plugin and must be able to read the file.
+const textfilePerms os.FileMode = 0o644
+
+// OpenMetricsContentType is the HTTP Content-Type of metrics emitted by
+// WriteOpenMetrics.
+const OpenMetricsContentType string = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// PrometheusMetrics is the collection of evaluation results emitted as
+// metrics in the Prometheus text exposition format.
+type PrometheusMetrics struct {
+	// Completed is the collection of assertions which completed evaluation.
+	Completed restart.RebootRequiredAsserters
+
+	// Unfinished is the collection of assertions which did not complete
+	// evaluation.
+	Unfinished restart.RebootRequiredAsserters
+
+	// Identities is the identity of each assertion (see
+	// restart.AssertionIdentities) used to label per-assertion metrics.
+	Identities map[restart.RebootRequiredAsserter]string
+
+	// State is the overall service state.
+	State nagios.ServiceState
+
+	// EvaluationDuration is the amount of time taken to evaluate all
+	// assertions.
+	EvaluationDuration time.Duration
+
+	// Uptime is the amount of time elapsed since the system was last
+	// booted. The uptime metric is omitted if zero.
+	Uptime time.Duration
+
+	// Timestamp is the time the evaluation completed.
+	Timestamp time.Time
+}
+
+// WritePrometheusMetrics emits the given evaluation results as gauges in the
+// Prometheus text exposition format (e.g., for use with the node_exporter
+// textfile collector).
+func WritePrometheusMetrics(w io.Writer, metrics PrometheusMetrics) error {
+	bw := bufio.NewWriter(w)
+
+	var rebootRequired int
+	if metrics.Completed.RebootRequired() {
+		rebootRequired = 1
+	}
+
+	writeGauge(bw, "reboot_required", "Whether any (non-ignored) assertion indicates a reboot is needed.", rebootRequired)
+	writeGauge(bw, "state", "Service state exit code (0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN).", metrics.State.ExitCode)
+	writeGauge(bw, "assertions_applied", "Number of assertions applied.", metrics.Completed.NumApplied()+len(metrics.Unfinished))
+	writeGauge(bw, "assertions_matched", "Number of assertions which indicate a reboot is needed.", metrics.Completed.NumMatched())
+	writeGauge(bw, "assertions_ignored", "Number of assertions marked as ignored.", metrics.Completed.NumIgnored())
+	writeGauge(bw, "assertions_unfinished", "Number of assertions which did not complete evaluation before the timeout.", len(metrics.Unfinished))
+	writeGauge(bw, "errors", "Number of errors encountered during evaluation.", metrics.Completed.NumErrors(false))
+
+	writeHeader(bw, "assertion_matched", "Whether the assertion indicates a reboot is needed.")
+	for _, assertion := range metrics.Completed {
+		var matched int
+		if assertion.RebootRequired() {
+			matched = 1
+		}
+
+		assertionType, assertionLabel := assertionLabels(assertion, metrics.Identities)
+
+		_, _ = fmt.Fprintf(
+			bw,
+			"%sassertion_matched{assertion=\"%s\",type=\"%s\"} %d\n",
+			metricPrefix,
+			escapeLabelValue(assertionLabel),
+			escapeLabelValue(assertionType),
+			matched,
+		)
+	}
+
+	writeGauge(bw, "evaluation_duration_seconds", "Amount of time taken to evaluate all assertions.", metrics.EvaluationDuration.Seconds())
+
+	if metrics.Uptime > 0 {
+		writeGauge(bw, "uptime_seconds", "Amount of time elapsed since the system was last booted.", metrics.Uptime.Seconds())
+	}
+
+	if !metrics.Timestamp.IsZero() {
+		writeGauge(bw, "last_run_timestamp_seconds", "Time the evaluation completed in seconds since the epoch.", metrics.Timestamp.Unix())
+	}
+
+	return bw.Flush()
+}
+
+// WriteOpenMetrics emits the given evaluation results as gauges in the
+// OpenMetrics text format (e.g., for use with a Prometheus scrape endpoint).
+// The gauges are the same as those emitted by WritePrometheusMetrics followed
+// by the required end of exposition marker.
+func WriteOpenMetrics(w io.Writer, metrics PrometheusMetrics) error {
+	if err := WritePrometheusMetrics(w, metrics); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "# EOF\n")
+
+	return err
+}
+
+// WritePrometheusTextfile replaces the given textfile collector file (e.g.,
+// /var/lib/node_exporter/textfile/check_restart.prom) with the given
+// evaluation results. The file is replaced atomically so that partially
+// written metrics are never collected.
+func WritePrometheusTextfile(filename string, metrics PrometheusMetrics) error {
+	err := restart.WriteFileAtomically(filename, textfilePerms, func(w io.Writer) error {
+		return WritePrometheusMetrics(w, metrics)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	logger.Printf("Metrics written to %s", filename)
+
+	return nil
+}
+
+// writeHeader emits the HELP and TYPE lines for the given gauge.
+func writeHeader(w io.Writer, name string, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %[1]s%[2]s %[3]s\n# TYPE %[1]s%[2]s gauge\n", metricPrefix, name, help)
+}
+
+// writeGauge emits the given gauge without labels.
+func writeGauge(w io.Writer, name string, help string, value any) {
+	writeHeader(w, name, help)
+	_, _ = fmt.Fprintf(w, "%s%s %v\n", metricPrefix, name, value)
+}
+
+// assertionLabels returns the type (e.g., registry) and assertion label
+// values for the given assertion. The assertion label is the identity of
+// the assertion without the leading type so that assertions sharing the same
+// path remain distinct.
+func assertionLabels(
+	assertion restart.RebootRequiredAsserter,
+	identities map[restart.RebootRequiredAsserter]string,
+) (string, string) {
+	assertionType := strings.TrimPrefix(fmt.Sprintf("%T", assertion), "*")
+	pkg, _, _ := strings.Cut(assertionType, ".")
+
+	identity, ok := identities[assertion]
+	if !ok {
+		return pkg, assertion.String()
+	}
+
+	_, label, _ := strings.Cut(identity, ":")
+
+	return pkg, label
+}
+
+// labelValueEscaper escapes the characters which are not permitted as-is
+// within a label value of the Prometheus text exposition format.
+var labelValueEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+// escapeLabelValue escapes the given label value (e.g., a Windows registry
+// path) for use within the Prometheus text exposition format.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package reports
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+	"github.com/atc0005/go-nagios"
+)
+
+// TestWritePrometheusTextfile asserts that evaluation results are written to
+// a textfile as gauges and that Windows paths are escaped within label
+// values.
+func TestWritePrometheusTextfile(t *testing.T) {
+	t.Parallel()
+
+	const keyPath = `SOFTWARE\Vendor\RebootPending`
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, keyPath)
+
+	assertions := restart.RebootRequiredAsserters{
+		registry.NewKey(
+			registry.RootKeyLocalMachine,
+			keyPath,
+			"",
+			registry.KeyRebootEvidence{KeyExists: true},
+			registry.KeyAssertions{},
+		),
+	}
+	registry.UseBackend(assertions, mb)
+
+	identities := restart.AssertionIdentities(assertions)
+
+	assertions.Evaluate(context.Background())
+
+	filename := filepath.Join(t.TempDir(), "check_restart.prom")
+
+	metrics := PrometheusMetrics{
+		Completed:          assertions,
+		Identities:         identities,
+		State:              nagios.ServiceState{Label: nagios.StateWARNINGLabel, ExitCode: nagios.StateWARNINGExitCode},
+		EvaluationDuration: 1500 * time.Millisecond,
+		Uptime:             90 * time.Second,
+		Timestamp:          time.Unix(1683727200, 0),
+	}
+
+	if err := WritePrometheusTextfile(filename, metrics); err != nil {
+		t.Fatalf("ERROR: failed to write textfile: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ERROR: failed to read textfile: %v", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE check_restart_reboot_required gauge\ncheck_restart_reboot_required 1\n",
+		"check_restart_assertions_matched 1\n",
+		"check_restart_errors 0\n",
+		`check_restart_assertion_matched{assertion="HKEY_LOCAL_MACHINE\\SOFTWARE\\Vendor\\RebootPending",type="registry"} 1` + "\n",
+		"check_restart_evaluation_duration_seconds 1.5\n",
+		"check_restart_uptime_seconds 90\n",
+		"check_restart_last_run_timestamp_seconds 1683727200\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("ERROR: textfile does not contain %q:\n%s", want, content)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("ERROR: got %d entries (%v); want only the textfile", len(entries), err)
+	}
+
+	// The textfile collector usually runs as a different user. Windows only
+	// supports the read-only permission bit.
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatalf("ERROR: failed to stat textfile: %v", err)
+		}
+
+		if got := info.Mode().Perm(); got != 0o644 {
+			t.Errorf("ERROR: got textfile permissions %v; want %v", got, os.FileMode(0o644))
+		}
+	}
+}
+
+// TestEscapeLabelValue asserts that backslashes, double quotes and newlines
+// are escaped within label values.
+func TestEscapeLabelValue(t *testing.T) {
+	t.Parallel()
+
+	got := escapeLabelValue("C:\\Windows\\\"temp\"\nfile")
+	want := `C:\\Windows\\\"temp\"\nfile`
+
+	if got != want {
+		t.Errorf("ERROR: got %q; want %q", got, want)
+	}
+}
+
+// TestWriteOpenMetrics asserts that the OpenMetrics exposition is terminated
+// by the end of exposition marker.
+func TestWriteOpenMetrics(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+
+	metrics := PrometheusMetrics{
+		State: nagios.ServiceState{Label: nagios.StateOKLabel, ExitCode: nagios.StateOKExitCode},
+	}
+
+	if err := WriteOpenMetrics(&buf, metrics); err != nil {
+		t.Fatalf("ERROR: failed to write metrics: %v", err)
+	}
+
+	got := buf.String()
+
+	if !strings.Contains(got, "check_restart_reboot_required 0\n") {
+		t.Errorf("ERROR: metrics do not contain reboot_required gauge:\n%s", got)
+	}
+
+	if !strings.HasSuffix(got, "\n# EOF\n") || strings.Count(got, "# EOF") != 1 {
+		t.Errorf("ERROR: metrics not terminated by a single EOF marker:\n%s", got)
+	}
+}
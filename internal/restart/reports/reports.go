@@ -11,16 +11,26 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+	"github.com/atc0005/check-restart/internal/restart/maintenance"
 	"github.com/atc0005/go-nagios"
 )
 
 // CheckRebootOneLineSummary returns a one-line summary of the evaluation
 // results suitable for display and notification purposes. A boolean value is
 // accepted which indicates whether assertion values marked as ignored (during
-// filtering) should also be considered.
-func CheckRebootOneLineSummary(assertions restart.RebootRequiredAsserters, evalIgnored bool) string {
+// filtering) should also be considered. If applied, the state from the given
+// maintenance window decision is used and the reason for it is noted. The
+// given scheduled shutdown (if not the zero value) is also noted.
+func CheckRebootOneLineSummary(
+	assertions restart.RebootRequiredAsserters,
+	evalIgnored bool,
+	decision maintenance.Decision,
+	scheduled boot.ScheduledShutdown,
+) string {
 	var summary string
 
 	switch {
@@ -29,9 +39,17 @@ func CheckRebootOneLineSummary(assertions restart.RebootRequiredAsserters, evalI
 	// whether a successful determination has been made that a reboot is
 	// needed.
 	case assertions.RebootRequired():
+		stateLabel := assertions.ServiceState().Label
+		if decision.Applied {
+			stateLabel = decision.State.Label
+		}
+
 		summary = fmt.Sprintf(
-			"%s: Reboot needed (assertions: %d applied, %d matched, %d ignored)",
-			assertions.ServiceState().Label,
+			"%s: Reboot needed%s%s%s (assertions: %d applied, %d matched, %d ignored)",
+			stateLabel,
+			pendingSummary(assertions),
+			maintenanceSummary(decision),
+			scheduledSummary(scheduled),
 			assertions.NumApplied(),
 			assertions.NumMatched(),
 			assertions.NumIgnored(),
@@ -68,6 +86,46 @@ func CheckRebootOneLineSummary(assertions restart.RebootRequiredAsserters, evalI
 
 }
 
+// pendingSummary returns a brief note for the one-line summary indicating
+// when the oldest assertion indicating a reboot is needed was first found to
+// do so and whether any assertions were escalated to a CRITICAL state as a
+// result. An empty string is returned if this has not been recorded.
+func pendingSummary(assertions restart.RebootRequiredAsserters) string {
+	oldest := assertions.OldestPending()
+	if oldest.IsZero() {
+		return ""
+	}
+
+	note := " since " + oldest.Format(time.RFC3339)
+	if assertions.HasEscalated() {
+		note += ", escalated after grace period"
+	}
+
+	return note
+}
+
+// maintenanceSummary returns a brief note for the one-line summary
+// explaining how maintenance windows were applied to the service state. An
+// empty string is returned if maintenance windows were not applied.
+func maintenanceSummary(decision maintenance.Decision) string {
+	if !decision.Applied || decision.Reason == "" {
+		return ""
+	}
+
+	return "; " + decision.Reason
+}
+
+// scheduledSummary returns a brief note for the one-line summary indicating
+// when a shutdown or reboot has been scheduled. An empty string is returned
+// if no shutdown has been scheduled.
+func scheduledSummary(scheduled boot.ScheduledShutdown) string {
+	if scheduled.Time.IsZero() {
+		return ""
+	}
+
+	return "; " + scheduled.String()
+}
+
 //nolint:all
 //lint:ignore U1000 disabling use per GH-119, but may re-enable later via flag
 func writeReportHeader(w io.Writer, assertions restart.RebootRequiredAsserters, verbose bool) {
@@ -106,7 +164,11 @@ func writeReportHeader(w io.Writer, assertions restart.RebootRequiredAsserters,
 	)
 }
 
-func writeAssertions(w io.Writer, assertions restart.RebootRequiredAsserters, verbose bool) {
+// writeAssertions emits the reboot reasons for each assertion in the
+// collection with evidence. If specified, the matched paths marked as ignored
+// (including those of assertions which are not ignored as a whole) are
+// listed along with the responsible ignore pattern details.
+func writeAssertions(w io.Writer, assertions restart.RebootRequiredAsserters, showIgnored bool, verbose bool) {
 
 	// Specific "template" strings used to control formatting/indentation
 	// levels for the first item in a listing and any "sub details" associated
@@ -124,7 +186,13 @@ func writeAssertions(w io.Writer, assertions restart.RebootRequiredAsserters, ve
 
 		// While there is *usually* one reason for a reboot, the current
 		// design allows for multiple reasons.
-		for _, reason := range assertion.RebootReasons() {
+		for i, reason := range assertion.RebootReasons() {
+			// The evaluation time is noted once alongside the first reason
+			// for the assertion.
+			if verbose && i == 0 {
+				reason += evaluationDurationSuffix(assertion)
+			}
+
 			_, _ = fmt.Fprintf(w, topDetailTemplateStr, reason, nagios.CheckOutputEOL)
 
 			// We are processing types beneath RebootReasons so that we can
@@ -135,15 +203,96 @@ func writeAssertions(w io.Writer, assertions restart.RebootRequiredAsserters, ve
 			}
 
 		}
+
+		// Only some matched paths may be ignored for an assertion which is
+		// not ignored as a whole.
+		if showIgnored {
+			writeIgnoredPaths(w, assertion, subDetailTemplateStr)
+		}
+
+		if assertion.RebootRequired() {
+			writePendingSince(w, assertion, subDetailTemplateStr)
+		}
+	}
+
+	_, _ = fmt.Fprint(w, nagios.CheckOutputEOL)
+}
+
+// writePendingSince emits the time the assertion was first found to indicate
+// that a reboot is needed (if recorded) and whether the assertion has been
+// escalated to a CRITICAL state as a result.
+func writePendingSince(w io.Writer, assertion restart.RebootRequiredAsserter, subDetailTemplateStr string) {
+	since := restart.PendingSince(assertion)
+	if since.IsZero() {
+		return
+	}
+
+	line := fmt.Sprintf(
+		"pending since %s (%s)",
+		since.Format(time.RFC3339),
+		time.Since(since).Round(time.Second),
+	)
+
+	if restart.IsEscalated(assertion) {
+		line += "; escalated to " + nagios.StateCRITICALLabel
+	}
+
+	_, _ = fmt.Fprintf(w, subDetailTemplateStr, line, nagios.CheckOutputEOL)
+}
+
+// writeIgnoredPaths emits each matched path for the assertion marked as
+// ignored along with the comment, owner and expiration time recorded for the
+// responsible ignore pattern.
+func writeIgnoredPaths(w io.Writer, assertion restart.RebootRequiredAsserter, subDetailTemplateStr string) {
+	for _, path := range assertion.MatchedPaths() {
+		v, ok := path.(restart.IgnoredMatchedPath)
+		if !ok {
+			continue
+		}
+
+		ignorePattern, ignored := v.IgnoredBy()
+		if !ignored {
+			continue
+		}
+
+		line := "ignored: " + path.String()
+		if details := ignorePattern.Details(); details != "" {
+			line += " (" + details + ")"
+		}
+
+		_, _ = fmt.Fprintf(w, subDetailTemplateStr, line, nagios.CheckOutputEOL)
+	}
+}
+
+// writeExpiredIgnorePatterns emits a warning for each ignore pattern which
+// is no longer applied because it has expired.
+func writeExpiredIgnorePatterns(w io.Writer, expiredIgnorePatterns restart.IgnorePatterns) {
+	if len(expiredIgnorePatterns) == 0 {
+		return
 	}
 
 	_, _ = fmt.Fprint(w, nagios.CheckOutputEOL)
+
+	for _, ip := range expiredIgnorePatterns {
+		line := fmt.Sprintf("%s: Expired ignore entry no longer applied: %s", nagios.StateWARNINGLabel, ip)
+		if details := ip.Details(); details != "" {
+			line += " (" + details + ")"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s%s", line, nagios.CheckOutputEOL)
+	}
 }
 
 // CheckRebootReport returns a formatted report of the evaluation results
 // suitable for display and notification purposes. If specified, additional
-// details are provided.
-func CheckRebootReport(assertions restart.RebootRequiredAsserters, showIgnored bool, verbose bool) string {
+// details are provided. A warning is included for each of the given expired
+// ignore patterns.
+func CheckRebootReport(
+	assertions restart.RebootRequiredAsserters,
+	expiredIgnorePatterns restart.IgnorePatterns,
+	showIgnored bool,
+	verbose bool,
+) string {
 	var report strings.Builder
 
 	// Disabling per GH-119, but may re-enable later via flag.
@@ -166,13 +315,15 @@ func CheckRebootReport(assertions restart.RebootRequiredAsserters, showIgnored b
 
 		logger.Printf("%d notIgnoredAssertions to process", len(notIgnoredAssertions))
 
-		writeAssertions(&report, notIgnoredAssertions, verbose)
+		writeAssertions(&report, notIgnoredAssertions, showIgnored, verbose)
 
 	case assertions.IsOKState():
 		_, _ = fmt.Fprintf(&report, "Reboot not required%s", nagios.CheckOutputEOL)
 
 	}
 
+	writeExpiredIgnorePatterns(&report, expiredIgnorePatterns)
+
 	if assertions.HasIgnored() && showIgnored {
 		_, _ = fmt.Fprintf(
 			&report,
@@ -184,7 +335,7 @@ func CheckRebootReport(assertions restart.RebootRequiredAsserters, showIgnored b
 
 		logger.Printf("%d ignoredAssertions to process", len(ignoredAssertions))
 
-		writeAssertions(&report, ignoredAssertions, verbose)
+		writeAssertions(&report, ignoredAssertions, showIgnored, verbose)
 	}
 
 	// Normalize output so that Windows-specific paths are less likely to be
@@ -193,6 +344,186 @@ func CheckRebootReport(assertions restart.RebootRequiredAsserters, showIgnored b
 
 }
 
+// TimeoutOneLineSummary returns a one-line summary of evaluation results
+// suitable for display and notification purposes when the evaluation of some
+// assertions did not complete before the given timeout. The counts reflect
+// only the completed assertions.
+func TimeoutOneLineSummary(
+	completed restart.RebootRequiredAsserters,
+	unfinished restart.RebootRequiredAsserters,
+	timeout time.Duration,
+) string {
+	return fmt.Sprintf(
+		"%s: Evaluation timed out after %s; %d of %d assertions did not complete (assertions: %d applied, %d matched, %d ignored)",
+		nagios.StateUNKNOWNLabel,
+		timeout,
+		len(unfinished),
+		len(completed)+len(unfinished),
+		completed.NumApplied(),
+		completed.NumMatched(),
+		completed.NumIgnored(),
+	)
+}
+
+// TimeoutReport returns a formatted list of the assertions which did not
+// complete before the given timeout suitable for display and notification
+// purposes. This is intended to precede the report for the completed
+// assertions.
+func TimeoutReport(unfinished restart.RebootRequiredAsserters, timeout time.Duration) string {
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Assertions not completed within %s:%s",
+		timeout,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, assertion := range unfinished {
+		_, _ = fmt.Fprintf(&report, "\n  - %s%s", assertion, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%[1]sResults of completed assertions:%[1]s%[1]s",
+		nagios.CheckOutputEOL,
+	)
+
+	return substituteSeparators(report.String())
+}
+
+// CheckRestartOneLineSummary returns a one-line summary of the service
+// restart evaluation results suitable for display and notification purposes.
+// A boolean value is accepted which indicates whether assertion values marked
+// as ignored (during filtering) should also be considered.
+func CheckRestartOneLineSummary(assertions restart.RebootRequiredAsserters, evalIgnored bool) string {
+	var summary string
+
+	switch {
+
+	// We're not checking whether errors were encountered at this point, just
+	// whether a successful determination has been made that a restart is
+	// needed.
+	case assertions.RebootRequired():
+		summary = fmt.Sprintf(
+			"%s: Service restart needed (assertions: %d applied, %d matched, %d ignored)",
+			assertions.ServiceState().Label,
+			assertions.NumApplied(),
+			assertions.NumMatched(),
+			assertions.NumIgnored(),
+		)
+
+	// Errors have occurred which prevent accurately detecting whether a
+	// restart is needed.
+	case assertions.HasErrors(evalIgnored):
+		summary = fmt.Sprintf(
+			"%s: Service restart evaluation failed; %d errors (assertions: %d applied, %d matched, %d ignored)",
+			assertions.ServiceState().Label,
+			assertions.NumErrors(evalIgnored),
+			assertions.NumApplied(),
+			assertions.NumMatched(),
+			assertions.NumIgnored(),
+		)
+
+	case assertions.IsOKState():
+		summary = fmt.Sprintf(
+			"%s: Service restart not needed (assertions: %d applied, %d matched, %d ignored)",
+			assertions.ServiceState().Label,
+			assertions.NumApplied(),
+			assertions.NumMatched(),
+			assertions.NumIgnored(),
+		)
+
+	default:
+		summary = "BUG: Expected assertions collection state unexpected"
+
+	}
+
+	return summary
+
+}
+
+// CheckRestartReport returns a formatted report of the service restart
+// evaluation results suitable for display and notification purposes. If
+// specified, additional details are provided. A warning is included for each
+// of the given expired ignore patterns.
+func CheckRestartReport(
+	assertions restart.RebootRequiredAsserters,
+	expiredIgnorePatterns restart.IgnorePatterns,
+	showIgnored bool,
+	verbose bool,
+) string {
+	var report strings.Builder
+
+	switch {
+
+	case assertions.RebootRequired():
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Service restart required for:%[1]s",
+			nagios.CheckOutputEOL,
+		)
+
+		notIgnoredAssertions := assertions.NotIgnoredItems()
+
+		logger.Printf("%d notIgnoredAssertions to process", len(notIgnoredAssertions))
+
+		writeAssertions(&report, notIgnoredAssertions, showIgnored, verbose)
+
+	case assertions.IsOKState():
+		_, _ = fmt.Fprintf(&report, "Service restart not required%s", nagios.CheckOutputEOL)
+
+		if verbose {
+			writeDataDisplay(&report, assertions)
+		}
+
+	}
+
+	writeExpiredIgnorePatterns(&report, expiredIgnorePatterns)
+
+	if assertions.HasIgnored() && showIgnored {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%[1]sAssertions ignored:%[1]s",
+			nagios.CheckOutputEOL,
+		)
+
+		ignoredAssertions := assertions.IgnoredItems()
+
+		logger.Printf("%d ignoredAssertions to process", len(ignoredAssertions))
+
+		writeAssertions(&report, ignoredAssertions, showIgnored, verbose)
+	}
+
+	return report.String()
+
+}
+
+// evaluationDurationSuffix returns the amount of time taken to evaluate the
+// assertion formatted for display after the assertion. An empty string is
+// returned if the assertion does not record evaluation durations.
+func evaluationDurationSuffix(assertion restart.RebootRequiredAsserter) string {
+	if _, ok := assertion.(restart.EvaluationTimer); !ok {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		" (evaluated in %s)",
+		restart.EvaluationDuration(assertion).Round(time.Microsecond),
+	)
+}
+
+// writeDataDisplay emits the display value for each assertion in the
+// collection providing one.
+func writeDataDisplay(w io.Writer, assertions restart.RebootRequiredAsserters) {
+	for _, assertion := range assertions {
+		if v, ok := assertion.(restart.RebootRequiredAsserterWithDataDisplay); ok {
+			_, _ = fmt.Fprintf(w, "%s  - %s%s", nagios.CheckOutputEOL, v.DataDisplay(), nagios.CheckOutputEOL)
+		}
+	}
+}
+
 func appendAdditionalContext(
 	w io.Writer,
 	assertion restart.RebootRequiredAsserter,
@@ -0,0 +1,255 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package reports
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+	"github.com/atc0005/check-restart/internal/restart/files"
+	"github.com/atc0005/check-restart/internal/restart/maintenance"
+	"github.com/atc0005/check-restart/internal/restart/registry"
+)
+
+// TestCheckRebootReportIgnoreDetails asserts that the comment recorded for
+// an ignore pattern is shown next to each ignored matched path and that
+// expired ignore patterns are reported as a warning.
+func TestCheckRebootReportIgnoreDetails(t *testing.T) {
+	t.Parallel()
+
+	const keyPath = `SOFTWARE\Vendor\RebootPending`
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, keyPath)
+
+	assertions := restart.RebootRequiredAsserters{
+		registry.NewKey(
+			registry.RootKeyLocalMachine,
+			keyPath,
+			"",
+			registry.KeyRebootEvidence{KeyExists: true},
+			registry.KeyAssertions{},
+		),
+	}
+	registry.UseBackend(assertions, mb)
+
+	assertions.Evaluate(context.Background())
+
+	const content = `{
+  "version": 1,
+  "ignore": [
+    {"pattern": "Vendor\\RebootPending", "owner": "ops", "comment": "vendor agent never clears flag"},
+    {"pattern": "Other", "comment": "retired agent", "expires": "2000-01-01"}
+  ]
+}`
+
+	ignorePatterns, err := restart.ParseIgnoreFile("ignore.json", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore file: %v", err)
+	}
+
+	active, expired := ignorePatterns.Partition(time.Now())
+	assertions.Filter(active)
+
+	if assertions.RebootRequired() {
+		t.Fatal("ERROR: reboot unexpectedly required after filtering")
+	}
+
+	report := CheckRebootReport(assertions, expired, true, false)
+
+	for _, want := range []string{
+		"ignored: HKEY_LOCAL_MACHINE/SOFTWARE/Vendor/RebootPending (vendor agent never clears flag; owner: ops)",
+		"WARNING: Expired ignore entry no longer applied: substring:Other (retired agent; expires: ",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("ERROR: report does not contain %q:\n%s", want, report)
+		}
+	}
+
+	if report := CheckRebootReport(assertions, nil, false, false); strings.Contains(report, "ignored:") {
+		t.Errorf("ERROR: ignored paths shown without show ignored option:\n%s", report)
+	}
+
+	report = CheckRebootReport(assertions, nil, true, true)
+	if strings.Count(report, "(evaluated in ") != 1 || strings.Contains(report, "Evaluation times:") {
+		t.Errorf("ERROR: evaluation time not shown once alongside assertion in verbose report:\n%s", report)
+	}
+
+	for _, line := range strings.Split(report, "\n") {
+		if strings.Contains(line, "(evaluated in ") && !strings.Contains(line, "Vendor/RebootPending") {
+			t.Errorf("ERROR: evaluation time not shown on the line for the assertion: %q", line)
+		}
+	}
+}
+
+// TestCheckRebootReportPartiallyIgnored asserts that the matched paths
+// ignored for an assertion which is still not ignored as a whole are shown
+// if requested.
+func TestCheckRebootReportPartiallyIgnored(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"kernel.pending", "libc6.pending"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+	}
+
+	assertions := restart.RebootRequiredAsserters{
+		files.NewFileGlob(
+			files.NewFile(filepath.Join(dir, "*.pending"), "", "", files.FileRebootEvidence{}, files.FileAssertions{}),
+			0,
+			0,
+			files.FileGlobRebootEvidence{MatchesFound: true},
+		),
+	}
+
+	assertions.Evaluate(context.Background())
+
+	ignorePattern, err := restart.ParseIgnorePattern("file:glob:**/libc6.pending")
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore pattern: %v", err)
+	}
+	assertions.Filter(restart.IgnorePatterns{ignorePattern})
+
+	if !assertions.RebootRequired() {
+		t.Fatal("ERROR: reboot unexpectedly not required with one of two entries ignored")
+	}
+
+	want := "ignored: " + filepath.ToSlash(filepath.Join(dir, "libc6.pending"))
+
+	report := CheckRebootReport(assertions, nil, true, false)
+	if !strings.Contains(report, want) {
+		t.Errorf("ERROR: report does not contain %q:\n%s", want, report)
+	}
+
+	if strings.Contains(report, "ignored: "+filepath.ToSlash(filepath.Join(dir, "kernel.pending"))) {
+		t.Errorf("ERROR: matched path not ignored shown as ignored:\n%s", report)
+	}
+
+	if report := CheckRebootReport(assertions, nil, false, false); strings.Contains(report, "ignored:") {
+		t.Errorf("ERROR: ignored paths shown without show ignored option:\n%s", report)
+	}
+}
+
+// TestCheckRebootReportReasonsFile asserts that the packages listed in the
+// reasons file of a sentinel file are shown in the report.
+func TestCheckRebootReportReasonsFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	sentinel := filepath.Join(dir, "reboot-required")
+	pkgs := filepath.Join(dir, "reboot-required.pkgs")
+
+	for path, content := range map[string]string{
+		sentinel: "*** System restart required ***\n",
+		pkgs:     "linux-image-6.1.0-21-amd64\nlibc6\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("ERROR: failed to create test file: %v", err)
+		}
+	}
+
+	assertions := restart.RebootRequiredAsserters{
+		files.NewFile(sentinel, "", pkgs, files.FileRebootEvidence{FileExists: true}, files.FileAssertions{}),
+	}
+
+	assertions.Evaluate(context.Background())
+
+	report := CheckRebootReport(assertions, nil, false, false)
+
+	for _, pkg := range []string{"linux-image-6.1.0-21-amd64", "libc6"} {
+		want := "Reboot requested by " + pkg + " (listed in " + filepath.ToSlash(pkgs) + ")"
+		if !strings.Contains(report, want) {
+			t.Errorf("ERROR: report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+// TestCheckRebootOneLineSummaryMaintenance asserts that the state and reason
+// from an applied maintenance window decision are used in the one-line
+// summary.
+func TestCheckRebootOneLineSummaryMaintenance(t *testing.T) {
+	t.Parallel()
+
+	const keyPath = `SOFTWARE\Vendor\RebootPending`
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, keyPath)
+
+	assertions := restart.RebootRequiredAsserters{
+		registry.NewKey(
+			registry.RootKeyLocalMachine,
+			keyPath,
+			"",
+			registry.KeyRebootEvidence{KeyExists: true},
+			registry.KeyAssertions{},
+		),
+	}
+	registry.UseBackend(assertions, mb)
+
+	assertions.Evaluate(context.Background())
+
+	if got := CheckRebootOneLineSummary(assertions, false, maintenance.Decision{}, boot.ScheduledShutdown{}); !strings.HasPrefix(got, "WARNING: Reboot needed (") {
+		t.Errorf("ERROR: got summary %q; want WARNING state without maintenance note", got)
+	}
+
+	windows, err := maintenance.ParseWindows([]string{"Sat 02:00-06:00"}, time.UTC)
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse maintenance window: %v", err)
+	}
+
+	// Wednesday.
+	decision := windows.Apply(assertions, time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC))
+
+	want := "OK: Reboot needed; outside maintenance window; next window starts 2023-05-13T02:00:00Z ("
+	if got := CheckRebootOneLineSummary(assertions, false, decision, boot.ScheduledShutdown{}); !strings.HasPrefix(got, want) {
+		t.Errorf("ERROR: got summary %q; want prefix %q", got, want)
+	}
+}
+
+// TestCheckRebootOneLineSummaryScheduled asserts that a scheduled reboot is
+// noted in the one-line summary.
+func TestCheckRebootOneLineSummaryScheduled(t *testing.T) {
+	t.Parallel()
+
+	const keyPath = `SOFTWARE\Vendor\RebootPending`
+
+	mb := registry.NewMemoryBackend()
+	mb.CreateKey(registry.RootKeyLocalMachine, keyPath)
+
+	assertions := restart.RebootRequiredAsserters{
+		registry.NewKey(
+			registry.RootKeyLocalMachine,
+			keyPath,
+			"",
+			registry.KeyRebootEvidence{KeyExists: true},
+			registry.KeyAssertions{},
+		),
+	}
+	registry.UseBackend(assertions, mb)
+
+	assertions.Evaluate(context.Background())
+
+	scheduled := boot.ScheduledShutdown{
+		Time: time.Date(2023, 5, 10, 14, 0, 0, 0, time.UTC),
+		Mode: boot.ShutdownModeReboot,
+	}
+
+	want := "WARNING: Reboot needed; reboot scheduled for 2023-05-10T14:00:00Z ("
+	if got := CheckRebootOneLineSummary(assertions, false, maintenance.Decision{}, scheduled); !strings.HasPrefix(got, want) {
+		t.Errorf("ERROR: got summary %q; want prefix %q", got, want)
+	}
+}
@@ -8,7 +8,10 @@
 package restart
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/atc0005/go-nagios"
 )
@@ -17,6 +20,10 @@ import (
 // has been met and a reboot is needed.
 var ErrRebootRequired = errors.New("reboot assertions matched, reboot needed")
 
+// ErrRestartRequired indicates that sufficient confidence in restart
+// assertions has been met and a service restart is needed.
+var ErrRestartRequired = errors.New("restart assertions matched, service restart needed")
+
 // ErrMissingValue indicates that an expected value was missing.
 var ErrMissingValue = errors.New("missing expected value")
 
@@ -36,6 +43,10 @@ var ErrMissingRequiredItem = errors.New("missing required reboot asserter")
 // key, file) was not found, though it is not required to be present.
 var ErrMissingOptionalItem = errors.New("missing optional reboot asserter")
 
+// ErrEvaluationTimeout indicates that the evaluation of one or more
+// assertions did not complete before the deadline was reached.
+var ErrEvaluationTimeout = errors.New("assertion evaluation did not complete before timeout")
+
 // ServiceStater represents a type that is capable of evaluating its overall
 // state.
 type ServiceStater interface {
@@ -80,6 +91,16 @@ type MatchedPath interface {
 	String() string
 }
 
+// IgnoredMatchedPath represents a matched path which is able to indicate the
+// ignore pattern (if any) responsible for marking it as ignored.
+type IgnoredMatchedPath interface {
+	MatchedPath
+
+	// IgnoredBy returns the ignore pattern which marked the matched path as
+	// ignored and whether the matched path has been marked as ignored.
+	IgnoredBy() (IgnorePattern, bool)
+}
+
 // RebootRequiredAsserter represents an item (reg key, file) that is able to
 // determine the need for a reboot.
 type RebootRequiredAsserter interface {
@@ -127,7 +148,7 @@ type RebootRequiredAsserter interface {
 	// path for the Key as ignored *IF* a match is found. If no matched paths
 	// are recorded Filter makes no changes. Filter should be called before
 	// performing final state evaluation.
-	Filter(ignorePatterns []string)
+	Filter(ignorePatterns IgnorePatterns)
 }
 
 // RebootRequiredAsserterWithDataDisplay represents an item (reg key, file)
@@ -152,6 +173,65 @@ type RebootRequiredAsserterWithSubPaths interface {
 	HasSubPathMatches() bool
 }
 
+// EvaluationTimer represents an item (reg key, file) that is able to record
+// the amount of time taken to evaluate it.
+type EvaluationTimer interface {
+	// EvaluationDuration returns the amount of time taken by the most recent
+	// evaluation.
+	EvaluationDuration() time.Duration
+
+	// SetEvaluationDuration records the amount of time taken by the most
+	// recent evaluation.
+	SetEvaluationDuration(duration time.Duration)
+}
+
+// ContextEvaluator represents an item (e.g., command) that is able to stop
+// evaluating once a given context is done.
+type ContextEvaluator interface {
+	// EvaluateContext performs an evaluation in the same way as Evaluate,
+	// stopping early once the given context is done.
+	EvaluateContext(ctx context.Context)
+}
+
+// PendingTracker represents an item (reg key, file) that is able to record
+// how long a reboot has been needed as determined by earlier evaluations.
+type PendingTracker interface {
+	// PendingSince returns the time a reboot was first found to be needed.
+	// The zero value is returned if this has not been recorded.
+	PendingSince() time.Time
+
+	// Escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	Escalated() bool
+
+	// SetPending records the time a reboot was first found to be needed and
+	// whether the need for a reboot has been pending longer than the
+	// escalation grace period.
+	SetPending(since time.Time, escalated bool)
+}
+
+// EvidenceReporter represents an item (reg key, file) that is able to
+// report the names of the evidence markers (e.g., KeyExists) it expects and
+// those discovered by an earlier evaluation.
+type EvidenceReporter interface {
+	// ExpectedEvidenceMarkers returns the names of the evidence markers
+	// which (if found) indicate a reboot is needed.
+	ExpectedEvidenceMarkers() []string
+
+	// DiscoveredEvidenceMarkers returns the names of the evidence markers
+	// found by the most recent evaluation.
+	DiscoveredEvidenceMarkers() []string
+}
+
+// Resetter represents an item (reg key, file) that is able to discard the
+// results of an earlier evaluation so that it may be evaluated again (e.g.,
+// when evaluating on an interval).
+type Resetter interface {
+	// Reset discards the results of an earlier evaluation, including matched
+	// paths and any evidence found.
+	Reset()
+}
+
 // RebootRequiredAsserters is a collection of items that if (if all
 // requirements are matched) indicate the need for a reboot.
 type RebootRequiredAsserters []RebootRequiredAsserter
@@ -177,12 +257,176 @@ func (rras RebootRequiredAsserters) Validate() error {
 	return nil
 }
 
-// Evaluate performs an evaluation of each assertion in the collection to
-// determine whether a reboot is needed.
-func (rras RebootRequiredAsserters) Evaluate() {
+// Evaluate performs an evaluation of each assertion in the collection (in
+// order) to determine whether a reboot is needed. The evaluated (completed)
+// assertions are returned along with any assertions which did not complete
+// (or were not started) before the given context was done.
+//
+// An assertion which did not complete may still be evaluating; the caller is
+// responsible for not using the evaluation results of those assertions.
+func (rras RebootRequiredAsserters) Evaluate(ctx context.Context) (completed RebootRequiredAsserters, unfinished RebootRequiredAsserters) {
+	return rras.EvaluateConcurrently(ctx, 1)
+}
+
+// EvaluateConcurrently performs an evaluation of each assertion in the
+// collection using up to the specified number of workers to determine
+// whether a reboot is needed. Each assertion is evaluated by a single worker;
+// assertions are expected to not share runtime state with other assertions.
+//
+// The evaluated (completed) assertions are returned along with any
+// assertions which did not complete (or were not started) before the given
+// context was done. Both collections retain the order of the original
+// collection regardless of the order in which evaluations complete.
+//
+// Assertions implementing ContextEvaluator (e.g., commands) are evaluated
+// using the given context and stop evaluating (and are returned as not
+// completed) once it is done. Other assertions cannot be interrupted; an
+// assertion which blocks (e.g., on an unresponsive network filesystem)
+// continues evaluating in the background, holding its worker goroutine,
+// after this method returns.
+//
+// An assertion which did not complete may still be evaluating; the caller is
+// responsible for not using the evaluation results of those assertions.
+func (rras RebootRequiredAsserters) EvaluateConcurrently(ctx context.Context, workers int) (completed RebootRequiredAsserters, unfinished RebootRequiredAsserters) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	logger.Printf("Evaluating %d assertions using %d workers", len(rras), min(workers, len(rras)))
+
+	var (
+		mu       sync.Mutex
+		finished = make([]bool, len(rras))
+		queue    = make(chan int)
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < min(workers, len(rras)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				start := time.Now()
+
+				if evaluator, ok := rras[i].(ContextEvaluator); ok {
+					evaluator.EvaluateContext(ctx)
+
+					// An evaluation stopped early is incomplete.
+					if ctx.Err() != nil {
+						continue
+					}
+				} else {
+					rras[i].Evaluate()
+				}
+
+				if timer, ok := rras[i].(EvaluationTimer); ok {
+					timer.SetEvaluationDuration(time.Since(start))
+				}
+
+				mu.Lock()
+				finished[i] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Assertions are queued in order; queueing stops once the context is
+	// done, leaving the remaining assertions unevaluated.
+	go func() {
+		defer close(queue)
+
+		for i := range rras {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	allFinished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allFinished)
+	}()
+
+	select {
+	case <-allFinished:
+	case <-ctx.Done():
+		logger.Printf("Evaluation did not complete: %v", ctx.Err())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	completed = make(RebootRequiredAsserters, 0, len(rras))
 	for i := range rras {
-		rras[i].Evaluate()
+		switch {
+		case finished[i]:
+			completed = append(completed, rras[i])
+		default:
+			logger.Printf("Evaluation of %q did not complete", rras[i])
+			unfinished = append(unfinished, rras[i])
+		}
 	}
+
+	return completed, unfinished
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation of the given assertion. Zero is returned if the assertion does
+// not record evaluation durations.
+func EvaluationDuration(rra RebootRequiredAsserter) time.Duration {
+	if timer, ok := rra.(EvaluationTimer); ok {
+		return timer.EvaluationDuration()
+	}
+
+	return 0
+}
+
+// TotalEvaluationDuration returns the combined amount of time taken to
+// evaluate each assertion in the collection. When assertions are evaluated
+// concurrently this may exceed the elapsed time.
+func (rras RebootRequiredAsserters) TotalEvaluationDuration() time.Duration {
+	var total time.Duration
+	for _, rra := range rras {
+		total += EvaluationDuration(rra)
+	}
+
+	return total
+}
+
+// SlowestEvaluation returns the assertion in the collection which took the
+// longest to evaluate along with the amount of time taken. A nil assertion
+// is returned if the collection is empty.
+func (rras RebootRequiredAsserters) SlowestEvaluation() (RebootRequiredAsserter, time.Duration) {
+	var (
+		slowest  RebootRequiredAsserter
+		duration time.Duration
+	)
+
+	for _, rra := range rras {
+		if d := EvaluationDuration(rra); slowest == nil || d > duration {
+			slowest = rra
+			duration = d
+		}
+	}
+
+	return slowest, duration
+}
+
+// SlowEvaluations returns the assertions in the collection which took longer
+// than the given threshold to evaluate.
+func (rras RebootRequiredAsserters) SlowEvaluations(threshold time.Duration) RebootRequiredAsserters {
+	slow := make(RebootRequiredAsserters, 0, len(rras))
+	for _, rra := range rras {
+		if EvaluationDuration(rra) > threshold {
+			slow = append(slow, rra)
+		}
+	}
+
+	return slow
 }
 
 // HasErrors indicates whether any of the assertion evaluations resulted in an
@@ -354,24 +598,43 @@ func (rras RebootRequiredAsserters) ServiceState() nagios.ServiceState {
 }
 
 // HasCriticalState indicates whether any items in the collection were
-// evaluated to a CRITICAL state. The caller is responsible for filtering the
-// collection prior to calling this method.
+// evaluated to a CRITICAL state. Items in a WARNING state which have been
+// escalated are considered to be in a CRITICAL state. The caller is
+// responsible for filtering the collection prior to calling this method.
 func (rras RebootRequiredAsserters) HasCriticalState() bool {
 	for _, assertion := range rras {
 		if assertion.IsCriticalState() {
 			return true
 		}
+
+		if assertion.IsWarningState() && IsEscalated(assertion) {
+			return true
+		}
 	}
 
 	return false
 }
 
 // HasWarningState indicates whether any items in the collection were
-// evaluated to a WARNING state. The caller is responsible for filtering the
-// collection prior to calling this method.
+// evaluated to a WARNING state. Items which have been escalated to a
+// CRITICAL state are not considered. The caller is responsible for filtering
+// the collection prior to calling this method.
 func (rras RebootRequiredAsserters) HasWarningState() bool {
 	for _, assertion := range rras {
-		if assertion.IsWarningState() {
+		if assertion.IsWarningState() && !IsEscalated(assertion) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasEscalated indicates whether any items in the collection in a WARNING
+// state were escalated to a CRITICAL state. The caller is responsible for
+// filtering the collection prior to calling this method.
+func (rras RebootRequiredAsserters) HasEscalated() bool {
+	for _, assertion := range rras {
+		if assertion.IsWarningState() && IsEscalated(assertion) {
 			return true
 		}
 	}
@@ -423,12 +686,26 @@ func (rras RebootRequiredAsserters) HasIgnored() bool {
 // Filter uses the list of specified ignore patterns to mark any applicable
 // items in the collection as ignored. Filter should be called before
 // performing final state evaluation.
-func (rras RebootRequiredAsserters) Filter(ignorePatterns []string) {
+func (rras RebootRequiredAsserters) Filter(ignorePatterns IgnorePatterns) {
 	for i := range rras {
 		rras[i].Filter(ignorePatterns)
 	}
 }
 
+// Reset discards the results of an earlier evaluation of each item in the
+// collection so that the collection may be evaluated again. Items which do
+// not support being reset are left as-is.
+//
+// The caller is responsible for not resetting items which may still be
+// evaluating (see EvaluateConcurrently).
+func (rras RebootRequiredAsserters) Reset() {
+	for i := range rras {
+		if resetter, ok := rras[i].(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+}
+
 // NumIgnored indicates how many of the items in the collection have been
 // marked as ignored. The caller is responsible for filtering the collection
 // prior to calling this method.
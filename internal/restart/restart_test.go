@@ -0,0 +1,178 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stubAsserter is a RebootRequiredAsserter whose evaluation takes the
+// specified amount of time. Methods other than Evaluate, String and those of
+// EvaluationTimer are not implemented.
+type stubAsserter struct {
+	RebootRequiredAsserter
+
+	name      string
+	delay     time.Duration
+	duration  time.Duration
+	evaluated chan struct{}
+}
+
+func (sa *stubAsserter) EvaluationDuration() time.Duration {
+	return sa.duration
+}
+
+func (sa *stubAsserter) SetEvaluationDuration(duration time.Duration) {
+	sa.duration = duration
+}
+
+func (sa *stubAsserter) Evaluate() {
+	time.Sleep(sa.delay)
+	close(sa.evaluated)
+}
+
+func (sa *stubAsserter) String() string {
+	return sa.name
+}
+
+// contextStubAsserter is a stubAsserter whose evaluation blocks until the
+// given context is done.
+type contextStubAsserter struct {
+	*stubAsserter
+}
+
+func (csa contextStubAsserter) EvaluateContext(ctx context.Context) {
+	<-ctx.Done()
+	close(csa.evaluated)
+}
+
+// TestEvaluateTimeout asserts that assertions which do not complete before
+// the context is done are returned separately from those which completed.
+func TestEvaluateTimeout(t *testing.T) {
+	t.Parallel()
+
+	newStub := func(name string, delay time.Duration) *stubAsserter {
+		return &stubAsserter{name: name, delay: delay, evaluated: make(chan struct{})}
+	}
+
+	fast := newStub("fast", 0)
+	hung := newStub("hung", time.Minute)
+	pending := newStub("pending", 0)
+
+	assertions := RebootRequiredAsserters{fast, hung, pending}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	completed, unfinished := assertions.Evaluate(ctx)
+
+	if len(completed) != 1 || completed[0] != fast {
+		t.Errorf("ERROR: got completed assertions %v; want only %q", completed, fast)
+	}
+
+	if len(unfinished) != 2 || unfinished[0] != hung || unfinished[1] != pending {
+		t.Errorf("ERROR: got unfinished assertions %v; want %q and %q", unfinished, hung, pending)
+	}
+
+	select {
+	case <-pending.evaluated:
+		t.Errorf("ERROR: assertion %q evaluated after timeout", pending)
+	default:
+	}
+
+	// Assertions able to stop evaluating are stopped once the context is
+	// done instead of continuing in the background.
+	blocked := contextStubAsserter{newStub("blocked", 0)}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, unfinished := (RebootRequiredAsserters{blocked}).Evaluate(ctx); len(unfinished) != 1 {
+		t.Errorf("ERROR: got %d unfinished assertions; want 1", len(unfinished))
+	}
+
+	select {
+	case <-blocked.evaluated:
+	case <-time.After(5 * time.Second):
+		t.Errorf("ERROR: assertion %q not stopped once context was done", blocked)
+	}
+
+	completed, unfinished = RebootRequiredAsserters{newStub("a", 0), newStub("b", 0)}.Evaluate(context.Background())
+	if len(completed) != 2 || len(unfinished) != 0 {
+		t.Errorf("ERROR: got %d completed and %d unfinished assertions; want 2 and 0", len(completed), len(unfinished))
+	}
+}
+
+// TestEvaluateConcurrently asserts that assertions are evaluated
+// concurrently and that the order of the collection is retained.
+func TestEvaluateConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const delay = 200 * time.Millisecond
+
+	assertions := make(RebootRequiredAsserters, 0, 4)
+	for i := 0; i < 4; i++ {
+		assertions = append(assertions, &stubAsserter{
+			name:      fmt.Sprintf("assertion-%d", i),
+			delay:     delay - time.Duration(i)*10*time.Millisecond,
+			evaluated: make(chan struct{}),
+		})
+	}
+
+	start := time.Now()
+	completed, unfinished := assertions.EvaluateConcurrently(context.Background(), 4)
+
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("ERROR: evaluation took %s; expected concurrent evaluation within %s", elapsed, 2*delay)
+	}
+
+	if len(unfinished) != 0 {
+		t.Fatalf("ERROR: got %d unfinished assertions; want 0", len(unfinished))
+	}
+
+	for i := range assertions {
+		if completed[i] != assertions[i] {
+			t.Errorf("ERROR: got %q at position %d; want %q", completed[i], i, assertions[i])
+		}
+	}
+}
+
+// TestEvaluationDuration asserts that the amount of time taken to evaluate
+// each assertion is recorded and summarized.
+func TestEvaluationDuration(t *testing.T) {
+	t.Parallel()
+
+	fast := &stubAsserter{name: "fast", evaluated: make(chan struct{})}
+	slow := &stubAsserter{name: "slow", delay: 100 * time.Millisecond, evaluated: make(chan struct{})}
+
+	assertions := RebootRequiredAsserters{fast, slow}
+	assertions.EvaluateConcurrently(context.Background(), 2)
+
+	if got := slow.EvaluationDuration(); got < slow.delay {
+		t.Errorf("ERROR: got duration %s for %q; want at least %s", got, slow, slow.delay)
+	}
+
+	if got, want := assertions.TotalEvaluationDuration(), fast.duration+slow.duration; got != want {
+		t.Errorf("ERROR: got total duration %s; want %s", got, want)
+	}
+
+	if got, duration := assertions.SlowestEvaluation(); got != slow || duration != slow.duration {
+		t.Errorf("ERROR: got slowest assertion %v (%s); want %q (%s)", got, duration, slow, slow.duration)
+	}
+
+	if got := assertions.SlowEvaluations(50 * time.Millisecond); len(got) != 1 || got[0] != slow {
+		t.Errorf("ERROR: got slow assertions %v; want only %q", got, slow)
+	}
+
+	if got, duration := (RebootRequiredAsserters{}).SlowestEvaluation(); got != nil || duration != 0 {
+		t.Errorf("ERROR: got slowest assertion %v (%s) for empty collection; want none", got, duration)
+	}
+}
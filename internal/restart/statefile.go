@@ -0,0 +1,254 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StateFileVersion is the state file format version supported by this
+// package.
+const StateFileVersion int = 1
+
+// stateFilePerms is the permissions of a written state file. The state file
+// is only expected to be read by the plugin.
+const stateFilePerms os.FileMode = 0o600
+
+// ErrInvalidStateFile indicates that the content of a state file could not
+// be used.
+var ErrInvalidStateFile = errors.New("invalid state file")
+
+// stateFile is the top-level structure of a state file.
+type stateFile struct {
+	Version int `json:"version"`
+
+	// Pending records when each assertion (by identity) was first found to
+	// indicate that a reboot is needed.
+	Pending map[string]time.Time `json:"pending"`
+}
+
+// PendingState records when each assertion (by identity) was first found to
+// indicate that a reboot is needed. See AssertionIdentities for how
+// assertions are identified.
+type PendingState map[string]time.Time
+
+// LoadStateFile loads the pending state from the given state file. An empty
+// pending state is returned if the state file does not exist.
+func LoadStateFile(filename string) (PendingState, error) {
+	fh, err := os.Open(filepath.Clean(filename))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logger.Printf("State file %s not found; no pending state recorded", filename)
+
+		return PendingState{}, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("failed to open state file: %w", err)
+	}
+
+	defer func() {
+		if err := fh.Close(); err != nil {
+			logger.Printf("error closing file %q: %v", filename, err)
+		}
+	}()
+
+	return ParseStateFile(filename, fh)
+}
+
+// ParseStateFile loads the pending state from the content of a state file.
+// The given name is used to identify the source in error messages.
+//
+// A state file is a JSON document recording when each assertion was first
+// found to indicate that a reboot is needed:
+//
+//	{
+//	  "version": 1,
+//	  "pending": {
+//	    "files.File:/var/run/reboot-required": "2023-05-01T04:12:09Z"
+//	  }
+//	}
+func ParseStateFile(name string, r io.Reader) (PendingState, error) {
+	var content stateFile
+	if err := json.NewDecoder(r).Decode(&content); err != nil {
+		return nil, fmt.Errorf("%s: %v: %w", name, err, ErrInvalidStateFile)
+	}
+
+	if content.Version != StateFileVersion {
+		return nil, fmt.Errorf(
+			"%s: unsupported version %d; expected %d: %w",
+			name,
+			content.Version,
+			StateFileVersion,
+			ErrInvalidStateFile,
+		)
+	}
+
+	pendingState := make(PendingState, len(content.Pending))
+	for identity, since := range content.Pending {
+		pendingState[identity] = since
+	}
+
+	logger.Printf("%d pending entries loaded from %s", len(pendingState), name)
+
+	return pendingState, nil
+}
+
+// WriteStateFile records the pending state to the given state file. The
+// state file is replaced atomically so that an interrupted write does not
+// leave a partial state file behind.
+func (ps PendingState) WriteStateFile(filename string) error {
+	content := stateFile{
+		Version: StateFileVersion,
+		Pending: ps,
+	}
+
+	err := WriteFileAtomically(filename, stateFilePerms, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(content)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	logger.Printf("%d pending entries written to %s", len(ps), filename)
+
+	return nil
+}
+
+// Track records when each of the given evaluated assertions was first found
+// to indicate that a reboot is needed. Entries for assertions which no
+// longer indicate that a reboot is needed (including those marked as
+// ignored) are cleared as are entries for assertions no longer listed in
+// the given identities.
+//
+// Assertions which could not be evaluated (e.g., due to errors or timeouts)
+// retain their existing entry. If specified, assertions which have indicated
+// that a reboot is needed for at least the escalateAfter grace period are
+// marked as escalated.
+func (ps PendingState) Track(
+	identities map[RebootRequiredAsserter]string,
+	evaluated RebootRequiredAsserters,
+	escalateAfter time.Duration,
+	now time.Time,
+) {
+	known := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		known[identity] = true
+	}
+
+	for identity := range ps {
+		if !known[identity] {
+			logger.Printf("Clearing pending entry for unknown assertion %q", identity)
+			delete(ps, identity)
+		}
+	}
+
+	for _, assertion := range evaluated {
+		identity, ok := identities[assertion]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case assertion.RebootRequired():
+			since, ok := ps[identity]
+			if !ok {
+				logger.Printf("Recording pending entry for %q", identity)
+				since = now
+				ps[identity] = since
+			}
+
+			escalated := escalateAfter > 0 && now.Sub(since) >= escalateAfter
+
+			if v, ok := assertion.(PendingTracker); ok {
+				v.SetPending(since, escalated)
+			}
+
+		case assertion.Err() != nil:
+			logger.Printf("Retaining pending entry (if any) for %q due to evaluation error", identity)
+
+		default:
+			if _, ok := ps[identity]; ok {
+				logger.Printf("Clearing pending entry for %q", identity)
+				delete(ps, identity)
+			}
+		}
+	}
+}
+
+// AssertionIdentities returns a stable identity for each assertion in the
+// collection suitable for recording pending state between evaluations. The
+// identity is formed from the type and fully qualified path of the
+// assertion. If multiple assertions share the same type and path (e.g.,
+// registry keys evaluated for different values) an ordinal suffix is added
+// in collection order.
+func AssertionIdentities(rras RebootRequiredAsserters) map[RebootRequiredAsserter]string {
+	identities := make(map[RebootRequiredAsserter]string, len(rras))
+	seen := make(map[string]int, len(rras))
+
+	for _, assertion := range rras {
+		identity := strings.TrimPrefix(fmt.Sprintf("%T", assertion), "*") + ":" + assertion.String()
+
+		seen[identity]++
+		if n := seen[identity]; n > 1 {
+			identity = fmt.Sprintf("%s#%d", identity, n)
+		}
+
+		identities[assertion] = identity
+	}
+
+	return identities
+}
+
+// PendingSince returns the time the given assertion was first found to
+// indicate that a reboot is needed. The zero value is returned if this has
+// not been recorded or the assertion does not record pending state.
+func PendingSince(rra RebootRequiredAsserter) time.Time {
+	if v, ok := rra.(PendingTracker); ok {
+		return v.PendingSince()
+	}
+
+	return time.Time{}
+}
+
+// IsEscalated indicates whether the need for a reboot indicated by the given
+// assertion has been pending longer than the escalation grace period.
+func IsEscalated(rra RebootRequiredAsserter) bool {
+	if v, ok := rra.(PendingTracker); ok {
+		return v.Escalated()
+	}
+
+	return false
+}
+
+// OldestPending returns the earliest time any assertion in the collection
+// which indicates a reboot is needed was first found to do so. The zero
+// value is returned if this has not been recorded for any assertion.
+func (rras RebootRequiredAsserters) OldestPending() time.Time {
+	var oldest time.Time
+	for _, rra := range rras {
+		if !rra.RebootRequired() {
+			continue
+		}
+
+		if since := PendingSince(rra); !since.IsZero() && (oldest.IsZero() || since.Before(oldest)) {
+			oldest = since
+		}
+	}
+
+	return oldest
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package restart
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pendingStub is a RebootRequiredAsserter in a WARNING state if a reboot is
+// required which records pending state. Methods other than those used to
+// track pending state and determine the service state are not implemented.
+type pendingStub struct {
+	RebootRequiredAsserter
+
+	name           string
+	rebootRequired bool
+	err            error
+	since          time.Time
+	escalated      bool
+}
+
+func (ps *pendingStub) String() string          { return ps.name }
+func (ps *pendingStub) Err() error              { return ps.err }
+func (ps *pendingStub) RebootRequired() bool    { return ps.rebootRequired }
+func (ps *pendingStub) IsWarningState() bool    { return ps.rebootRequired }
+func (ps *pendingStub) IsCriticalState() bool   { return ps.err != nil }
+func (ps *pendingStub) PendingSince() time.Time { return ps.since }
+func (ps *pendingStub) Escalated() bool         { return ps.escalated }
+func (ps *pendingStub) SetPending(since time.Time, escalated bool) {
+	ps.since = since
+	ps.escalated = escalated
+}
+
+// TestPendingStateTrack asserts that the time each assertion first
+// indicated a reboot is needed is recorded, retained and cleared as expected
+// and that assertions pending longer than the grace period are escalated.
+func TestPendingStateTrack(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	escalateAfter := 72 * time.Hour
+
+	stale := &pendingStub{name: "stale", rebootRequired: true}
+	fresh := &pendingStub{name: "fresh", rebootRequired: true}
+	cleared := &pendingStub{name: "cleared"}
+	failed := &pendingStub{name: "failed", err: errors.New("access denied")}
+
+	assertions := RebootRequiredAsserters{stale, fresh, cleared, failed}
+	identities := AssertionIdentities(assertions)
+
+	pendingState := PendingState{
+		identities[stale]:   now.Add(-5 * 24 * time.Hour),
+		identities[cleared]: now.Add(-time.Hour),
+		identities[failed]:  now.Add(-time.Hour),
+		"removed:assertion": now.Add(-time.Hour),
+	}
+
+	pendingState.Track(identities, assertions, escalateAfter, now)
+
+	if !stale.PendingSince().Equal(now.Add(-5*24*time.Hour)) || !stale.Escalated() {
+		t.Errorf("ERROR: got pending since %s (escalated %t) for %q; want existing entry escalated", stale.since, stale.escalated, stale)
+	}
+
+	if !fresh.PendingSince().Equal(now) || fresh.Escalated() {
+		t.Errorf("ERROR: got pending since %s (escalated %t) for %q; want new entry", fresh.since, fresh.escalated, fresh)
+	}
+
+	if _, ok := pendingState[identities[cleared]]; ok {
+		t.Errorf("ERROR: entry for %q not cleared", cleared)
+	}
+
+	if _, ok := pendingState[identities[failed]]; !ok {
+		t.Errorf("ERROR: entry for %q not retained", failed)
+	}
+
+	if _, ok := pendingState["removed:assertion"]; ok {
+		t.Error("ERROR: entry for removed assertion not cleared")
+	}
+
+	if got := assertions.OldestPending(); !got.Equal(stale.since) {
+		t.Errorf("ERROR: got oldest pending %s; want %s", got, stale.since)
+	}
+
+	if !assertions.HasEscalated() || !assertions.HasCriticalState() {
+		t.Error("ERROR: escalated WARNING state not considered CRITICAL")
+	}
+
+	if (RebootRequiredAsserters{fresh}).HasCriticalState() || !(RebootRequiredAsserters{fresh}).HasWarningState() {
+		t.Error("ERROR: WARNING state escalated before grace period passed")
+	}
+}
+
+// TestStateFileRoundTrip asserts that the pending state is written to and
+// loaded from a state file and that invalid state files are rejected.
+func TestStateFileRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "check_reboot.state")
+
+	empty, err := LoadStateFile(filename)
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("ERROR: got %v (%v) for missing state file; want empty pending state", empty, err)
+	}
+
+	since := time.Date(2023, 5, 1, 4, 12, 9, 0, time.UTC)
+	want := PendingState{"files.File:/var/run/reboot-required": since}
+
+	if err := want.WriteStateFile(filename); err != nil {
+		t.Fatalf("ERROR: failed to write state file: %v", err)
+	}
+
+	got, err := LoadStateFile(filename)
+	if err != nil {
+		t.Fatalf("ERROR: failed to load state file: %v", err)
+	}
+
+	// Windows only supports the read-only permission bit.
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatalf("ERROR: failed to stat state file: %v", err)
+		}
+
+		if got := info.Mode().Perm(); got != stateFilePerms {
+			t.Errorf("ERROR: got state file permissions %v; want %v", got, stateFilePerms)
+		}
+	}
+
+	if len(got) != 1 || !got["files.File:/var/run/reboot-required"].Equal(since) {
+		t.Errorf("ERROR: got pending state %v; want %v", got, want)
+	}
+
+	for _, invalid := range []string{`{"version": 2, "pending": {}}`, `not json`} {
+		if _, err := ParseStateFile("state", strings.NewReader(invalid)); !errors.Is(err, ErrInvalidStateFile) {
+			t.Errorf("ERROR: expected ErrInvalidStateFile for %q; got %v", invalid, err)
+		}
+	}
+}
+
+// TestAssertionIdentities asserts that assertions sharing the same type and
+// path are given distinct identities.
+func TestAssertionIdentities(t *testing.T) {
+	t.Parallel()
+
+	first := &pendingStub{name: `HKEY_LOCAL_MACHINE\SOFTWARE\Example`}
+	second := &pendingStub{name: `HKEY_LOCAL_MACHINE\SOFTWARE\Example`}
+
+	identities := AssertionIdentities(RebootRequiredAsserters{first, second})
+
+	if got, want := identities[first], `restart.pendingStub:HKEY_LOCAL_MACHINE\SOFTWARE\Example`; got != want {
+		t.Errorf("ERROR: got identity %q; want %q", got, want)
+	}
+
+	if got, want := identities[second], identities[first]+"#2"; got != want {
+		t.Errorf("ERROR: got identity %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package uptime provides types and functionality used to determine whether
+// the time elapsed since the system was last booted indicates the need for a
+// system reboot.
+package uptime
@@ -0,0 +1,541 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package uptime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserter implementation isn't correct.
+var _ restart.RebootRequiredAsserter = (*Uptime)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.RebootRequiredAsserterWithDataDisplay implementation isn't correct.
+var _ restart.RebootRequiredAsserterWithDataDisplay = (*Uptime)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvaluationTimer implementation isn't correct.
+var _ restart.EvaluationTimer = (*Uptime)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.PendingTracker implementation isn't correct.
+var _ restart.PendingTracker = (*Uptime)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.EvidenceReporter implementation isn't correct.
+var _ restart.EvidenceReporter = (*Uptime)(nil)
+
+// Add an "implements assertion" to fail the build if the
+// restart.Resetter implementation isn't correct.
+var _ restart.Resetter = (*Uptime)(nil)
+
+// Add "implements assertions" to fail the build if the restart.MatchedPath
+// implementation isn't correct.
+var _ restart.MatchedPath = (*MatchedPath)(nil)
+
+// assertionName is the name used to refer to an uptime assertion and its
+// matched path.
+const assertionName string = "uptime"
+
+// UptimeRebootEvidence indicates what uptime evidence is required in order
+// to determine that a reboot is needed.
+//
+//nolint:revive
+type UptimeRebootEvidence struct {
+	// WarningThresholdExceeded is an evidence "marker" that if satisfied
+	// indicates the need for a reboot. This marker is satisfied if the
+	// uptime exceeds the WARNING threshold, but not the CRITICAL threshold.
+	WarningThresholdExceeded bool
+
+	// CriticalThresholdExceeded is an evidence "marker" that if satisfied
+	// indicates the need for a reboot. This marker is satisfied if the
+	// uptime exceeds the CRITICAL threshold.
+	CriticalThresholdExceeded bool
+}
+
+// UptimeRuntime is a collection of values for an Uptime that are set during
+// evaluation. Unlike the static values set for an Uptime (e.g., thresholds),
+// these values are not known until execution or runtime.
+//
+//nolint:revive
+type UptimeRuntime struct {
+	// err records any error that occurs while performing an evaluation.
+	err error
+
+	// evidenceFound is the collection of evidence found when evaluating a
+	// specified assertion.
+	evidenceFound UptimeRebootEvidence
+
+	// uptime is the amount of time elapsed since the system was last booted.
+	uptime time.Duration
+
+	// pathsMatched is a collection of path values that were matched during
+	// evaluation of specified reboot required assertions.
+	pathsMatched MatchedPathIndex
+
+	// duration is the amount of time taken by the most recent evaluation.
+	duration time.Duration
+
+	// pendingSince is the time a reboot was first found to be needed as
+	// recorded by an earlier evaluation.
+	pendingSince time.Time
+
+	// escalated indicates whether the need for a reboot has been pending
+	// longer than the escalation grace period.
+	escalated bool
+}
+
+// MatchedPathIndex is a collection of path values that were matched during
+// evaluation of specified reboot required assertions.
+type MatchedPathIndex map[string]MatchedPath
+
+// MatchedPath represents the uptime of the system when matched while
+// performing an evaluation of a "reboot required" assertion. The uptime has
+// no path; the name of the assertion is used instead.
+type MatchedPath struct {
+	// name is the name of the assertion.
+	name string
+
+	// ignored indicates whether this value has been marked by filtering logic
+	// as not considered when determining whether a reboot is needed.
+	ignored bool
+
+	// ignoredBy is the ignore pattern responsible for marking this value as
+	// ignored.
+	ignoredBy restart.IgnorePattern
+}
+
+// Uptime represents a comparison between the time elapsed since the system
+// was last booted and the specified WARNING and CRITICAL thresholds. If a
+// threshold is exceeded a reboot is needed.
+type Uptime struct {
+	// warning is the amount of uptime after which a reboot is needed and a
+	// WARNING state is indicated. Zero disables this threshold.
+	warning time.Duration
+
+	// critical is the amount of uptime after which a reboot is needed and a
+	// CRITICAL state is indicated. Zero disables this threshold.
+	critical time.Duration
+
+	// uptime if set, is used instead of boot.Uptime to determine the amount
+	// of time elapsed since the system was last booted.
+	uptime boot.UptimeProvider
+
+	// runtime is a collection of values that are set during evaluation.
+	// Unlike static values that are known ahead of time, these values are not
+	// known until execution or runtime.
+	runtime UptimeRuntime
+
+	// evidenceExpected indicates what evidence is used to determine that a
+	// reboot is needed.
+	evidenceExpected UptimeRebootEvidence
+}
+
+// NewUptime creates an Uptime assertion using the given WARNING and
+// CRITICAL thresholds. A threshold of zero is not applied.
+func NewUptime(warning time.Duration, critical time.Duration) *Uptime {
+	return &Uptime{
+		warning:  warning,
+		critical: critical,
+		evidenceExpected: UptimeRebootEvidence{
+			WarningThresholdExceeded:  warning > 0,
+			CriticalThresholdExceeded: critical > 0,
+		},
+	}
+}
+
+// SetUptimeProvider sets the function used to determine the amount of time
+// elapsed since the system was last booted instead of boot.Uptime.
+func (u *Uptime) SetUptimeProvider(uptime boot.UptimeProvider) {
+	u.uptime = uptime
+}
+
+// Warning returns the amount of uptime after which a WARNING state is
+// indicated. Zero indicates that the threshold is not applied.
+func (u *Uptime) Warning() time.Duration {
+	return u.warning
+}
+
+// Critical returns the amount of uptime after which a CRITICAL state is
+// indicated. Zero indicates that the threshold is not applied.
+func (u *Uptime) Critical() time.Duration {
+	return u.critical
+}
+
+// Uptime returns the amount of time elapsed since the system was last booted
+// recorded during an earlier evaluation.
+func (u *Uptime) Uptime() time.Duration {
+	return u.runtime.uptime
+}
+
+// Err exposes the underlying error (if any) as-is.
+func (u *Uptime) Err() error {
+	return u.runtime.err
+}
+
+// EvaluationDuration returns the amount of time taken by the most recent
+// evaluation.
+func (u *Uptime) EvaluationDuration() time.Duration {
+	return u.runtime.duration
+}
+
+// SetEvaluationDuration records the amount of time taken by the most recent
+// evaluation.
+func (u *Uptime) SetEvaluationDuration(duration time.Duration) {
+	u.runtime.duration = duration
+}
+
+// PendingSince returns the time a reboot was first found to be needed. The
+// zero value is returned if this has not been recorded.
+func (u *Uptime) PendingSince() time.Time {
+	return u.runtime.pendingSince
+}
+
+// Escalated indicates whether the need for a reboot has been pending longer
+// than the escalation grace period.
+func (u *Uptime) Escalated() bool {
+	return u.runtime.escalated
+}
+
+// SetPending records the time a reboot was first found to be needed and
+// whether the need for a reboot has been pending longer than the escalation
+// grace period.
+func (u *Uptime) SetPending(since time.Time, escalated bool) {
+	u.runtime.pendingSince = since
+	u.runtime.escalated = escalated
+}
+
+// Validate performs basic validation. An error is returned for any validation
+// failures.
+func (u *Uptime) Validate() error {
+	if u.warning < 0 || u.critical < 0 {
+		return fmt.Errorf(
+			"invalid uptime thresholds (warning: %s, critical: %s): %w",
+			u.warning,
+			u.critical,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	if !u.evidenceExpected.WarningThresholdExceeded &&
+		!u.evidenceExpected.CriticalThresholdExceeded {
+		return fmt.Errorf(
+			"uptime threshold not specified: %w",
+			restart.ErrUnknownRebootEvidence,
+		)
+	}
+
+	if u.warning > 0 && u.critical > 0 && u.warning > u.critical {
+		return fmt.Errorf(
+			"uptime warning threshold %s exceeds critical threshold %s: %w",
+			u.warning,
+			u.critical,
+			restart.ErrInvalidRebootEvidence,
+		)
+	}
+
+	return nil
+}
+
+// String provides the name of the assertion.
+func (u *Uptime) String() string {
+	return assertionName
+}
+
+// DataDisplay provides a string representation of the uptime and thresholds
+// for display purposes.
+func (u *Uptime) DataDisplay() string {
+	display := "Uptime: " + FormatDuration(u.Uptime())
+
+	if u.warning > 0 {
+		display += ", warning: " + FormatDuration(u.warning)
+	}
+
+	if u.critical > 0 {
+		display += ", critical: " + FormatDuration(u.critical)
+	}
+
+	return display
+}
+
+// Evaluate applies the specified assertion to determine if a reboot is
+// necessary.
+func (u *Uptime) Evaluate() {
+	provider := u.uptime
+	if provider == nil {
+		provider = boot.Uptime
+	}
+
+	uptime, err := provider()
+	if err != nil {
+		u.runtime.err = fmt.Errorf("failed to determine uptime: %w", err)
+
+		return
+	}
+
+	u.runtime.uptime = uptime
+
+	logger.Printf(
+		"Evaluating uptime %s using thresholds (warning: %s, critical: %s)",
+		uptime,
+		u.warning,
+		u.critical,
+	)
+
+	switch {
+	case u.evidenceExpected.CriticalThresholdExceeded && uptime > u.critical:
+		logger.Println("Reboot Required!")
+		u.SetFoundEvidenceCriticalThresholdExceeded()
+		u.AddMatchedPath(assertionName)
+
+	case u.evidenceExpected.WarningThresholdExceeded && uptime > u.warning:
+		logger.Println("Reboot Required!")
+		u.SetFoundEvidenceWarningThresholdExceeded()
+		u.AddMatchedPath(assertionName)
+
+	default:
+		logger.Printf("Uptime %s within thresholds, reboot not required due to uptime.", uptime)
+	}
+}
+
+// Reset discards the results of an earlier evaluation so that the Uptime may
+// be evaluated again.
+func (u *Uptime) Reset() {
+	u.runtime = UptimeRuntime{}
+}
+
+// AddMatchedPath records given paths as successful assertion matches.
+// Duplicate entries are ignored.
+func (u *Uptime) AddMatchedPath(paths ...string) {
+	if u.runtime.pathsMatched == nil {
+		u.runtime.pathsMatched = make(MatchedPathIndex)
+	}
+
+	for _, path := range paths {
+		if _, ok := u.runtime.pathsMatched[path]; !ok {
+			u.runtime.pathsMatched[path] = MatchedPath{name: path}
+		}
+	}
+}
+
+// MatchedPaths returns all recorded paths from successful assertion matches.
+func (u *Uptime) MatchedPaths() restart.MatchedPaths {
+	matchedPaths := make(restart.MatchedPaths, 0, len(u.runtime.pathsMatched))
+	for _, matchedPath := range u.runtime.pathsMatched {
+		matchedPaths = append(matchedPaths, matchedPath)
+	}
+
+	return matchedPaths
+}
+
+// Filter uses the list of specified ignore patterns to mark each matched path
+// for the Uptime as ignored *IF* a match is found.
+//
+// If no matched paths are recorded Filter makes no changes. Filter should be
+// called before performing final state evaluation.
+func (u *Uptime) Filter(ignorePatterns restart.IgnorePatterns) {
+	if len(ignorePatterns) == 0 {
+		logger.Printf("0 ignore patterns specified for %q; skipping Filter", u)
+		return
+	}
+
+	for originalPathString, matchedPath := range u.runtime.pathsMatched {
+		if ignorePattern, ok := ignorePatterns.Match(restart.IgnoreTargetUptime, originalPathString, u.String()); ok {
+			logger.Printf("marking matched path %q as ignored", originalPathString)
+			matchedPath.ignored = true
+			matchedPath.ignoredBy = ignorePattern
+			u.runtime.pathsMatched[originalPathString] = matchedPath
+		}
+	}
+}
+
+// ExpectedEvidence returns the specified evidence that (if found) indicates a
+// reboot is needed.
+func (u *Uptime) ExpectedEvidence() UptimeRebootEvidence {
+	return u.evidenceExpected
+}
+
+// DiscoveredEvidence returns the discovered evidence from an earlier
+// evaluation.
+func (u *Uptime) DiscoveredEvidence() UptimeRebootEvidence {
+	return u.runtime.evidenceFound
+}
+
+// ExpectedEvidenceMarkers returns the names of the evidence markers which
+// (if found) indicate a reboot is needed.
+func (u *Uptime) ExpectedEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(u.evidenceExpected)
+}
+
+// DiscoveredEvidenceMarkers returns the names of the evidence markers found
+// by the most recent evaluation.
+func (u *Uptime) DiscoveredEvidenceMarkers() []string {
+	return restart.EvidenceMarkers(u.runtime.evidenceFound)
+}
+
+// SetFoundEvidenceWarningThresholdExceeded records that the
+// WarningThresholdExceeded reboot evidence was found.
+func (u *Uptime) SetFoundEvidenceWarningThresholdExceeded() {
+	logger.Printf("Recording that the WarningThresholdExceeded evidence was found for %q", u)
+	u.runtime.evidenceFound.WarningThresholdExceeded = true
+}
+
+// SetFoundEvidenceCriticalThresholdExceeded records that the
+// CriticalThresholdExceeded reboot evidence was found.
+func (u *Uptime) SetFoundEvidenceCriticalThresholdExceeded() {
+	logger.Printf("Recording that the CriticalThresholdExceeded evidence was found for %q", u)
+	u.runtime.evidenceFound.CriticalThresholdExceeded = true
+}
+
+// HasEvidence indicates whether any evidence was found for an assertion
+// evaluation.
+func (u *Uptime) HasEvidence() bool {
+	return u.runtime.evidenceFound.WarningThresholdExceeded ||
+		u.runtime.evidenceFound.CriticalThresholdExceeded
+}
+
+// Ignored indicates whether the Uptime has been marked as ignored.
+func (u *Uptime) Ignored() bool {
+	if len(u.runtime.pathsMatched) == 0 {
+		return false
+	}
+
+	for _, v := range u.runtime.pathsMatched {
+		if !v.ignored {
+			return false
+		}
+	}
+
+	// The Uptime is ignored *only* if all recorded match path entries are
+	// marked as ignored.
+	return true
+}
+
+// RebootRequired indicates whether an evaluation determined that a reboot is
+// needed. If the Uptime has been marked as ignored (all recorded matched
+// paths marked as ignored) the need for a reboot is not indicated.
+func (u *Uptime) RebootRequired() bool {
+	return !u.Ignored() && u.HasEvidence()
+}
+
+// IsCriticalState indicates whether an evaluation determined that the Uptime
+// is in a CRITICAL state. Unlike other assertions, exceeding the CRITICAL
+// threshold indicates a CRITICAL state. Whether the Uptime has been marked as
+// Ignored is considered. The caller is responsible for filtering the
+// collection prior to calling this method.
+func (u *Uptime) IsCriticalState() bool {
+	switch {
+	case u.Ignored():
+		return false
+	case u.RebootRequired():
+		return u.runtime.evidenceFound.CriticalThresholdExceeded
+	default:
+		return u.Err() != nil
+	}
+}
+
+// IsWarningState indicates whether an evaluation determined that the Uptime
+// is in a WARNING state. Whether the Uptime has been marked as Ignored is
+// considered. The caller is responsible for filtering the collection prior to
+// calling this method.
+func (u *Uptime) IsWarningState() bool {
+	return u.RebootRequired() && !u.runtime.evidenceFound.CriticalThresholdExceeded
+}
+
+// IsOKState indicates whether an evaluation determined that the Uptime is in
+// an OK state. Whether the Uptime has been marked as Ignored is considered.
+// The caller is responsible for filtering the collection prior to calling
+// this method.
+func (u *Uptime) IsOKState() bool {
+	switch {
+	case u.Ignored():
+		return true
+	case u.RebootRequired():
+		return false
+	case u.Err() != nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// RebootReasons returns a list of the reasons associated with the evidence
+// found for an evaluation that indicates a reboot is needed.
+func (u *Uptime) RebootReasons() []string {
+	reasons := make([]string, 0, 1)
+
+	switch {
+	case u.runtime.evidenceFound.CriticalThresholdExceeded:
+		reasons = append(reasons, fmt.Sprintf(
+			"Uptime of %s exceeds critical threshold of %s",
+			FormatDuration(u.Uptime()),
+			FormatDuration(u.critical),
+		))
+
+	case u.runtime.evidenceFound.WarningThresholdExceeded:
+		reasons = append(reasons, fmt.Sprintf(
+			"Uptime of %s exceeds warning threshold of %s",
+			FormatDuration(u.Uptime()),
+			FormatDuration(u.warning),
+		))
+	}
+
+	return reasons
+}
+
+// FormatDuration provides a human readable form of the given amount of
+// uptime using days, hours and minutes (e.g., 45d 2h 13m).
+func FormatDuration(d time.Duration) string {
+	day := 24 * time.Hour
+
+	days := d / day
+	hours := (d % day) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+}
+
+// Root returns the left-most element of a matched path. The uptime has no
+// path; the name of the assertion is returned instead.
+func (mp MatchedPath) Root() string {
+	return mp.name
+}
+
+// Rel returns the relative (unqualified) element of a matched path. The
+// uptime has no path; the name of the assertion is returned instead.
+func (mp MatchedPath) Rel() string {
+	return mp.name
+}
+
+// Base returns the last or right-most "leaf" element of a matched path. The
+// uptime has no path; the name of the assertion is returned instead.
+func (mp MatchedPath) Base() string {
+	return mp.name
+}
+
+// Full returns the qualified matched path value. The uptime has no path; the
+// name of the assertion is returned instead.
+func (mp MatchedPath) Full() string {
+	return mp.name
+}
+
+// String provides a human readable version of the matched path value.
+func (mp MatchedPath) String() string {
+	return mp.Full()
+}
+
+// IgnoredBy returns the ignore pattern which marked the matched path as
+// ignored and whether the matched path has been marked as ignored.
+func (mp MatchedPath) IgnoredBy() (restart.IgnorePattern, bool) {
+	return mp.ignoredBy, mp.ignored
+}
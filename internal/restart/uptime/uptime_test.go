@@ -0,0 +1,151 @@
+// Copyright 2022 Adam Chalkley
+//
+// https://github.com/atc0005/check-restart
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package uptime
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atc0005/check-restart/internal/restart"
+	"github.com/atc0005/check-restart/internal/restart/boot"
+)
+
+const day = 24 * time.Hour
+
+// TestUptimeEvaluate asserts that exceeding the WARNING or CRITICAL uptime
+// threshold maps to the expected state.
+func TestUptimeEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		uptime       time.Duration
+		warning      time.Duration
+		critical     time.Duration
+		wantReboot   bool
+		wantWarning  bool
+		wantCritical bool
+	}{
+		"within thresholds": {
+			uptime:   10 * day,
+			warning:  30 * day,
+			critical: 45 * day,
+		},
+		"warning threshold exceeded": {
+			uptime:      31 * day,
+			warning:     30 * day,
+			critical:    45 * day,
+			wantReboot:  true,
+			wantWarning: true,
+		},
+		"critical threshold exceeded": {
+			uptime:       90 * day,
+			warning:      30 * day,
+			critical:     45 * day,
+			wantReboot:   true,
+			wantCritical: true,
+		},
+		"critical threshold only": {
+			uptime:       90 * day,
+			critical:     45 * day,
+			wantReboot:   true,
+			wantCritical: true,
+		},
+		"warning threshold only": {
+			uptime:      90 * day,
+			warning:     45 * day,
+			wantReboot:  true,
+			wantWarning: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			u := NewUptime(tt.warning, tt.critical)
+			u.SetUptimeProvider(func() (time.Duration, error) { return tt.uptime, nil })
+
+			if err := u.Validate(); err != nil {
+				t.Fatalf("ERROR: failed to validate uptime: %v", err)
+			}
+
+			u.Evaluate()
+
+			if got := u.RebootRequired(); got != tt.wantReboot {
+				t.Errorf("ERROR: got reboot required %t; want %t", got, tt.wantReboot)
+			}
+
+			if got := u.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("ERROR: got warning state %t; want %t", got, tt.wantWarning)
+			}
+
+			if got := u.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("ERROR: got critical state %t; want %t", got, tt.wantCritical)
+			}
+
+			if got := u.IsOKState(); got == tt.wantReboot {
+				t.Errorf("ERROR: got OK state %t; want %t", got, !tt.wantReboot)
+			}
+		})
+	}
+}
+
+// TestUptimeServiceState asserts that a host with no other reboot evidence
+// is in a CRITICAL state once the CRITICAL uptime threshold is exceeded and
+// that the uptime assertion may be ignored.
+func TestUptimeServiceState(t *testing.T) {
+	t.Parallel()
+
+	u := NewUptime(30*day, 45*day)
+	u.SetUptimeProvider(func() (time.Duration, error) { return 90 * day, nil })
+
+	assertions := restart.RebootRequiredAsserters{u}
+	for _, assertion := range assertions {
+		assertion.Evaluate()
+	}
+
+	if got := assertions.ServiceState().Label; got != "CRITICAL" {
+		t.Errorf("ERROR: got service state %s; want CRITICAL", got)
+	}
+
+	ignorePattern, err := restart.ParseIgnorePattern("uptime:exact:uptime")
+	if err != nil {
+		t.Fatalf("ERROR: failed to parse ignore pattern: %v", err)
+	}
+
+	assertions.Filter(restart.IgnorePatterns{ignorePattern})
+
+	if got := assertions.ServiceState().Label; got != "OK" {
+		t.Errorf("ERROR: got service state %s after filtering; want OK", got)
+	}
+}
+
+// TestUptimeErrors asserts that invalid thresholds are rejected and that a
+// failure to determine the uptime results in a CRITICAL state.
+func TestUptimeErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, u := range map[string]*Uptime{
+		"no thresholds":            NewUptime(0, 0),
+		"negative threshold":       NewUptime(-day, 45*day),
+		"warning exceeds critical": NewUptime(45*day, 30*day),
+	} {
+		if err := u.Validate(); err == nil {
+			t.Errorf("ERROR: expected validation error for %s", name)
+		}
+	}
+
+	u := NewUptime(30*day, 45*day)
+	u.SetUptimeProvider(func() (time.Duration, error) { return 0, boot.ErrUnsupportedPlatform })
+	u.Evaluate()
+
+	if !errors.Is(u.Err(), boot.ErrUnsupportedPlatform) || !u.IsCriticalState() || u.IsOKState() {
+		t.Errorf("ERROR: got error %v and critical state %t; want CRITICAL state", u.Err(), u.IsCriticalState())
+	}
+}
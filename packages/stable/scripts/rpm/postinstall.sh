@@ -17,37 +17,41 @@ project_releases="${project_repo}/releases"
 project_issues="${project_repo}/issues"
 project_discussions="${project_repo}/discussions"
 
-plugin_name="check_reboot"
+plugin_names=("check_reboot" "check_restart")
 plugin_path="/usr/lib64/nagios/plugins"
 
-# Set required SELinux context to allow plugin use when SELinux is enabled.
-if [ -f "${plugin_path}/${plugin_name}" ]; then
+for plugin_name in "${plugin_names[@]}"; do
 
-    # Make sure we can locate the selinuxenabled binary.
-    if [ -x "$(command -v selinuxenabled)" ]; then
-        selinuxenabled
+    # Set required SELinux context to allow plugin use when SELinux is enabled.
+    if [ -f "${plugin_path}/${plugin_name}" ]; then
 
-        if [ $? -ne 0 ]; then
-            echo -e "\nSELinux is not enabled, skipping application of contexts."
-        else
-            # SELinux is enabled. Set context.
-            echo -e "\nApplying SELinux contexts on ${plugin_path}/${plugin_name} ..."
-            restorecon -v ${plugin_path}/${plugin_name}
+        # Make sure we can locate the selinuxenabled binary.
+        if [ -x "$(command -v selinuxenabled)" ]; then
+            selinuxenabled
 
-            if [ $? -eq 0 ]; then
-                echo "Successfully applied SELinux contexts on ${plugin_path}/${plugin_name}"
+            if [ $? -ne 0 ]; then
+                echo -e "\nSELinux is not enabled, skipping application of contexts."
             else
-                echo "Failed to set SELinux contexts on ${plugin_path}/${plugin_name}"
+                # SELinux is enabled. Set context.
+                echo -e "\nApplying SELinux contexts on ${plugin_path}/${plugin_name} ..."
+                restorecon -v ${plugin_path}/${plugin_name}
+
+                if [ $? -eq 0 ]; then
+                    echo "Successfully applied SELinux contexts on ${plugin_path}/${plugin_name}"
+                else
+                    echo "Failed to set SELinux contexts on ${plugin_path}/${plugin_name}"
+                fi
             fi
+
+        else
+            echo "Error: Failed to locate selinuxenabled command." >&2
         fi
 
     else
-        echo "Error: Failed to locate selinuxenabled command." >&2
+        echo "${plugin_path}/${plugin_name} could not be found!"
     fi
 
-else
-    echo "${plugin_path}/${plugin_name} could not be found!"
-fi
+done
 
 echo
 echo "Thank you for installing packages provided by the ${project_fq_name} project!"